@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/backup"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+)
+
+const usage = `Usage: go run ./cmd/backup COMMAND ARCHIVE [flags]
+
+Commands:
+  create ARCHIVE    export every table to ARCHIVE (a .tar.gz file)
+  restore ARCHIVE   load ARCHIVE, then verify row counts and checksums
+  verify ARCHIVE    compare the database with ARCHIVE without changing it
+  inspect ARCHIVE   print the manifest of ARCHIVE
+
+Flags:
+  --db DSN          postgres:// URL or SQLite file; defaults to the
+                    configured database_url
+  --clean           restore: delete the rows of the restored tables first
+  --create-tables   restore: create missing tables from the manifest
+  --force           restore: ignore a schema version mismatch
+  --no-verify       restore: skip verification`
+
+func main() {
+	if len(os.Args) < 3 {
+		log.Fatal(usage)
+	}
+	command, archive := os.Args[1], os.Args[2]
+
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	dsn := fs.String("db", "", "")
+	clean := fs.Bool("clean", false, "")
+	createTables := fs.Bool("create-tables", false, "")
+	force := fs.Bool("force", false, "")
+	noVerify := fs.Bool("no-verify", false, "")
+	fs.Parse(os.Args[3:])
+
+	if command == "inspect" {
+		m := readManifest(archive)
+		printManifest(m)
+		return
+	}
+
+	if *dsn == "" {
+		cfg, err := config.Load(nil)
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+		*dsn = cfg.DatabaseURL
+	}
+	db, dialect, err := backup.Open(*dsn)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	target := *dsn
+	if dialect == backup.Postgres {
+		target = (&config.Config{DatabaseURL: *dsn}).RedactedDatabaseURL()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "create":
+		fmt.Printf("💾 Backing up %s to %s\n", target, archive)
+		f, err := os.Create(archive)
+		if err != nil {
+			log.Fatalf("Failed to create archive: %v", err)
+		}
+		m, err := backup.Dump(ctx, db, dialect, f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(archive)
+			log.Fatalf("❌ Backup failed: %v", err)
+		}
+		printManifest(m)
+		fmt.Printf("✅ Backed up %d table(s)\n", len(m.Tables))
+	case "restore":
+		fmt.Printf("🔄 Restoring %s into %s\n", archive, target)
+		f, err := os.Open(archive)
+		if err != nil {
+			log.Fatalf("Failed to open archive: %v", err)
+		}
+		m, err := backup.Restore(ctx, db, dialect, f,
+			backup.WithClean(*clean), backup.WithCreateTables(*createTables), backup.WithIgnoreSchemaVersion(*force))
+		f.Close()
+		if err != nil {
+			log.Fatalf("❌ Restore failed: %v", err)
+		}
+		fmt.Printf("✅ Restored %d table(s)\n", len(m.Tables))
+		if !*noVerify {
+			verify(ctx, db, m)
+		}
+	case "verify":
+		fmt.Printf("🔍 Verifying %s against %s\n", target, archive)
+		verify(ctx, db, readManifest(archive))
+	default:
+		log.Fatalf("Unknown command %q\n\n%s", command, usage)
+	}
+}
+
+func readManifest(archive string) *backup.Manifest {
+	f, err := os.Open(archive)
+	if err != nil {
+		log.Fatalf("Failed to open archive: %v", err)
+	}
+	defer f.Close()
+	m, err := backup.ReadManifest(f)
+	if err != nil {
+		log.Fatalf("Failed to read archive: %v", err)
+	}
+	return m
+}
+
+// verify compares the database with the manifest and exits 1 on differences
+func verify(ctx context.Context, db *sql.DB, m *backup.Manifest) {
+	mismatches, err := backup.Verify(ctx, db, m)
+	if err != nil {
+		log.Fatalf("❌ Verification failed: %v", err)
+	}
+	for _, mismatch := range mismatches {
+		fmt.Printf("⚠️ %s\n", mismatch)
+	}
+	if len(mismatches) > 0 {
+		fmt.Printf("❌ %d of %d table(s) differ from the archive\n", len(mismatches), len(m.Tables))
+		os.Exit(1)
+	}
+	fmt.Printf("✅ All %d table(s) match the archive\n", len(m.Tables))
+}
+
+func printManifest(m *backup.Manifest) {
+	version := "none"
+	if m.SchemaTable != "" {
+		version = fmt.Sprintf("%d (%s)", m.SchemaVersion, m.SchemaTable)
+	}
+	fmt.Printf("Format %d, %s, created %s, schema version %s\n\n", m.FormatVersion, m.Dialect, m.CreatedAt.Format("2006-01-02 15:04:05 MST"), version)
+	fmt.Printf("%-32s %10s  %s\n", "TABLE", "ROWS", "CHECKSUM")
+	for _, t := range m.Tables {
+		fmt.Printf("%-32s %10d  %.16s\n", t.Name, t.Rows, t.Checksum)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("Usage: go run cmd/config/main.go [print|validate] [flags]")
+	}
+
+	command := os.Args[1]
+	cfg, err := config.Load(os.Args[2:])
+
+	switch command {
+	case "print":
+		if err != nil {
+			log.Fatalf("❌ Invalid configuration:\n%v", err)
+		}
+		printConfig(cfg)
+	case "validate":
+		if err != nil {
+			log.Fatalf("❌ Invalid configuration:\n%v", err)
+		}
+		fmt.Printf("✅ Configuration is valid (env: %s)\n", cfg.Env)
+	default:
+		log.Fatal("Invalid command. Use 'print' or 'validate'")
+	}
+}
+
+func printConfig(cfg *config.Config) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, s := range cfg.Settings() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+	}
+	w.Flush()
+}
@@ -1,41 +1,205 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/database"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/migrate"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/migrations"
 )
 
+// migrationsDir is where create writes new files, relative to the backend root
+const migrationsDir = "migrations"
+
+const usage = `Usage: go run ./cmd/migrate COMMAND [ARGS] [--strict] [--dry-run] [config flags]
+
+Commands:
+  up             apply all pending migrations
+  down [N]       roll back the last N applied migrations (default 1)
+  goto VERSION   migrate up or down to VERSION (0 rolls back everything)
+  redo           roll back and reapply the last applied migration
+  status         list migrations and whether they are applied
+  create NAME    create an empty migration in ./migrations
+
+Flags:
+  --strict       refuse to run when an applied migration file has changed
+  --dry-run      print the SQL up, down, goto or redo would execute, in order,
+                 without changing the database`
+
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run cmd/migrate/main.go [up|down]")
+		log.Fatal(usage)
 	}
+	command := os.Args[1]
+	args, flags := splitArgs(os.Args[2:])
+	flags, strict := extractFlag(flags, "strict")
+	flags, dryRun := extractFlag(flags, "dry-run")
 
-	cfg := config.Load()
-	direction := os.Args[1]
+	if command == "create" {
+		if len(args) != 1 {
+			log.Fatal("Usage: go run ./cmd/migrate create NAME")
+		}
+		path, err := migrate.Create(migrationsDir, args[0])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		fmt.Printf("✅ Created %s\n", path)
+		return
+	}
+
+	cfg, err := config.Load(flags)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
 
-	switch direction {
+	opts := []migrate.Option{migrate.WithStrict(strict)}
+	if dryRun {
+		opts = append(opts, migrate.WithDryRun(os.Stdout))
+	}
+	m, err := migrate.New(db, migrations.FS, opts...)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if dryRun {
+		fmt.Printf("-- Dry run of %s against %s\n\n", command, cfg.RedactedDatabaseURL())
+	} else {
+		fmt.Printf("🔄 Running %s against %s\n", command, cfg.RedactedDatabaseURL())
+	}
+	var applied []migrate.Applied
+	switch command {
 	case "up":
-		runMigrationsUp(cfg)
+		applied, err = m.Up(ctx)
 	case "down":
-		runMigrationsDown(cfg)
+		n := 1
+		if len(args) > 0 {
+			n, err = strconv.Atoi(args[0])
+			if err != nil {
+				log.Fatalf("Invalid count %q", args[0])
+			}
+		}
+		applied, err = m.Down(ctx, n)
+	case "goto":
+		if len(args) != 1 {
+			log.Fatal("Usage: go run ./cmd/migrate goto VERSION")
+		}
+		version, parseErr := strconv.ParseInt(args[0], 10, 64)
+		if parseErr != nil {
+			log.Fatalf("Invalid version %q", args[0])
+		}
+		applied, err = m.Goto(ctx, version)
+	case "redo":
+		applied, err = m.Redo(ctx)
+	case "status":
+		printStatus(ctx, m, strict)
+		return
 	default:
-		log.Fatal("Invalid direction. Use 'up' or 'down'")
+		log.Fatalf("Unknown command %q\n\n%s", command, usage)
+	}
+
+	if dryRun {
+		if err != nil {
+			log.Fatalf("❌ Dry run failed: %v", err)
+		}
+		fmt.Printf("-- %d migration(s) would be applied\n", len(applied))
+		return
 	}
+
+	printApplied(applied)
+	if err != nil {
+		log.Fatalf("❌ Migration failed: %v", err)
+	}
+	if len(applied) == 0 {
+		fmt.Println("✅ Nothing to do, the schema is up to date")
+		return
+	}
+	fmt.Printf("✅ Applied %d migration(s)\n", len(applied))
+}
+
+// extractFlag removes the boolean flag --name (or -name) from args and
+// reports whether it was present
+func extractFlag(args []string, name string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == "--"+name || arg == "-"+name {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// splitArgs separates the positional arguments from the config flags that follow them
+func splitArgs(args []string) (positional, flags []string) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return args[:i], args[i:]
+		}
+	}
+	return args, nil
 }
 
-func runMigrationsUp(cfg *config.Config) {
-	fmt.Printf("🔄 Running migrations UP against %s\n", cfg.DatabaseURL)
-	// TODO: Implement actual migration logic
-	// This would typically use a migration library like golang-migrate
-	fmt.Println("✅ Migrations completed successfully")
+func printApplied(applied []migrate.Applied) {
+	for _, a := range applied {
+		arrow := "⬆️"
+		if a.Direction == migrate.Down {
+			arrow = "⬇️"
+		}
+		fmt.Printf("%s  %-5s %s (%s)\n", arrow, a.Direction, a.Migration, a.Duration.Round(time.Millisecond))
+	}
 }
 
-func runMigrationsDown(cfg *config.Config) {
-	fmt.Printf("🔄 Running migrations DOWN against %s\n", cfg.DatabaseURL)
-	// TODO: Implement actual migration logic
-	// This would typically use a migration library like golang-migrate
-	fmt.Println("✅ Migrations rollback completed successfully")
+// printStatus lists the migrations; in strict mode it exits with an error
+// when an applied migration has changed
+func printStatus(ctx context.Context, m *migrate.Migrator, strict bool) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		log.Fatalf("Failed to read migration status: %v", err)
+	}
+
+	pending, changed := 0, 0
+	fmt.Printf("%-16s %-40s %-26s %s\n", "VERSION", "NAME", "APPLIED AT", "NOTE")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Local().Format(time.RFC3339)
+		} else {
+			pending++
+		}
+		name, note := s.Name, ""
+		switch {
+		case s.Missing:
+			name = "(missing file)"
+		case s.Changed:
+			note = "⚠️ changed since applied"
+			changed++
+		}
+		fmt.Printf("%-16d %-40s %-26s %s\n", s.Version, name, appliedAt, note)
+	}
+	fmt.Printf("\n%d migration(s), %d pending\n", len(statuses), pending)
+	if changed > 0 {
+		fmt.Printf("⚠️ %d applied migration(s) changed; restore the original files or add new migrations instead\n", changed)
+		if strict {
+			os.Exit(1)
+		}
+	}
 }
@@ -3,48 +3,235 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/database"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/handlers"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/health"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jwtservice"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/metrics"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/migrate"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/security"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/tracing"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/migrations"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/pkg/cors"
 )
 
+// minFreeDiskSpace is the free disk space below which the server reports not ready
+const minFreeDiskSpace = 100 << 20 // 100 MiB
+
 func main() {
 	// Load configuration
-	cfg := config.Load()
+	watcher, err := config.NewWatcher(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	cfg := watcher.Config()
+
+	// Configure logging; the level follows configuration reloads
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.LogLevel)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+	logSampleRate := new(middleware.SampleRateVar)
+	logSampleRate.Set(cfg.LogSampleRate)
+	slog.SetDefault(logger)
+
+	// CORS policies follow configuration reloads
+	corsEngine, err := cors.New(corsConfig(cfg), logger)
+	if err != nil {
+		log.Fatalf("Failed to configure CORS: %v", err)
+	}
+
+	// Rate limits follow configuration reloads; the store is fixed at startup
+	var limiter *ratelimit.Limiter
+	if cfg.EnableRateLimit {
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimitStore == "redis" {
+			redisClient := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr})
+			defer redisClient.Close()
+			store = ratelimit.NewRedisStore(redisClient, "")
+		}
+		limits, err := rateLimitConfig(cfg)
+		if err == nil {
+			limiter, err = ratelimit.New(store, limits)
+		}
+		if err != nil {
+			log.Fatalf("Failed to configure rate limiting: %v", err)
+		}
+	}
+
+	watcher.Subscribe(func(old, new *config.Config) {
+		logLevel.Set(new.LogLevel)
+		logSampleRate.Set(new.LogSampleRate)
+		if err := corsEngine.Update(corsConfig(new)); err != nil {
+			log.Printf("❌ Failed to update CORS policy: %v", err)
+		}
+		if limiter != nil {
+			limits, err := rateLimitConfig(new)
+			if err == nil {
+				err = limiter.Update(limits)
+			}
+			if err != nil {
+				log.Printf("❌ Failed to update rate limits: %v", err)
+			}
+		}
+		if old.Port != new.Port || old.DatabaseURL != new.DatabaseURL {
+			log.Println("⚠️ Port and database changes take effect after a restart")
+		}
+		if old.JWTSecret != new.JWTSecret || old.AccessTokenTTL != new.AccessTokenTTL || old.RefreshTokenTTL != new.RefreshTokenTTL {
+			log.Println("⚠️ JWT secret and token lifetime changes take effect after a restart")
+		}
+		if !slices.Equal(old.TrustedProxies, new.TrustedProxies) {
+			log.Println("⚠️ Trusted proxy changes take effect after a restart")
+		}
+		if old.EnableRateLimit != new.EnableRateLimit || old.RateLimitStore != new.RateLimitStore {
+			log.Println("⚠️ Enabling rate limiting or changing its store takes effect after a restart")
+		}
+		if old.RedisAddr != new.RedisAddr || old.ShutdownTimeout != new.ShutdownTimeout {
+			log.Println("⚠️ Redis address and shutdown timeout changes take effect after a restart")
+		}
+		if old.EnableMetrics != new.EnableMetrics || old.MetricsAddr != new.MetricsAddr {
+			log.Println("⚠️ Enabling metrics or changing their address takes effect after a restart")
+		}
+		if old.EnableTracing != new.EnableTracing || old.TracingExporter != new.TracingExporter ||
+			old.OTLPEndpoint != new.OTLPEndpoint || old.TracingSampleRatio != new.TracingSampleRatio {
+			log.Println("⚠️ Tracing changes take effect after a restart")
+		}
+		log.Println("✅ Configuration reloaded")
+	})
+
+	// Reload configuration on SIGHUP or config file changes
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go watcher.Run(watchCtx)
+
+	// Set up tracing before anything creates spans
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
+	// Open the database connection pool
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Set up authentication
+	tokens, err := jwtservice.NewJWTService(cfg.JWTSecret, jwtservice.WithTTL(cfg.AccessTokenTTL))
+	if err != nil {
+		log.Fatalf("Failed to configure JWT: %v", err)
+	}
+	authHandler := handlers.NewAuthHandler(
+		auth.NewService(auth.NewPostgresStore(db), tokens, security.NewPasswordService(), cfg.RefreshTokenTTL))
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	// Register readiness checks
+	readiness := health.NewRegistry()
+	readiness.Register("database", health.DatabaseChecker(db))
+	// Not ready while any embedded migration is unapplied
+	readiness.Register("migrations", health.MigrationChecker(migrator.Pending), health.WithCacheTTL(30*time.Second))
+	readiness.Register("disk", health.DiskSpaceChecker(".", minFreeDiskSpace), health.WithCacheTTL(30*time.Second))
+	if cfg.RedisAddr != "" {
+		readiness.Register("redis", health.RedisChecker(cfg.RedisAddr))
+	}
 
 	// Initialize Gin router
-	if cfg.Env == "production" {
+	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
+	// Only believe X-Forwarded-For from known proxies; the client IP keys
+	// rate limits, so anyone else could pick a fresh one per request
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Failed to set trusted proxies: %v", err)
+	}
 
 	// Add middleware
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-	router.Use(middleware.CORS())
+	loggerConfig := middleware.DefaultLoggerConfig()
+	loggerConfig.Logger = logger
+	loggerConfig.SampleRateVar = logSampleRate
+	loggerConfig.SkipPaths = []string{"/health", "/livez", "/readyz"}
+	router.Use(middleware.RequestLogger(loggerConfig))
+	router.Use(tracing.Middleware())
+
+	var adminServer *http.Server
+	// publicMetrics is served on the main router, once the global middleware is in place
+	var publicMetrics http.Handler
+	if cfg.EnableMetrics {
+		m := metrics.New()
+		if err := m.RegisterDB(db, "coursedb"); err != nil {
+			log.Fatalf("Failed to register database metrics: %v", err)
+		}
+		router.Use(m.Middleware())
 
-	// Health check endpoint
+		if cfg.MetricsAddr != "" {
+			// Keep metrics off the public port
+			adminMux := http.NewServeMux()
+			adminMux.Handle("/metrics", m.Handler())
+			adminServer = &http.Server{Addr: cfg.MetricsAddr, Handler: adminMux, ReadHeaderTimeout: cfg.ReadTimeout}
+		} else {
+			publicMetrics = m.Handler()
+		}
+	}
+
+	router.Use(middleware.Recovery())
+	router.Use(middleware.CORS(corsEngine))
+	// Before rate limiting, so requests can be counted by user
+	router.Use(middleware.Authenticate(tokens))
+	if limiter != nil {
+		// After CORS, so preflights are not counted and 429s carry CORS headers
+		router.Use(middleware.RateLimit(limiter))
+	}
+
+	// Health check endpoints
 	router.GET("/health", handlers.HealthCheck)
+	router.GET("/livez", handlers.Livez)
+	router.GET("/readyz", handlers.Readyz(readiness))
+	if publicMetrics != nil {
+		router.GET("/metrics", gin.WrapH(publicMetrics))
+	}
 
 	// API routes
 	api := router.Group("/api/v1")
 	{
 		api.GET("/ping", handlers.Ping)
-		// Add more routes as needed
+
+		authRoutes := api.Group("/auth")
+		authRoutes.POST("/register", authHandler.Register)
+		authRoutes.POST("/login", authHandler.Login)
+		authRoutes.POST("/refresh", authHandler.Refresh)
+		authRoutes.POST("/logout", authHandler.Logout)
+
+		protected := middleware.Protected(api, "/")
+		protected.GET("/me", authHandler.Me)
 	}
 
 	// Create HTTP server
 	server := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+		Addr:         ":" + cfg.Port,
+		Handler:      router,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
 	}
 
 	// Start server in a goroutine
@@ -55,19 +242,92 @@ func main() {
 		}
 	}()
 
+	if adminServer != nil {
+		go func() {
+			log.Printf("📈 Metrics available on %s/metrics", cfg.MetricsAddr)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start metrics server: %v", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("🛑 Shutting down server...")
 
-	// Give outstanding requests 10 seconds to complete
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Fail readiness first so no new traffic is routed here while draining
+	readiness.SetShuttingDown()
+
+	// Give outstanding requests time to complete
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
+	// Keep going when requests are stuck: their traces matter most then
+	forced := false
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
+		forced = true
 	}
 
+	// The drain may have used up ctx, so closing the metrics server and
+	// flushing traces get their own deadline
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer flushCancel()
+
+	if adminServer != nil {
+		if err := adminServer.Shutdown(flushCtx); err != nil {
+			log.Printf("Metrics server forced to shutdown: %v", err)
+		}
+	}
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+
+	if forced {
+		cancel()
+		flushCancel()
+		log.Fatal("❌ Server exited before all requests completed")
+	}
 	log.Println("✅ Server exited")
 }
+
+// corsConfig builds the CORS policies: API routes allow the configured
+// origins with credentials, health probes may be read from any origin
+func corsConfig(cfg *config.Config) cors.Config {
+	api := cors.DefaultPolicy()
+	api.AllowedOrigins = cfg.CORSOrigins
+	api.AllowCredentials = true
+	api.MaxAge = cfg.CORSMaxAge
+	api.ExposedHeaders = append(api.ExposedHeaders,
+		middleware.RateLimitLimitHeader, middleware.RateLimitRemainingHeader, middleware.RateLimitResetHeader,
+		middleware.RateLimitPolicyHeader, middleware.RetryAfterHeader)
+
+	probes := cors.DefaultPolicy()
+	probes.AllowedOrigins = []string{"*"}
+	probes.AllowedMethods = []string{http.MethodGet}
+	probes.MaxAge = cfg.CORSMaxAge
+
+	return cors.Config{
+		Default: api,
+		Groups: map[string]cors.Policy{
+			"/health": probes,
+			"/livez":  probes,
+			"/readyz": probes,
+		},
+	}
+}
+
+// rateLimitConfig builds the per-route-group rate limits
+func rateLimitConfig(cfg *config.Config) (ratelimit.Config, error) {
+	keyBy, err := ratelimit.ParseKeySources(cfg.RateLimitKey)
+	if err != nil {
+		return ratelimit.Config{}, err
+	}
+	groups, err := ratelimit.ParseGroups(cfg.RateLimits, ratelimit.Algorithm(cfg.RateLimitAlgorithm))
+	if err != nil {
+		return ratelimit.Config{}, err
+	}
+	return ratelimit.Config{KeyBy: keyBy, Groups: groups}, nil
+}
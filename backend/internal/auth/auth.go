@@ -0,0 +1,218 @@
+// Package auth implements registration, login and token refresh on top of
+// jwtservice, security and userdomain.
+//
+// Clients receive a short-lived JWT access token and an opaque refresh
+// token. Refresh tokens are single use: every refresh revokes the presented
+// token and issues a new pair. Presenting an already rotated token revokes
+// all sessions of its user, since the token has most likely been stolen;
+// tokens ended by logout are merely rejected.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jwtservice"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/security"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/userdomain"
+)
+
+// TokenType is the scheme clients send access tokens with
+const TokenType = "Bearer"
+
+var (
+	// ErrInvalidUser wraps validation failures of registration data
+	ErrInvalidUser = errors.New("invalid user")
+	// ErrEmailTaken is returned when registering an email that already exists
+	ErrEmailTaken = errors.New("email already registered")
+	// ErrInvalidCredentials is returned for unknown emails and wrong passwords alike
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrUserNotFound is returned by Store lookups of unknown users
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// TokenPair is issued on registration, login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // access token lifetime in seconds
+}
+
+// Service authenticates users
+type Service struct {
+	store      Store
+	tokens     *jwtservice.JWTService
+	passwords  *security.PasswordService
+	refreshTTL time.Duration
+	now        func() time.Time
+
+	// dummyHash is compared against on logins of unknown emails, so they
+	// take as long as wrong passwords
+	dummyHash func() (string, error)
+}
+
+// NewService creates an authentication service issuing refresh tokens
+// valid for refreshTTL
+func NewService(store Store, tokens *jwtservice.JWTService, passwords *security.PasswordService, refreshTTL time.Duration) *Service {
+	return &Service{
+		store:      store,
+		tokens:     tokens,
+		passwords:  passwords,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+		dummyHash: sync.OnceValues(func() (string, error) {
+			return passwords.HashPassword(rand.Text())
+		}),
+	}
+}
+
+// Register creates a user and signs them in
+func (s *Service) Register(ctx context.Context, email, name, password string) (*userdomain.User, *TokenPair, error) {
+	user, err := userdomain.NewUser(email, name, password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidUser, err)
+	}
+	user.PasswordHash, err = s.passwords.HashPassword(user.Password)
+	if err != nil {
+		return nil, nil, err
+	}
+	user.Password = ""
+
+	if err := s.store.CreateUser(ctx, user); err != nil {
+		return nil, nil, err
+	}
+	pair, err := s.issue(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, pair, nil
+}
+
+// Login checks the user's password and signs them in
+func (s *Service) Login(ctx context.Context, email, password string) (*userdomain.User, *TokenPair, error) {
+	user, err := s.store.UserByEmail(ctx, normalizeEmail(email))
+	if errors.Is(err, ErrUserNotFound) {
+		if hash, err := s.dummyHash(); err == nil {
+			s.passwords.VerifyPassword(password, hash)
+		}
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.passwords.VerifyPassword(password, user.PasswordHash) {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	pair, err := s.issue(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, pair, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	hash := hashToken(refreshToken)
+	stored, err := s.store.RefreshToken(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if stored.RevokedAt != nil {
+		if stored.RevokedReason == RevokedRotated {
+			// A rotated token was replayed: end every session of the user
+			if err := s.store.RevokeUserRefreshTokens(ctx, stored.UserID, now, RevokedReuse); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if !now.Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	revoked, err := s.store.RevokeRefreshToken(ctx, hash, now, RevokedRotated)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		// Lost a race against a concurrent refresh with the same token
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.store.UserByID(ctx, stored.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user)
+}
+
+// Logout revokes a refresh token. Unknown and already revoked tokens are
+// ignored. Access tokens stay valid until they expire.
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return ErrInvalidRefreshToken
+	}
+	_, err := s.store.RevokeRefreshToken(ctx, hashToken(refreshToken), s.now(), RevokedLogout)
+	return err
+}
+
+// User returns the user with the given ID
+func (s *Service) User(ctx context.Context, id int) (*userdomain.User, error) {
+	return s.store.UserByID(ctx, id)
+}
+
+// issue creates an access token and a stored refresh token for user
+func (s *Service) issue(ctx context.Context, user *userdomain.User) (*TokenPair, error) {
+	access, err := s.tokens.GenerateToken(user.ID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := rand.Text()
+	now := s.now()
+	err = s.store.SaveRefreshToken(ctx, RefreshToken{
+		Hash:      hashToken(refresh),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    TokenType,
+		ExpiresIn:    int(s.tokens.TTL().Seconds()),
+	}, nil
+}
+
+// hashToken returns the hex-encoded SHA-256 hash of a refresh token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeEmail matches the normalization of userdomain.NewUser
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jwtservice"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/security"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/userdomain"
+)
+
+// memoryStore is an in-memory Store for tests
+type memoryStore struct {
+	mu     sync.Mutex
+	users  []*userdomain.User
+	tokens map[string]*RefreshToken
+}
+
+func (s *memoryStore) CreateUser(ctx context.Context, u *userdomain.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.users {
+		if existing.Email == u.Email {
+			return ErrEmailTaken
+		}
+	}
+	u.ID = len(s.users) + 1
+	stored := *u
+	s.users = append(s.users, &stored)
+	return nil
+}
+
+func (s *memoryStore) UserByEmail(ctx context.Context, email string) (*userdomain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == email {
+			found := *u
+			return &found, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s *memoryStore) UserByID(ctx context.Context, id int) (*userdomain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id < 1 || id > len(s.users) {
+		return nil, ErrUserNotFound
+	}
+	found := *s.users[id-1]
+	return &found, nil
+}
+
+func (s *memoryStore) SaveRefreshToken(ctx context.Context, t RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tokens == nil {
+		s.tokens = make(map[string]*RefreshToken)
+	}
+	s.tokens[t.Hash] = &t
+	return nil
+}
+
+func (s *memoryStore) RefreshToken(ctx context.Context, hash string) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[hash]
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+	found := *t
+	return &found, nil
+}
+
+func (s *memoryStore) RevokeRefreshToken(ctx context.Context, hash string, at time.Time, reason RevokeReason) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[hash]
+	if !ok || t.RevokedAt != nil {
+		return false, nil
+	}
+	t.RevokedAt = &at
+	t.RevokedReason = reason
+	return true, nil
+}
+
+func (s *memoryStore) RevokeUserRefreshTokens(ctx context.Context, userID int, at time.Time, reason RevokeReason) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &at
+			t.RevokedReason = reason
+		}
+	}
+	return nil
+}
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	tokens, err := jwtservice.NewJWTService("test-secret", jwtservice.WithTTL(15*time.Minute))
+	if err != nil {
+		t.Fatalf("NewJWTService() failed: %v", err)
+	}
+	return NewService(&memoryStore{}, tokens, security.NewPasswordService(), time.Hour)
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	user, pair, err := svc.Register(ctx, "Alice@Example.com", "Alice", "Password123")
+	if err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	if user.ID == 0 || user.Email != "alice@example.com" || user.Password != "" || user.PasswordHash == "" {
+		t.Errorf("Unexpected user %+v", user)
+	}
+	if pair.AccessToken == "" || pair.RefreshToken == "" || pair.TokenType != TokenType || pair.ExpiresIn != 900 {
+		t.Errorf("Unexpected token pair %+v", pair)
+	}
+
+	if _, _, err := svc.Register(ctx, "alice@example.com", "Alice", "Password123"); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("Expected ErrEmailTaken, got %v", err)
+	}
+	if _, _, err := svc.Register(ctx, "bob@example.com", "Bob", "weak"); !errors.Is(err, ErrInvalidUser) {
+		t.Errorf("Expected ErrInvalidUser, got %v", err)
+	}
+	if _, _, err := svc.Register(ctx, "carol@example.com", "Carol", "Password1"+strings.Repeat("x", 64)); !errors.Is(err, ErrInvalidUser) {
+		t.Errorf("Expected ErrInvalidUser for a password over 72 bytes, got %v", err)
+	}
+
+	loggedIn, _, err := svc.Login(ctx, " ALICE@example.com", "Password123")
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if loggedIn.ID != user.ID {
+		t.Errorf("Expected user %d, got %d", user.ID, loggedIn.ID)
+	}
+	if _, _, err := svc.Login(ctx, "alice@example.com", "Wrong123"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for a wrong password, got %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "nobody@example.com", "Password123"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for an unknown email, got %v", err)
+	}
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	_, first, err := svc.Register(ctx, "alice@example.com", "Alice", "Password123")
+	if err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	second, err := svc.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() failed: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("Expected a new refresh token")
+	}
+
+	// Replaying the rotated token ends every session
+	if _, err := svc.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected ErrInvalidRefreshToken on reuse, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected reuse to revoke the newer token too, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, "unknown"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected ErrInvalidRefreshToken for an unknown token, got %v", err)
+	}
+}
+
+func TestRefreshExpired(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	_, pair, err := svc.Register(ctx, "alice@example.com", "Alice", "Password123")
+	if err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := svc.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected ErrInvalidRefreshToken for an expired token, got %v", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	_, pair, err := svc.Register(ctx, "alice@example.com", "Alice", "Password123")
+	if err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	if err := svc.Logout(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("Logout() failed: %v", err)
+	}
+	if _, err := svc.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected ErrInvalidRefreshToken after logout, got %v", err)
+	}
+	if err := svc.Logout(ctx, pair.RefreshToken); err != nil {
+		t.Errorf("Expected repeated logout to succeed, got %v", err)
+	}
+}
+
+func TestLoggedOutTokenDoesNotEndOtherSessions(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	_, phone, err := svc.Register(ctx, "alice@example.com", "Alice", "Password123")
+	if err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	_, laptop, err := svc.Login(ctx, "alice@example.com", "Password123")
+	if err != nil {
+		t.Fatalf("Login() failed: %v", err)
+	}
+	if err := svc.Logout(ctx, phone.RefreshToken); err != nil {
+		t.Fatalf("Logout() failed: %v", err)
+	}
+
+	// A stale client retrying with its logged out token is only rejected
+	if _, err := svc.Refresh(ctx, phone.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected ErrInvalidRefreshToken after logout, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, laptop.RefreshToken); err != nil {
+		t.Errorf("Expected the other session to survive, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/userdomain"
+)
+
+// uniqueViolation is the Postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+// RefreshToken is a stored refresh token. Only the SHA-256 hash of the token
+// is kept, so a leaked table cannot be used to mint sessions.
+type RefreshToken struct {
+	Hash          string
+	UserID        int
+	ExpiresAt     time.Time
+	RevokedAt     *time.Time
+	RevokedReason RevokeReason
+	CreatedAt     time.Time
+}
+
+// RevokeReason records why a refresh token was revoked
+type RevokeReason string
+
+const (
+	// RevokedRotated marks a token exchanged for a new pair by Refresh
+	RevokedRotated RevokeReason = "rotated"
+	// RevokedLogout marks a token ended by Logout
+	RevokedLogout RevokeReason = "logout"
+	// RevokedReuse marks the tokens revoked because a rotated token was replayed
+	RevokedReuse RevokeReason = "reuse"
+)
+
+// Store persists users and refresh tokens
+type Store interface {
+	// CreateUser inserts u and sets its ID; it fails with ErrEmailTaken
+	// when the email is already registered
+	CreateUser(ctx context.Context, u *userdomain.User) error
+	// UserByEmail and UserByID fail with ErrUserNotFound
+	UserByEmail(ctx context.Context, email string) (*userdomain.User, error)
+	UserByID(ctx context.Context, id int) (*userdomain.User, error)
+
+	SaveRefreshToken(ctx context.Context, t RefreshToken) error
+	// RefreshToken fails with ErrInvalidRefreshToken for unknown hashes
+	RefreshToken(ctx context.Context, hash string) (*RefreshToken, error)
+	// RevokeRefreshToken revokes the token and reports whether it was still
+	// active, so concurrent rotations of the same token cannot both succeed
+	RevokeRefreshToken(ctx context.Context, hash string, at time.Time, reason RevokeReason) (bool, error)
+	RevokeUserRefreshTokens(ctx context.Context, userID int, at time.Time, reason RevokeReason) error
+}
+
+// PostgresStore is a Store backed by the users and refresh_tokens tables
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a store using db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// CreateUser inserts u and sets its ID
+func (s *PostgresStore) CreateUser(ctx context.Context, u *userdomain.User) error {
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO users (email, name, password_hash, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		u.Email, u.Name, u.PasswordHash, u.CreatedAt, u.UpdatedAt,
+	).Scan(&u.ID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("create user: %w", err)
+	}
+	return nil
+}
+
+// UserByEmail finds a user by their normalized email
+func (s *PostgresStore) UserByEmail(ctx context.Context, email string) (*userdomain.User, error) {
+	return s.user(ctx, `WHERE email = $1`, email)
+}
+
+// UserByID finds a user by ID
+func (s *PostgresStore) UserByID(ctx context.Context, id int) (*userdomain.User, error) {
+	return s.user(ctx, `WHERE id = $1`, id)
+}
+
+func (s *PostgresStore) user(ctx context.Context, where string, arg any) (*userdomain.User, error) {
+	u := &userdomain.User{}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, email, name, password_hash, created_at, updated_at FROM users `+where, arg,
+	).Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.CreatedAt, &u.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	return u, nil
+}
+
+// SaveRefreshToken stores a newly issued refresh token
+func (s *PostgresStore) SaveRefreshToken(ctx context.Context, t RefreshToken) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO refresh_tokens (token_hash, user_id, expires_at, created_at) VALUES ($1, $2, $3, $4)`,
+		t.Hash, t.UserID, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("save refresh token: %w", err)
+	}
+	return nil
+}
+
+// RefreshToken looks up a refresh token by hash
+func (s *PostgresStore) RefreshToken(ctx context.Context, hash string) (*RefreshToken, error) {
+	t := &RefreshToken{Hash: hash}
+	var revokedAt sql.NullTime
+	var revokedReason sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		`SELECT user_id, expires_at, revoked_at, revoked_reason, created_at FROM refresh_tokens WHERE token_hash = $1`, hash,
+	).Scan(&t.UserID, &t.ExpiresAt, &revokedAt, &revokedReason, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get refresh token: %w", err)
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+		t.RevokedReason = RevokeReason(revokedReason.String)
+	}
+	return t, nil
+}
+
+// RevokeRefreshToken revokes an active refresh token
+func (s *PostgresStore) RevokeRefreshToken(ctx context.Context, hash string, at time.Time, reason RevokeReason) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = $2, revoked_reason = $3 WHERE token_hash = $1 AND revoked_at IS NULL`,
+		hash, at, string(reason))
+	if err != nil {
+		return false, fmt.Errorf("revoke refresh token: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("revoke refresh token: %w", err)
+	}
+	return n == 1, nil
+}
+
+// RevokeUserRefreshTokens revokes every active refresh token of a user
+func (s *PostgresStore) RevokeUserRefreshTokens(ctx context.Context, userID int, at time.Time, reason RevokeReason) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = $2, revoked_reason = $3 WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID, at, string(reason))
+	if err != nil {
+		return fmt.Errorf("revoke refresh tokens: %w", err)
+	}
+	return nil
+}
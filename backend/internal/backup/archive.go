@@ -0,0 +1,152 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// tableFile returns the archive path of a table's rows
+func tableFile(table string) string {
+	return path.Join("tables", table+".ndjson")
+}
+
+// writeArchive writes the manifest followed by the table files staged in dir
+func writeArchive(w io.Writer, dir string, m *Manifest) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("backup: encode manifest: %w", err)
+	}
+	header := &tar.Header{Name: ManifestName, Mode: 0o644, Size: int64(len(manifest)), ModTime: m.CreatedAt, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("backup: write archive: %w", err)
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return fmt.Errorf("backup: write archive: %w", err)
+	}
+
+	for _, t := range m.Tables {
+		if err := addFile(tw, filepath.Join(dir, filepath.FromSlash(t.File)), t.File, m.CreatedAt); err != nil {
+			return fmt.Errorf("backup: write archive: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("backup: write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("backup: write archive: %w", err)
+	}
+	return nil
+}
+
+func addFile(tw *tar.Writer, src, name string, modTime time.Time) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{Name: name, Mode: 0o644, Size: info.Size(), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// extractArchive unpacks an archive into dir and returns its manifest
+func extractArchive(r io.Reader, dir string) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("backup: read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("backup: read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !filepath.IsLocal(header.Name) {
+			return nil, fmt.Errorf("backup: read archive: invalid file name %q", header.Name)
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, fmt.Errorf("backup: read archive: %w", err)
+		}
+		f, err := os.Create(dst)
+		if err != nil {
+			return nil, fmt.Errorf("backup: read archive: %w", err)
+		}
+		_, err = io.Copy(f, tr)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("backup: read archive: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("backup: archive has no %s: %w", ManifestName, err)
+	}
+	m, err := parseManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range m.Tables {
+		if !filepath.IsLocal(t.File) {
+			return nil, fmt.Errorf("backup: table %s: invalid file name %q", t.Name, t.File)
+		}
+	}
+	return m, nil
+}
+
+// ReadManifest returns the manifest of an archive without unpacking the tables
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("backup: read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("backup: archive has no %s", ManifestName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("backup: read archive: %w", err)
+		}
+		if header.Name != ManifestName {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("backup: read archive: %w", err)
+		}
+		return parseManifest(data)
+	}
+}
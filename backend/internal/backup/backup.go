@@ -0,0 +1,423 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrSchemaMismatch is returned by Restore when the target database is at
+	// another schema version than the archive
+	ErrSchemaMismatch = errors.New("backup: schema version mismatch")
+	// ErrNotEmpty is returned by Restore when a table to restore has rows
+	// and WithClean was not given
+	ErrNotEmpty = errors.New("backup: table is not empty")
+	// ErrMissingTable is returned by Restore when a table of the archive does
+	// not exist and WithCreateTables was not given
+	ErrMissingTable = errors.New("backup: table does not exist")
+)
+
+// Dump writes an archive of every table of db, except the migration tools'
+// bookkeeping tables, to w. The tables are read in one transaction so that
+// the archive is a consistent snapshot.
+func Dump(ctx context.Context, db *sql.DB, dialect Dialect, w io.Writer) (*Manifest, error) {
+	dir, err := os.MkdirTemp("", "backup-")
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := &sql.TxOptions{ReadOnly: true}
+	if dialect == Postgres {
+		opts.Isolation = sql.LevelRepeatableRead
+	} else {
+		opts = nil // the sqlite3 driver has no read-only transactions
+	}
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("backup: begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	m := &Manifest{FormatVersion: FormatVersion, CreatedAt: time.Now().UTC(), Dialect: dialect}
+	if m.SchemaVersion, m.SchemaTable, err = dialect.schemaVersion(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	names, err := dialect.tables(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	var tables []Table
+	for _, name := range names {
+		if bookkeepingTables[name] {
+			continue
+		}
+		t, err := dialect.describe(ctx, tx, name)
+		if err != nil {
+			return nil, err
+		}
+		t.File = tableFile(name)
+		tables = append(tables, t)
+	}
+	resolveRefColumns(tables)
+	if m.Tables, err = sortTables(tables); err != nil {
+		return nil, err
+	}
+
+	for i := range m.Tables {
+		t := &m.Tables[i]
+		path := filepath.Join(dir, filepath.FromSlash(t.File))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("backup: %w", err)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("backup: %w", err)
+		}
+		buf := bufio.NewWriter(f)
+		t.Rows, t.Checksum, err = exportRows(ctx, tx, *t, buf)
+		if err == nil {
+			err = buf.Flush()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeArchive(w, dir, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// resolveRefColumns fills in the referenced columns of SQLite foreign keys
+// declared as REFERENCES table, which reference the primary key
+func resolveRefColumns(tables []Table) {
+	pks := map[string][]string{}
+	for _, t := range tables {
+		pks[t.Name] = t.PrimaryKey
+	}
+	for i := range tables {
+		for j := range tables[i].ForeignKeys {
+			fk := &tables[i].ForeignKeys[j]
+			if len(fk.RefColumns) == 0 {
+				fk.RefColumns = pks[fk.RefTable]
+			}
+		}
+	}
+}
+
+// sortTables orders tables so that each comes after the tables it
+// references, keeping the given order otherwise. References to a table's
+// own rows and to tables outside the list are ignored.
+func sortTables(tables []Table) ([]Table, error) {
+	present := map[string]bool{}
+	for _, t := range tables {
+		present[t.Name] = true
+	}
+
+	sorted := make([]Table, 0, len(tables))
+	placed := map[string]bool{}
+	remaining := tables
+	for len(remaining) > 0 {
+		var next []Table
+		for _, t := range remaining {
+			ready := true
+			for _, fk := range t.ForeignKeys {
+				if fk.RefTable != t.Name && present[fk.RefTable] && !placed[fk.RefTable] {
+					ready = false
+				}
+			}
+			if ready {
+				sorted = append(sorted, t)
+				placed[t.Name] = true
+			} else {
+				next = append(next, t)
+			}
+		}
+		if len(next) == len(remaining) {
+			names := make([]string, len(next))
+			for i, t := range next {
+				names[i] = t.Name
+			}
+			return nil, fmt.Errorf("backup: foreign keys form a cycle between %s", strings.Join(names, ", "))
+		}
+		remaining = next
+	}
+	return sorted, nil
+}
+
+// exportRows writes the rows of t to w as NDJSON, ordered by primary key,
+// and returns their number and checksum
+func exportRows(ctx context.Context, q querier, t Table, w io.Writer) (int64, string, error) {
+	names := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		names[i] = c.Name
+	}
+	query := "SELECT " + quoteAll(names) + " FROM " + quote(t.Name)
+	if len(t.PrimaryKey) > 0 {
+		query += " ORDER BY " + quoteAll(t.PrimaryKey)
+	}
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return 0, "", fmt.Errorf("backup: read table %s: %w", t.Name, err)
+	}
+	defer rows.Close()
+
+	values := make([]any, len(t.Columns))
+	dest := make([]any, len(t.Columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var count int64
+	var sums [][]byte
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return 0, "", fmt.Errorf("backup: read table %s: %w", t.Name, err)
+		}
+		row := make(map[string]any, len(t.Columns))
+		for i, c := range t.Columns {
+			v, err := encodeValue(c.Type, values[i])
+			if err != nil {
+				return 0, "", fmt.Errorf("backup: table %s: column %s: %w", t.Name, c.Name, err)
+			}
+			row[c.Name] = v
+		}
+		// Maps are encoded with sorted keys, so a row always has the same line
+		line, err := json.Marshal(row)
+		if err != nil {
+			return 0, "", fmt.Errorf("backup: table %s: %w", t.Name, err)
+		}
+		sum := sha256.Sum256(line)
+		sums = append(sums, sum[:])
+		count++
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return 0, "", fmt.Errorf("backup: write table %s: %w", t.Name, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, "", fmt.Errorf("backup: read table %s: %w", t.Name, err)
+	}
+
+	slices.SortFunc(sums, bytes.Compare)
+	h := sha256.New()
+	for _, sum := range sums {
+		h.Write(sum)
+	}
+	return count, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type restoreOptions struct {
+	clean               bool
+	createTables        bool
+	ignoreSchemaVersion bool
+}
+
+// RestoreOption configures Restore
+type RestoreOption func(*restoreOptions)
+
+// WithClean deletes the rows of the restored tables before restoring.
+// Without it Restore refuses to write into tables that have rows.
+func WithClean(clean bool) RestoreOption {
+	return func(o *restoreOptions) { o.clean = clean }
+}
+
+// WithCreateTables creates the tables missing from the database from the
+// manifest, with their primary and foreign keys but without defaults or
+// indexes. This allows restoring into an empty database of the other dialect.
+func WithCreateTables(create bool) RestoreOption {
+	return func(o *restoreOptions) { o.createTables = create }
+}
+
+// WithIgnoreSchemaVersion restores even when the database records another
+// schema version than the archive
+func WithIgnoreSchemaVersion(ignore bool) RestoreOption {
+	return func(o *restoreOptions) { o.ignoreSchemaVersion = ignore }
+}
+
+// Restore loads an archive written by Dump into db in one transaction,
+// inserting parent tables before the tables that reference them. The
+// database may be of the other dialect than the archive. Use Verify to
+// compare the result with the archive.
+func Restore(ctx context.Context, db *sql.DB, dialect Dialect, r io.Reader, opts ...RestoreOption) (*Manifest, error) {
+	o := &restoreOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dir, err := os.MkdirTemp("", "restore-")
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	m, err := extractArchive(r, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("backup: begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	version, table, err := dialect.schemaVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if table != "" && !o.ignoreSchemaVersion && (table != m.SchemaTable || version != m.SchemaVersion) {
+		return nil, fmt.Errorf("%w: archive is at %s %d, database is at %s %d",
+			ErrSchemaMismatch, m.SchemaTable, m.SchemaVersion, table, version)
+	}
+
+	for _, t := range m.Tables {
+		if err := prepareTable(ctx, tx, dialect, t, o); err != nil {
+			return nil, err
+		}
+	}
+	if o.clean {
+		for i := len(m.Tables) - 1; i >= 0; i-- {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+quote(m.Tables[i].Name)); err != nil {
+				return nil, fmt.Errorf("backup: clean table %s: %w", m.Tables[i].Name, err)
+			}
+		}
+	}
+
+	for _, t := range m.Tables {
+		if err := importRows(ctx, tx, dialect, t, filepath.Join(dir, filepath.FromSlash(t.File))); err != nil {
+			return nil, err
+		}
+	}
+	if err := dialect.afterRestore(ctx, tx, m.Tables); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("backup: commit restore: %w", err)
+	}
+	return m, nil
+}
+
+// prepareTable creates t if needed and allowed, and checks that it has the
+// archived columns and, unless cleaning, no rows
+func prepareTable(ctx context.Context, q querier, dialect Dialect, t Table, o *restoreOptions) error {
+	exists, err := dialect.tableExists(ctx, q, t.Name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if !o.createTables {
+			return fmt.Errorf("%w: %s", ErrMissingTable, t.Name)
+		}
+		if _, err := q.ExecContext(ctx, dialect.createTable(t)); err != nil {
+			return fmt.Errorf("backup: create table %s: %w", t.Name, err)
+		}
+		return nil
+	}
+
+	current, err := dialect.describe(ctx, q, t.Name)
+	if err != nil {
+		return err
+	}
+	for _, c := range t.Columns {
+		if !slices.ContainsFunc(current.Columns, func(cc Column) bool { return cc.Name == c.Name }) {
+			return fmt.Errorf("backup: table %s has no column %s", t.Name, c.Name)
+		}
+	}
+
+	if !o.clean {
+		var hasRows bool
+		if err := q.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+quote(t.Name)+")").Scan(&hasRows); err != nil {
+			return fmt.Errorf("backup: count rows of %s: %w", t.Name, err)
+		}
+		if hasRows {
+			return fmt.Errorf("%w: %s", ErrNotEmpty, t.Name)
+		}
+	}
+	return nil
+}
+
+// importRows inserts the rows of a table file
+func importRows(ctx context.Context, q querier, dialect Dialect, t Table, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("backup: table %s: %w", t.Name, err)
+	}
+	defer f.Close()
+
+	names := make([]string, len(t.Columns))
+	placeholders := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		names[i] = c.Name
+		placeholders[i] = dialect.placeholder(i + 1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quote(t.Name), quoteAll(names), strings.Join(placeholders, ", "))
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	dec.UseNumber()
+	args := make([]any, len(t.Columns))
+	for line := 1; ; line++ {
+		var row map[string]any
+		if err := dec.Decode(&row); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("backup: table %s: row %d: %w", t.Name, line, err)
+		}
+		for i, c := range t.Columns {
+			if args[i], err = decodeValue(c.Type, row[c.Name]); err != nil {
+				return fmt.Errorf("backup: table %s: row %d: column %s: %w", t.Name, line, c.Name, err)
+			}
+		}
+		if _, err := q.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("backup: table %s: row %d: %w", t.Name, line, err)
+		}
+	}
+}
+
+// Mismatch is a table whose rows differ from the archive
+type Mismatch struct {
+	Table        string
+	WantRows     int64
+	GotRows      int64
+	WantChecksum string
+	GotChecksum  string
+}
+
+func (m Mismatch) String() string {
+	if m.WantRows != m.GotRows {
+		return fmt.Sprintf("%s: %d rows, archive has %d", m.Table, m.GotRows, m.WantRows)
+	}
+	return fmt.Sprintf("%s: checksum %.12s, archive has %.12s", m.Table, m.GotChecksum, m.WantChecksum)
+}
+
+// Verify compares the row counts and checksums of the tables of m with db.
+// Only the archived columns are compared, so db may have extra columns.
+func Verify(ctx context.Context, db *sql.DB, m *Manifest) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	for _, t := range m.Tables {
+		rows, sum, err := exportRows(ctx, db, t, io.Discard)
+		if err != nil {
+			return nil, err
+		}
+		if rows != t.Rows || sum != t.Checksum {
+			mismatches = append(mismatches, Mismatch{Table: t.Name, WantRows: t.Rows, GotRows: rows, WantChecksum: t.Checksum, GotChecksum: sum})
+		}
+	}
+	return mismatches, nil
+}
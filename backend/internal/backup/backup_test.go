@@ -0,0 +1,218 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+CREATE TABLE goose_db_version (id INTEGER PRIMARY KEY AUTOINCREMENT, version_id INTEGER NOT NULL, is_applied BOOLEAN NOT NULL);
+INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, 1), (20250708090008, 1), (20250708090055, 1);
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(100) NOT NULL,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	score REAL,
+	avatar BLOB,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE posts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	title VARCHAR(200) NOT NULL,
+	published BOOLEAN DEFAULT FALSE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE categories (id INTEGER PRIMARY KEY AUTOINCREMENT, name VARCHAR(100) NOT NULL UNIQUE);
+CREATE TABLE post_categories (
+	post_id INTEGER NOT NULL,
+	category_id INTEGER NOT NULL,
+	PRIMARY KEY (post_id, category_id),
+	FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	FOREIGN KEY (category_id) REFERENCES categories
+);`
+
+const testData = `
+INSERT INTO users (name, email, score, avatar, created_at) VALUES
+	('Alice', 'alice@example.com', 4.5, X'89504E47', '2025-07-08 09:00:08'),
+	('Bob', 'bob@example.com', NULL, NULL, '2025-07-09 10:30:00');
+INSERT INTO categories (name) VALUES ('Go'), ('SQL');
+INSERT INTO posts (user_id, title, published) VALUES (1, 'Hello', TRUE), (2, 'Draft', FALSE), (1, 'Joins', TRUE);
+INSERT INTO post_categories VALUES (1, 1), (1, 2), (3, 2);`
+
+func openTestDB(t *testing.T, script string) *sql.DB {
+	t.Helper()
+	db, _, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(script); err != nil {
+		t.Fatalf("Failed to set up database: %v", err)
+	}
+	return db
+}
+
+func dump(t *testing.T, db *sql.DB) (*Manifest, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	m, err := Dump(context.Background(), db, SQLite, &buf)
+	if err != nil {
+		t.Fatalf("Dump() failed: %v", err)
+	}
+	return m, buf.Bytes()
+}
+
+func TestDump(t *testing.T) {
+	db := openTestDB(t, testSchema+testData)
+	m, archive := dump(t, db)
+
+	if m.SchemaVersion != 20250708090055 || m.SchemaTable != "goose_db_version" {
+		t.Errorf("Expected schema version 20250708090055 of goose_db_version, got %d of %q", m.SchemaVersion, m.SchemaTable)
+	}
+	var order []string
+	for _, table := range m.Tables {
+		order = append(order, table.Name)
+	}
+	if got := strings.Join(order, ","); got != "categories,users,posts,post_categories" {
+		t.Errorf("Expected parents before children without bookkeeping tables, got %s", got)
+	}
+	rows := map[string]int64{"users": 2, "posts": 3, "categories": 2, "post_categories": 3}
+	for name, want := range rows {
+		if got := m.Table(name).Rows; got != want {
+			t.Errorf("Expected %d rows in %s, got %d", want, name, got)
+		}
+	}
+	fk := m.Table("post_categories").ForeignKeys
+	if len(fk) != 2 || fk[1].RefTable != "categories" || strings.Join(fk[1].RefColumns, ",") != "id" {
+		t.Errorf("Expected the implicit reference to categories.id to be resolved, got %+v", fk)
+	}
+
+	read, err := ReadManifest(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("ReadManifest() failed: %v", err)
+	}
+	if read.Table("users").Checksum != m.Table("users").Checksum {
+		t.Error("Expected the archived manifest to match the returned one")
+	}
+}
+
+func TestRestoreIntoEmptyDatabase(t *testing.T) {
+	source := openTestDB(t, testSchema+testData)
+	m, archive := dump(t, source)
+
+	target := openTestDB(t, "")
+	if _, err := Restore(context.Background(), target, SQLite, bytes.NewReader(archive)); !errors.Is(err, ErrMissingTable) {
+		t.Fatalf("Expected ErrMissingTable, got %v", err)
+	}
+	if _, err := Restore(context.Background(), target, SQLite, bytes.NewReader(archive), WithCreateTables(true)); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	mismatches, err := Verify(context.Background(), target, m)
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if len(mismatches) > 0 {
+		t.Errorf("Expected the restored database to match, got %v", mismatches)
+	}
+
+	// Generated keys continue after the restored ones
+	res, err := target.Exec(`INSERT INTO users (name, email) VALUES ('Carol', 'carol@example.com')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := res.LastInsertId(); id != 3 {
+		t.Errorf("Expected the next user id to be 3, got %d", id)
+	}
+}
+
+func TestRestoreIntoMigratedDatabase(t *testing.T) {
+	source := openTestDB(t, testSchema+testData)
+	m, archive := dump(t, source)
+	ctx := context.Background()
+
+	target := openTestDB(t, testSchema)
+	if _, err := Restore(ctx, target, SQLite, bytes.NewReader(archive)); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	if _, err := Restore(ctx, target, SQLite, bytes.NewReader(archive)); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("Expected ErrNotEmpty restoring twice, got %v", err)
+	}
+	if _, err := Restore(ctx, target, SQLite, bytes.NewReader(archive), WithClean(true)); err != nil {
+		t.Fatalf("Restore() with clean failed: %v", err)
+	}
+	if mismatches, err := Verify(ctx, target, m); err != nil || len(mismatches) > 0 {
+		t.Errorf("Expected the restored database to match, got %v, %v", mismatches, err)
+	}
+
+	target.Exec(`INSERT INTO goose_db_version (version_id, is_applied) VALUES (20250801000000, 1)`)
+	if _, err := Restore(ctx, target, SQLite, bytes.NewReader(archive), WithClean(true)); !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("Expected ErrSchemaMismatch, got %v", err)
+	}
+	if _, err := Restore(ctx, target, SQLite, bytes.NewReader(archive), WithClean(true), WithIgnoreSchemaVersion(true)); err != nil {
+		t.Errorf("Expected the schema version to be ignored, got %v", err)
+	}
+}
+
+func TestRestoreRollsBackOnError(t *testing.T) {
+	source := openTestDB(t, testSchema+testData)
+	_, archive := dump(t, source)
+
+	// The target lacks the avatar column
+	target := openTestDB(t, strings.Replace(testSchema, "avatar BLOB,", "", 1))
+	if _, err := Restore(context.Background(), target, SQLite, bytes.NewReader(archive)); err == nil || !strings.Contains(err.Error(), "no column avatar") {
+		t.Fatalf("Expected a missing column error, got %v", err)
+	}
+	var n int
+	target.QueryRow(`SELECT COUNT(*) FROM categories`).Scan(&n)
+	if n != 0 {
+		t.Errorf("Expected nothing to be restored, got %d categories", n)
+	}
+}
+
+func TestVerifyDetectsChanges(t *testing.T) {
+	db := openTestDB(t, testSchema+testData)
+	m, _ := dump(t, db)
+
+	db.Exec(`UPDATE posts SET title = 'Edited' WHERE id = 2`)
+	db.Exec(`DELETE FROM post_categories WHERE post_id = 3`)
+
+	mismatches, err := Verify(context.Background(), db, m)
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if len(mismatches) != 2 {
+		t.Fatalf("Expected 2 mismatches, got %v", mismatches)
+	}
+	if mismatches[0].Table != "posts" || mismatches[0].WantRows != mismatches[0].GotRows {
+		t.Errorf("Expected a checksum mismatch for posts, got %v", mismatches[0])
+	}
+	if got := mismatches[1].String(); got != "post_categories: 2 rows, archive has 3" {
+		t.Errorf("Unexpected mismatch %q", got)
+	}
+}
+
+func TestSortTables(t *testing.T) {
+	tables := []Table{
+		{Name: "comments", ForeignKeys: []ForeignKey{{RefTable: "posts"}, {RefTable: "comments"}}},
+		{Name: "posts", ForeignKeys: []ForeignKey{{RefTable: "users"}, {RefTable: "excluded"}}},
+		{Name: "users"},
+	}
+	sorted, err := sortTables(tables)
+	if err != nil {
+		t.Fatalf("sortTables() failed: %v", err)
+	}
+	if sorted[0].Name != "users" || sorted[1].Name != "posts" || sorted[2].Name != "comments" {
+		t.Errorf("Unexpected order %v", sorted)
+	}
+
+	tables[2].ForeignKeys = []ForeignKey{{RefTable: "comments"}}
+	if _, err := sortTables(tables); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+}
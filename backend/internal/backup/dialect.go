@@ -0,0 +1,481 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/migrate"
+)
+
+// Dialect is the kind of database an archive is written from or restored into
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// gooseTable is the version table of goose, which the labs migrate with
+const gooseTable = "goose_db_version"
+
+// bookkeepingTables belong to the migration tools and are never backed up;
+// the target database records its own schema version
+var bookkeepingTables = map[string]bool{
+	gooseTable:                  true,
+	"goose_migration_checksums": true,
+	migrate.DefaultTable:        true,
+}
+
+// DialectOf returns Postgres for postgres:// and postgresql:// URLs and
+// SQLite for anything else, which is taken as a file name
+func DialectOf(dsn string) Dialect {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return Postgres
+	}
+	return SQLite
+}
+
+// Open connects to a Postgres URL or SQLite file. SQLite connections
+// enforce foreign keys.
+func Open(dsn string) (*sql.DB, Dialect, error) {
+	dialect := DialectOf(dsn)
+	driver := "postgres"
+	if dialect == SQLite {
+		driver = "sqlite3"
+		dsn = strings.TrimPrefix(dsn, "sqlite://")
+		if strings.Contains(dsn, "?") {
+			dsn += "&_foreign_keys=on"
+		} else {
+			dsn += "?_foreign_keys=on"
+		}
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, "", fmt.Errorf("backup: open database: %w", err)
+	}
+	return db, dialect, nil
+}
+
+// querier is satisfied by *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// quote quotes an identifier; both dialects use double quotes
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (d Dialect) placeholder(i int) string {
+	if d == Postgres {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
+
+// tables lists the user tables of the database by name
+func (d Dialect) tables(ctx context.Context, q querier) ([]string, error) {
+	query := `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	if d == Postgres {
+		query = `SELECT table_name FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name`
+	}
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("backup: list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("backup: list tables: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (d Dialect) tableExists(ctx context.Context, q querier, name string) (bool, error) {
+	query := `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?`
+	arg := name
+	if d == Postgres {
+		query = `SELECT to_regclass($1) IS NOT NULL`
+		arg = quote(name)
+	}
+	var exists bool
+	if err := q.QueryRowContext(ctx, query, arg).Scan(&exists); err != nil {
+		return false, fmt.Errorf("backup: check for table %s: %w", name, err)
+	}
+	return exists, nil
+}
+
+// describe reads the columns, primary key and foreign keys of a table
+func (d Dialect) describe(ctx context.Context, q querier, name string) (Table, error) {
+	t := Table{Name: name}
+	var err error
+	if d == Postgres {
+		err = describePostgres(ctx, q, &t)
+	} else {
+		err = describeSQLite(ctx, q, &t)
+	}
+	if err != nil {
+		return Table{}, fmt.Errorf("backup: describe table %s: %w", name, err)
+	}
+	return t, nil
+}
+
+func describeSQLite(ctx context.Context, q querier, t *Table) error {
+	rows, err := q.QueryContext(ctx, `PRAGMA table_info(`+quote(t.Name)+`)`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	pk := map[int]string{}
+	for rows.Next() {
+		var cid, notNull, pkIndex int
+		var name, declared string
+		var def sql.NullString
+		if err := rows.Scan(&cid, &name, &declared, &notNull, &def, &pkIndex); err != nil {
+			return err
+		}
+		t.Columns = append(t.Columns, Column{Name: name, Type: sqliteType(declared), Nullable: notNull == 0 && pkIndex == 0})
+		if pkIndex > 0 {
+			pk[pkIndex] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := 1; i <= len(pk); i++ {
+		t.PrimaryKey = append(t.PrimaryKey, pk[i])
+	}
+
+	fks, err := q.QueryContext(ctx, `PRAGMA foreign_key_list(`+quote(t.Name)+`)`)
+	if err != nil {
+		return err
+	}
+	defer fks.Close()
+	byID := map[int]*ForeignKey{}
+	var ids []int
+	for fks.Next() {
+		var id, seq int
+		var table, from, onUpdate, onDelete, match string
+		var to sql.NullString
+		if err := fks.Scan(&id, &seq, &table, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			return err
+		}
+		fk, ok := byID[id]
+		if !ok {
+			fk = &ForeignKey{RefTable: table, OnDelete: referentialAction(onDelete)}
+			byID[id] = fk
+			ids = append(ids, id)
+		}
+		fk.Columns = append(fk.Columns, from)
+		if to.Valid {
+			fk.RefColumns = append(fk.RefColumns, to.String)
+		}
+	}
+	if err := fks.Err(); err != nil {
+		return err
+	}
+	// PRAGMA foreign_key_list lists constraints in reverse order of declaration
+	for i := len(ids) - 1; i >= 0; i-- {
+		t.ForeignKeys = append(t.ForeignKeys, *byID[ids[i]])
+	}
+	return nil
+}
+
+func describePostgres(ctx context.Context, q querier, t *Table) error {
+	rows, err := q.QueryContext(ctx, `SELECT column_name, data_type, is_nullable = 'YES'
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position`, t.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c Column
+		var dataType string
+		if err := rows.Scan(&c.Name, &dataType, &c.Nullable); err != nil {
+			return err
+		}
+		c.Type = postgresType(dataType)
+		t.Columns = append(t.Columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	pk, err := q.QueryContext(ctx, `SELECT a.attname FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = to_regclass($1) AND i.indisprimary
+		ORDER BY array_position(i.indkey::int2[], a.attnum)`, quote(t.Name))
+	if err != nil {
+		return err
+	}
+	defer pk.Close()
+	for pk.Next() {
+		var name string
+		if err := pk.Scan(&name); err != nil {
+			return err
+		}
+		t.PrimaryKey = append(t.PrimaryKey, name)
+	}
+	if err := pk.Err(); err != nil {
+		return err
+	}
+
+	fks, err := q.QueryContext(ctx, `SELECT c.conname, ref.relname, a.attname, af.attname, c.confdeltype
+		FROM pg_constraint c
+		CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(col, refcol, n)
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.col
+		JOIN pg_attribute af ON af.attrelid = c.confrelid AND af.attnum = k.refcol
+		JOIN pg_class ref ON ref.oid = c.confrelid
+		WHERE c.contype = 'f' AND c.conrelid = to_regclass($1)
+		ORDER BY c.conname, k.n`, quote(t.Name))
+	if err != nil {
+		return err
+	}
+	defer fks.Close()
+	var current string
+	for fks.Next() {
+		var name, refTable, column, refColumn, action string
+		if err := fks.Scan(&name, &refTable, &column, &refColumn, &action); err != nil {
+			return err
+		}
+		if name != current || len(t.ForeignKeys) == 0 {
+			t.ForeignKeys = append(t.ForeignKeys, ForeignKey{RefTable: refTable, OnDelete: postgresAction(action)})
+			current = name
+		}
+		fk := &t.ForeignKeys[len(t.ForeignKeys)-1]
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+	}
+	return fks.Err()
+}
+
+// sqliteType maps a declared column type to a portable type using SQLite's
+// type affinity rules, with booleans and timestamps told apart
+func sqliteType(declared string) Type {
+	upper := strings.ToUpper(declared)
+	switch {
+	case strings.Contains(upper, "BOOL"):
+		return TypeBoolean
+	case strings.Contains(upper, "INT"):
+		return TypeInteger
+	case strings.Contains(upper, "CHAR"), strings.Contains(upper, "CLOB"), strings.Contains(upper, "TEXT"):
+		return TypeText
+	case strings.Contains(upper, "BLOB"):
+		return TypeBlob
+	case strings.Contains(upper, "REAL"), strings.Contains(upper, "FLOA"), strings.Contains(upper, "DOUB"):
+		return TypeReal
+	case strings.Contains(upper, "DATE"), strings.Contains(upper, "TIME"):
+		return TypeTimestamp
+	case strings.Contains(upper, "NUMERIC"), strings.Contains(upper, "DECIMAL"):
+		return TypeNumeric
+	default:
+		return TypeText
+	}
+}
+
+// postgresType maps an information_schema data_type to a portable type.
+// Types without a portable equivalent, such as uuid and jsonb, are kept as text.
+func postgresType(dataType string) Type {
+	switch dataType {
+	case "smallint", "integer", "bigint":
+		return TypeInteger
+	case "real", "double precision":
+		return TypeReal
+	case "numeric":
+		return TypeNumeric
+	case "boolean":
+		return TypeBoolean
+	case "date", "timestamp without time zone", "timestamp with time zone":
+		return TypeTimestamp
+	case "bytea":
+		return TypeBlob
+	default:
+		return TypeText
+	}
+}
+
+// referentialAction normalizes an ON DELETE action; NO ACTION is the default
+func referentialAction(action string) string {
+	action = strings.ToUpper(action)
+	if action == "NO ACTION" {
+		return ""
+	}
+	return action
+}
+
+func postgresAction(code string) string {
+	switch code {
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	default:
+		return ""
+	}
+}
+
+// sqlType returns the column type used when creating a table
+func (d Dialect) sqlType(t Type) string {
+	types := map[Type][2]string{ // sqlite, postgres
+		TypeInteger:   {"INTEGER", "BIGINT"},
+		TypeReal:      {"REAL", "DOUBLE PRECISION"},
+		TypeNumeric:   {"NUMERIC", "NUMERIC"},
+		TypeBoolean:   {"BOOLEAN", "BOOLEAN"},
+		TypeText:      {"TEXT", "TEXT"},
+		TypeTimestamp: {"DATETIME", "TIMESTAMP"},
+		TypeBlob:      {"BLOB", "BYTEA"},
+	}
+	if d == Postgres {
+		return types[t][1]
+	}
+	return types[t][0]
+}
+
+// createTable returns the DDL of a table with its columns, primary key and
+// foreign keys. Defaults, indexes and check constraints are not part of an
+// archive and are not created.
+func (d Dialect) createTable(t Table) string {
+	var defs []string
+	serial := serialColumn(t)
+	for _, c := range t.Columns {
+		def := quote(c.Name) + " " + d.sqlType(c.Type)
+		switch {
+		case c.Name == serial && d == Postgres:
+			def += " GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY"
+		case c.Name == serial:
+			def += " PRIMARY KEY AUTOINCREMENT"
+		case !c.Nullable:
+			def += " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+	if serial == "" && len(t.PrimaryKey) > 0 {
+		defs = append(defs, "PRIMARY KEY ("+quoteAll(t.PrimaryKey)+")")
+	}
+	for _, fk := range t.ForeignKeys {
+		def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", quoteAll(fk.Columns), quote(fk.RefTable), quoteAll(fk.RefColumns))
+		if fk.OnDelete != "" {
+			def += " ON DELETE " + fk.OnDelete
+		}
+		defs = append(defs, def)
+	}
+	return "CREATE TABLE " + quote(t.Name) + " (\n\t" + strings.Join(defs, ",\n\t") + "\n)"
+}
+
+// serialColumn returns the primary key column when it is a single integer
+// column, which both databases generate values for
+func serialColumn(t Table) string {
+	if len(t.PrimaryKey) != 1 {
+		return ""
+	}
+	for _, c := range t.Columns {
+		if c.Name == t.PrimaryKey[0] && c.Type == TypeInteger {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = quote(n)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// schemaVersion returns the latest applied migration and the table it was
+// read from; a database without a version table has version 0
+func (d Dialect) schemaVersion(ctx context.Context, q querier) (int64, string, error) {
+	if ok, err := d.tableExists(ctx, q, gooseTable); err != nil || ok {
+		if err != nil {
+			return 0, "", err
+		}
+		// The latest row of each version decides whether it is applied
+		rows, err := q.QueryContext(ctx, `SELECT version_id, is_applied FROM `+gooseTable+` ORDER BY id`)
+		if err != nil {
+			return 0, "", fmt.Errorf("backup: read schema version: %w", err)
+		}
+		defer rows.Close()
+		applied := map[int64]bool{}
+		for rows.Next() {
+			var version int64
+			var isApplied bool
+			if err := rows.Scan(&version, &isApplied); err != nil {
+				return 0, "", fmt.Errorf("backup: read schema version: %w", err)
+			}
+			applied[version] = isApplied
+		}
+		var latest int64
+		for version, ok := range applied {
+			if ok && version > latest {
+				latest = version
+			}
+		}
+		return latest, gooseTable, rows.Err()
+	}
+
+	if ok, err := d.tableExists(ctx, q, migrate.DefaultTable); err != nil || !ok {
+		return 0, "", err
+	}
+	var version sql.NullInt64
+	if err := q.QueryRowContext(ctx, `SELECT MAX(version) FROM `+migrate.DefaultTable).Scan(&version); err != nil {
+		return 0, "", fmt.Errorf("backup: read schema version: %w", err)
+	}
+	return version.Int64, migrate.DefaultTable, nil
+}
+
+// afterRestore moves Postgres sequences past the restored keys and checks
+// SQLite foreign keys, which are only enforced when enabled on the connection
+func (d Dialect) afterRestore(ctx context.Context, q querier, tables []Table) error {
+	if d == SQLite {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int
+		err := q.QueryRowContext(ctx, `PRAGMA foreign_key_check`).Scan(&table, &rowid, &parent, &fkid)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("backup: check foreign keys: %w", err)
+		}
+		return fmt.Errorf("backup: a row of %s references a missing row of %s", table, parent)
+	}
+
+	for _, t := range tables {
+		column := serialColumn(t)
+		if column == "" {
+			continue
+		}
+		var sequence sql.NullString
+		if err := q.QueryRowContext(ctx, `SELECT pg_get_serial_sequence($1, $2)`, quote(t.Name), column).Scan(&sequence); err != nil {
+			return fmt.Errorf("backup: find sequence of %s: %w", t.Name, err)
+		}
+		if !sequence.Valid {
+			continue
+		}
+		query := fmt.Sprintf(`SELECT setval($1, COALESCE((SELECT MAX(%s) FROM %s), 0) + 1, false)`, quote(column), quote(t.Name))
+		if _, err := q.ExecContext(ctx, query, sequence.String); err != nil {
+			return fmt.Errorf("backup: reset sequence of %s: %w", t.Name, err)
+		}
+	}
+	return nil
+}
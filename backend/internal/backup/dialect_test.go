@@ -0,0 +1,112 @@
+package backup
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColumnTypes(t *testing.T) {
+	sqlite := map[string]Type{
+		"INTEGER": TypeInteger, "BIGINT": TypeInteger, "BOOLEAN": TypeBoolean, "VARCHAR(100)": TypeText,
+		"TEXT": TypeText, "BLOB": TypeBlob, "REAL": TypeReal, "DATETIME": TypeTimestamp,
+		"DECIMAL(10,2)": TypeNumeric, "": TypeText,
+	}
+	for declared, want := range sqlite {
+		if got := sqliteType(declared); got != want {
+			t.Errorf("sqliteType(%q) = %s, expected %s", declared, got, want)
+		}
+	}
+	postgres := map[string]Type{
+		"integer": TypeInteger, "bigint": TypeInteger, "boolean": TypeBoolean, "character varying": TypeText,
+		"uuid": TypeText, "bytea": TypeBlob, "double precision": TypeReal, "numeric": TypeNumeric,
+		"timestamp with time zone": TypeTimestamp,
+	}
+	for dataType, want := range postgres {
+		if got := postgresType(dataType); got != want {
+			t.Errorf("postgresType(%q) = %s, expected %s", dataType, got, want)
+		}
+	}
+}
+
+func TestCreateTable(t *testing.T) {
+	table := Table{
+		Name: "posts",
+		Columns: []Column{
+			{Name: "id", Type: TypeInteger},
+			{Name: "user_id", Type: TypeInteger},
+			{Name: "title", Type: TypeText},
+			{Name: "published_at", Type: TypeTimestamp, Nullable: true},
+		},
+		PrimaryKey:  []string{"id"},
+		ForeignKeys: []ForeignKey{{Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"}},
+	}
+	want := `CREATE TABLE "posts" (
+	"id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	"user_id" BIGINT NOT NULL,
+	"title" TEXT NOT NULL,
+	"published_at" TIMESTAMP,
+	FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+)`
+	if got := Postgres.createTable(table); got != want {
+		t.Errorf("Unexpected Postgres DDL:\n%s", got)
+	}
+	if got := SQLite.createTable(table); !strings.Contains(got, `"id" INTEGER PRIMARY KEY AUTOINCREMENT`) {
+		t.Errorf("Expected an autoincrement key in SQLite DDL:\n%s", got)
+	}
+
+	table.PrimaryKey = []string{"id", "user_id"}
+	if got := SQLite.createTable(table); !strings.Contains(got, `PRIMARY KEY ("id", "user_id")`) {
+		t.Errorf("Expected a composite primary key:\n%s", got)
+	}
+}
+
+// TestEncodeValueAcrossDialects checks that values as returned by the
+// sqlite3 and Postgres drivers encode the same, so checksums match
+func TestEncodeValueAcrossDialects(t *testing.T) {
+	ts := time.Date(2025, 7, 8, 9, 0, 8, 123456789, time.FixedZone("MSK", 3*3600))
+	tests := []struct {
+		typ              Type
+		sqlite, postgres any
+		want             string
+	}{
+		{TypeBoolean, int64(1), true, `true`},
+		{TypeInteger, int64(42), int64(42), `42`},
+		{TypeNumeric, float64(12.5), []byte("12.5"), `"12.5"`},
+		{TypeText, "héllo", "héllo", `"héllo"`},
+		{TypeTimestamp, "2025-07-08 06:00:08.123456", ts, `"2025-07-08T06:00:08.123456Z"`},
+		{TypeTimestamp, ts, ts.UTC(), `"2025-07-08T06:00:08.123456Z"`},
+		{TypeBlob, []byte{0x89, 'P'}, []byte{0x89, 'P'}, `"iVA="`},
+	}
+	for _, tt := range tests {
+		for _, v := range []any{tt.sqlite, tt.postgres} {
+			encoded, err := encodeValue(tt.typ, v)
+			if err != nil {
+				t.Errorf("encodeValue(%s, %#v) failed: %v", tt.typ, v, err)
+				continue
+			}
+			data, _ := json.Marshal(encoded)
+			if string(data) != tt.want {
+				t.Errorf("encodeValue(%s, %#v) = %s, expected %s", tt.typ, v, data, tt.want)
+			}
+		}
+	}
+
+	if _, err := encodeValue(TypeTimestamp, "yesterday"); err == nil {
+		t.Error("Expected an error for an invalid timestamp")
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	v, err := decodeValue(TypeTimestamp, "2025-07-08T06:00:08.123456Z")
+	if err != nil || !v.(time.Time).Equal(time.Date(2025, 7, 8, 6, 0, 8, 123456000, time.UTC)) {
+		t.Errorf("Unexpected timestamp %v, %v", v, err)
+	}
+	if v, err := decodeValue(TypeInteger, json.Number("7")); err != nil || v != int64(7) {
+		t.Errorf("Unexpected integer %v, %v", v, err)
+	}
+	if _, err := decodeValue(TypeBoolean, "yes"); err == nil {
+		t.Error("Expected an error for a string in a boolean column")
+	}
+}
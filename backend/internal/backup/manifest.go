@@ -0,0 +1,108 @@
+// Package backup exports a SQLite or Postgres database to a portable archive
+// and restores it, possibly into the other kind of database.
+//
+// An archive is a gzipped tar file holding manifest.json and one NDJSON file
+// per table, tables/<name>.ndjson, with a JSON object per row. Values are
+// encoded by the portable column type recorded in the manifest, so a row
+// reads the same whichever database it came from: timestamps are RFC 3339
+// strings in UTC, blobs are base64 and booleans are JSON booleans.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// FormatVersion is the archive format written by Dump. Restore reads archives
+// up to this version.
+const FormatVersion = 1
+
+// ManifestName is the name of the manifest inside an archive
+const ManifestName = "manifest.json"
+
+// Type is the portable type of a column
+type Type string
+
+const (
+	TypeInteger   Type = "integer"
+	TypeReal      Type = "real"
+	TypeNumeric   Type = "numeric" // exact decimal, kept as a string
+	TypeBoolean   Type = "boolean"
+	TypeText      Type = "text"
+	TypeTimestamp Type = "timestamp"
+	TypeBlob      Type = "blob"
+)
+
+// Manifest describes an archive
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Dialect       Dialect   `json:"dialect"`
+	// SchemaVersion is the latest applied migration of the source database,
+	// read from SchemaTable; both are empty when it has no version table
+	SchemaVersion int64  `json:"schema_version"`
+	SchemaTable   string `json:"schema_table,omitempty"`
+	// Tables are ordered so that every table comes after the tables its
+	// foreign keys reference
+	Tables []Table `json:"tables"`
+}
+
+// Table describes one table of an archive
+type Table struct {
+	Name        string       `json:"name"`
+	File        string       `json:"file"`
+	Columns     []Column     `json:"columns"`
+	PrimaryKey  []string     `json:"primary_key,omitempty"`
+	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+	Rows        int64        `json:"rows"`
+	// Checksum is the hex SHA-256 of the sorted SHA-256 sums of the rows,
+	// so it does not depend on the order a database returns them in
+	Checksum string `json:"checksum"`
+}
+
+// Column is a column of a table
+type Column struct {
+	Name     string `json:"name"`
+	Type     Type   `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// ForeignKey is a foreign key constraint of a table
+type ForeignKey struct {
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+	// OnDelete is the referential action, e.g. "CASCADE"; empty means NO ACTION
+	OnDelete string `json:"on_delete,omitempty"`
+}
+
+// Table returns the table with the given name, or nil
+func (m *Manifest) Table(name string) *Table {
+	for i := range m.Tables {
+		if m.Tables[i].Name == name {
+			return &m.Tables[i]
+		}
+	}
+	return nil
+}
+
+func parseManifest(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("backup: parse manifest: %w", err)
+	}
+	if m.FormatVersion < 1 || m.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("backup: unsupported archive format %d (this build reads up to %d)", m.FormatVersion, FormatVersion)
+	}
+	for _, t := range m.Tables {
+		for _, c := range t.Columns {
+			switch c.Type {
+			case TypeInteger, TypeReal, TypeNumeric, TypeBoolean, TypeText, TypeTimestamp, TypeBlob:
+			default:
+				return nil, fmt.Errorf("backup: table %s: column %s has unknown type %q", t.Name, c.Name, c.Type)
+			}
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqliteTimeLayouts are the formats SQLite stores timestamps in, as accepted
+// by the sqlite3 driver
+var sqliteTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	time.RFC3339Nano,
+}
+
+// encodeValue converts a value scanned from either database to its JSON form
+// for the column type. Timestamps are truncated to microseconds, the
+// precision of Postgres.
+func encodeValue(t Type, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if b, ok := v.([]byte); ok && t != TypeBlob {
+		v = string(b)
+	}
+
+	switch t {
+	case TypeInteger:
+		switch v := v.(type) {
+		case int64:
+			return v, nil
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case float64:
+			if v == float64(int64(v)) {
+				return int64(v), nil
+			}
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+	case TypeReal:
+		switch v := v.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case string:
+			return strconv.ParseFloat(v, 64)
+		}
+	case TypeNumeric:
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+	case TypeBoolean:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case TypeText:
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case time.Time:
+			return v.UTC().Format(time.RFC3339Nano), nil
+		default:
+			// SQLite lets any value into any column
+			return fmt.Sprint(v), nil
+		}
+	case TypeTimestamp:
+		switch v := v.(type) {
+		case time.Time:
+			return formatTime(v), nil
+		case string:
+			for _, layout := range sqliteTimeLayouts {
+				if parsed, err := time.ParseInLocation(layout, strings.TrimSuffix(v, "Z"), time.UTC); err == nil {
+					return formatTime(parsed), nil
+				}
+			}
+			return nil, fmt.Errorf("invalid timestamp %q", v)
+		}
+	case TypeBlob:
+		switch v := v.(type) {
+		case []byte:
+			return base64.StdEncoding.EncodeToString(v), nil
+		case string:
+			return base64.StdEncoding.EncodeToString([]byte(v)), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot store %T in a %s column", v, t)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// decodeValue converts a JSON value decoded with UseNumber back to the Go
+// value inserted into the column
+func decodeValue(t Type, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch t {
+	case TypeInteger:
+		if n, ok := v.(json.Number); ok {
+			return n.Int64()
+		}
+	case TypeReal:
+		if n, ok := v.(json.Number); ok {
+			return n.Float64()
+		}
+	case TypeBoolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case TypeNumeric, TypeText:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case TypeTimestamp:
+		if s, ok := v.(string); ok {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+	case TypeBlob:
+		if s, ok := v.(string); ok {
+			return base64.StdEncoding.DecodeString(s)
+		}
+	}
+	return nil, fmt.Errorf("unexpected %T for a %s column", v, t)
+}
@@ -1,13 +1,19 @@
 package config
 
 import (
+	"log/slog"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
 	// Test default values
-	cfg := Load()
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
 
 	if cfg.Env != "development" {
 		t.Errorf("Expected default env to be 'development', got '%s'", cfg.Env)
@@ -20,6 +26,10 @@ func TestLoad(t *testing.T) {
 	if cfg.JWTSecret != "your-jwt-secret-key" {
 		t.Errorf("Expected default JWT secret to be 'your-jwt-secret-key', got '%s'", cfg.JWTSecret)
 	}
+
+	if len(cfg.TrustedProxies) != 0 {
+		t.Errorf("Expected no trusted proxies by default, got %v", cfg.TrustedProxies)
+	}
 }
 
 func TestLoadWithEnvVars(t *testing.T) {
@@ -35,7 +45,10 @@ func TestLoadWithEnvVars(t *testing.T) {
 		os.Unsetenv("JWT_SECRET")
 	}()
 
-	cfg := Load()
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
 
 	if cfg.Env != "test" {
 		t.Errorf("Expected env to be 'test', got '%s'", cfg.Env)
@@ -50,36 +63,112 @@ func TestLoadWithEnvVars(t *testing.T) {
 	}
 }
 
-func TestGetEnvAsInt(t *testing.T) {
-	// Test with valid integer
-	os.Setenv("TEST_INT", "42")
-	defer os.Unsetenv("TEST_INT")
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	content := "port: 7000\nlog_level: debug\nread_timeout: 5s\ncors_origins:\n  - http://a.example\n  - http://b.example\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("LOG_LEVEL", "warn")
+
+	cfg, err := Load([]string{"--read-timeout=7s"})
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.Port != "7000" {
+		t.Errorf("Expected port from file to be '7000', got '%s'", cfg.Port)
+	}
+	if cfg.LogLevel != slog.LevelWarn {
+		t.Errorf("Expected env to override file log level, got %v", cfg.LogLevel)
+	}
+	if cfg.ReadTimeout != 7*time.Second {
+		t.Errorf("Expected flag to override file read timeout, got %v", cfg.ReadTimeout)
+	}
+	if len(cfg.CORSOrigins) != 2 || cfg.CORSOrigins[1] != "http://b.example" {
+		t.Errorf("Expected CORS origins from file, got %v", cfg.CORSOrigins)
+	}
+	if cfg.ConfigFile != file {
+		t.Errorf("Expected ConfigFile to be '%s', got '%s'", file, cfg.ConfigFile)
+	}
+}
+
+func TestLoadJSONFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	content := `{"db_max_open_conns": 40, "db_max_idle_conns": 10, "enable_metrics": true}`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
 
-	result := getEnvAsInt("TEST_INT", 10)
-	if result != 42 {
-		t.Errorf("Expected 42, got %d", result)
+	cfg, err := Load([]string{"--config", file})
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
 	}
 
-	// Test with fallback
-	result = getEnvAsInt("NON_EXISTENT", 10)
-	if result != 10 {
-		t.Errorf("Expected fallback value 10, got %d", result)
+	if cfg.DBMaxOpenConns != 40 || cfg.DBMaxIdleConns != 10 {
+		t.Errorf("Expected pool sizes 40/10, got %d/%d", cfg.DBMaxOpenConns, cfg.DBMaxIdleConns)
+	}
+	if !cfg.EnableMetrics {
+		t.Error("Expected EnableMetrics to be true")
 	}
 }
 
-func TestGetEnvAsBool(t *testing.T) {
-	// Test with valid boolean
-	os.Setenv("TEST_BOOL", "true")
-	defer os.Unsetenv("TEST_BOOL")
+func TestLoadErrors(t *testing.T) {
+	unknown := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(unknown, []byte("prot: 8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{"invalid int", map[string]string{"DB_MAX_OPEN_CONNS": "many"}, nil},
+		{"invalid duration", nil, []string{"--read-timeout=soon"}},
+		{"invalid port", map[string]string{"PORT": "99999"}, nil},
+		{"negative timeout", nil, []string{"--shutdown-timeout=-1s"}},
+		{"idle exceeds open", map[string]string{"DB_MAX_OPEN_CONNS": "2", "DB_MAX_IDLE_CONNS": "5"}, nil},
+		{"unknown env", map[string]string{"ENV": "prod"}, nil},
+		{"unknown flag", nil, []string{"--no-such-flag=1"}},
+		{"unknown file key", nil, []string{"--config", unknown}},
+		{"missing file", nil, []string{"--config", "does-not-exist.yaml"}},
+		{"invalid rate limit", map[string]string{"RATE_LIMITS": "/api/v1=lots"}, nil},
+		{"unknown rate limit algorithm", nil, []string{"--rate-limit-algorithm=leaky_bucket"}},
+		{"redis rate limit without redis", map[string]string{"ENABLE_RATE_LIMIT": "true", "RATE_LIMIT_STORE": "redis"}, nil},
+		{"unknown rate limit key", map[string]string{"ENABLE_RATE_LIMIT": "true", "RATE_LIMIT_KEY": "cookie"}, nil},
+		{"invalid trusted proxy", map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8,proxy.local"}, nil},
+		{"production default secret", map[string]string{"ENV": "production"}, nil},
+		{"production without origins", map[string]string{"ENV": "production", "JWT_SECRET": "s3cret", "CORS_ORIGINS": ""}, nil},
+		{"production default origins", map[string]string{"ENV": "production", "JWT_SECRET": "s3cret"}, nil},
+		{"production loopback origin", map[string]string{"ENV": "production", "JWT_SECRET": "s3cret", "CORS_ORIGINS": "https://app.example.com,http://127.0.0.1:3000"}, nil},
+	}
 
-	result := getEnvAsBool("TEST_BOOL", false)
-	if result != true {
-		t.Errorf("Expected true, got %v", result)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, err := Load(tt.args); err == nil {
+				t.Error("Expected error, got none")
+			}
+		})
 	}
+}
+
+func TestLoadProduction(t *testing.T) {
+	t.Setenv("ENV", "production")
+	t.Setenv("JWT_SECRET", "s3cret")
+	t.Setenv("CORS_ORIGINS", "https://app.example.com")
 
-	// Test with fallback
-	result = getEnvAsBool("NON_EXISTENT", false)
-	if result != false {
-		t.Errorf("Expected fallback value false, got %v", result)
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.IsProduction() {
+		t.Error("Expected production config")
 	}
 }
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// secretMask replaces secret values in printed and logged configuration
+const secretMask = "********"
+
+// Setting is a single effective configuration value, as shown by `config print`
+type Setting struct {
+	Key    string
+	Value  string // masked for secrets
+	Source string // e.g. "default", "file config.yaml", "env JWT_SECRET_FILE", "flag --port"
+	Secret bool
+}
+
+// Settings returns every configuration value with its source. Secrets are masked.
+func (c *Config) Settings() []Setting {
+	result := make([]Setting, 0, len(settings))
+	for _, s := range settings {
+		result = append(result, Setting{
+			Key:    s.key,
+			Value:  c.redacted(s),
+			Source: c.Source(s.key),
+			Secret: s.secret,
+		})
+	}
+	return result
+}
+
+// Source reports where the setting with the given key was read from
+func (c *Config) Source(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return "unknown"
+}
+
+// RedactedDatabaseURL returns DatabaseURL with the password masked
+func (c *Config) RedactedDatabaseURL() string {
+	return redactURL(c.DatabaseURL)
+}
+
+// String returns the configuration as key=value pairs with secrets masked
+func (c *Config) String() string {
+	var b strings.Builder
+	for i, s := range settings {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(s.key)
+		b.WriteByte('=')
+		b.WriteString(c.redacted(s))
+	}
+	return b.String()
+}
+
+// LogValue implements slog.LogValuer so that logging a Config never leaks secrets
+func (c *Config) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(settings))
+	for _, s := range settings {
+		attrs = append(attrs, slog.String(s.key, c.redacted(s)))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// redacted returns the printable value of s
+func (c *Config) redacted(s setting) string {
+	value := s.get(c)
+	switch {
+	case !s.secret || value == "":
+		return value
+	case s.key == "database_url":
+		return redactURL(value)
+	default:
+		return secretMask
+	}
+}
+
+// redactURL masks the password in a connection URL. Values that cannot be
+// parsed as a URL are masked entirely.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return secretMask
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), secretMask)
+	}
+	query := u.Query()
+	if query.Has("password") {
+		query.Set("password", secretMask)
+		u.RawQuery = query.Encode()
+	}
+	// Avoid escaping the mask so the output stays readable
+	return strings.ReplaceAll(u.String(), url.QueryEscape(secretMask), secretMask)
+}
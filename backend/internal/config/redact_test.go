@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretFromFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(file, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JWT_SECRET_FILE", file)
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.JWTSecret != "file-secret" {
+		t.Errorf("Expected JWT secret 'file-secret', got '%s'", cfg.JWTSecret)
+	}
+	if src := cfg.Source("jwt_secret"); src != "env JWT_SECRET_FILE" {
+		t.Errorf("Expected source 'env JWT_SECRET_FILE', got '%s'", src)
+	}
+}
+
+func TestSecretFromFileConflicts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "database_url")
+	if err := os.WriteFile(file, []byte("postgres://u:p@db/app"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DATABASE_URL_FILE", file)
+	t.Setenv("DATABASE_URL", "postgres://u:p@db/other")
+
+	if _, err := Load(nil); err == nil {
+		t.Error("Expected error when both DATABASE_URL and DATABASE_URL_FILE are set")
+	}
+}
+
+func TestSources(t *testing.T) {
+	t.Setenv("PORT", "9000")
+
+	cfg, err := Load([]string{"--log-level=debug"})
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"env":       "default",
+		"port":      "env PORT",
+		"log_level": "flag --log-level",
+	}
+	for key, want := range tests {
+		if got := cfg.Source(key); got != want {
+			t.Errorf("Source(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	t.Setenv("JWT_SECRET", "super-secret")
+	t.Setenv("DATABASE_URL", "postgres://user:hunter2@db:5432/app?sslmode=disable")
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	var logged bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("config", "config", cfg)
+
+	outputs := map[string]string{
+		"String":    cfg.String(),
+		"Printf %v": fmt.Sprintf("%v", cfg),
+		"LogValue":  logged.String(),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, "super-secret") || strings.Contains(out, "hunter2") {
+			t.Errorf("%s leaks a secret: %s", name, out)
+		}
+	}
+
+	if got := cfg.RedactedDatabaseURL(); got != "postgres://user:********@db:5432/app?sslmode=disable" {
+		t.Errorf("Unexpected redacted database URL: %s", got)
+	}
+
+	for _, s := range cfg.Settings() {
+		if s.Key == "jwt_secret" && s.Value != secretMask {
+			t.Errorf("Expected masked jwt_secret, got '%s'", s.Value)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"postgres://user@db/app", "postgres://user@db/app"},
+		{"postgres://db/app?password=secret", "postgres://db/app?password=********"},
+		{"host=db password=secret", secretMask},
+	}
+	for _, tt := range tests {
+		if got := redactURL(tt.in); got != tt.want {
+			t.Errorf("redactURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultPollInterval is how often the Watcher checks the config file for changes
+const DefaultPollInterval = 2 * time.Second
+
+// Subscriber is notified after a new configuration has been activated.
+// Both snapshots are shared and must not be modified.
+type Subscriber func(old, new *Config)
+
+// Watcher holds the active configuration and reloads it on SIGHUP or when
+// the config file changes. Reads through Config are lock-free; a reload
+// replaces the whole snapshot, so readers never see a partial update.
+type Watcher struct {
+	args         []string
+	PollInterval time.Duration
+
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex // serializes reloads and guards subscribers
+	subscribers []Subscriber
+}
+
+// NewWatcher loads the initial configuration from args (see Load)
+func NewWatcher(args []string) (*Watcher, error) {
+	cfg, err := Load(args)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{args: args, PollInterval: DefaultPollInterval}
+	w.current.Store(cfg)
+	return w, nil
+}
+
+// Config returns the active configuration snapshot
+func (w *Watcher) Config() *Config {
+	return w.current.Load()
+}
+
+// Subscribe registers fn to be called after every successful reload
+func (w *Watcher) Subscribe(fn Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload loads and validates a new configuration and activates it.
+// If loading fails, the active configuration is kept and the error returned.
+// Loading happens under the reload lock, so concurrent reloads activate
+// their snapshots in the order they read them.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cfg, err := Load(w.args)
+	if err != nil {
+		return err
+	}
+
+	old := w.current.Swap(cfg)
+	for _, fn := range w.subscribers {
+		fn(old, cfg)
+	}
+	return nil
+}
+
+// Run reloads the configuration on SIGHUP and whenever the config file
+// changes, until ctx is cancelled. Failed reloads are logged and the
+// previous configuration stays active.
+func (w *Watcher) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	interval := w.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := w.fileStamp()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-hup:
+			log.Println("🔄 Reloading configuration (SIGHUP)")
+			w.reloadAndLog()
+			last = w.fileStamp()
+		case <-ticker.C:
+			if stamp := w.fileStamp(); stamp != last {
+				last = stamp
+				log.Println("🔄 Reloading configuration (config file changed)")
+				w.reloadAndLog()
+			}
+		}
+	}
+}
+
+func (w *Watcher) reloadAndLog() {
+	if err := w.Reload(); err != nil {
+		log.Printf("❌ Configuration reload failed, keeping previous configuration: %v", err)
+	}
+}
+
+// fileStamp identifies the current version of the config file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func (w *Watcher) fileStamp() fileStamp {
+	path := w.Config().ConfigFile
+	if path == "" {
+		return fileStamp{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, file, "log_level: info\n")
+
+	w, err := NewWatcher([]string{"--config", file})
+	if err != nil {
+		t.Fatalf("NewWatcher() failed: %v", err)
+	}
+	initial := w.Config()
+
+	var gotOld, gotNew *Config
+	w.Subscribe(func(old, new *Config) {
+		gotOld, gotNew = old, new
+	})
+
+	writeConfigFile(t, file, "log_level: debug\n")
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+
+	if w.Config().LogLevel != slog.LevelDebug {
+		t.Errorf("Expected reloaded log level debug, got %v", w.Config().LogLevel)
+	}
+	if gotOld != initial || gotNew != w.Config() {
+		t.Error("Subscriber should receive the previous and the new snapshot")
+	}
+	if initial.LogLevel != slog.LevelInfo {
+		t.Error("Previous snapshot must not be modified by a reload")
+	}
+}
+
+func TestWatcherKeepsConfigOnInvalidReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, file, "port: 9000\n")
+
+	w, err := NewWatcher([]string{"--config", file})
+	if err != nil {
+		t.Fatalf("NewWatcher() failed: %v", err)
+	}
+	initial := w.Config()
+
+	called := false
+	w.Subscribe(func(old, new *Config) { called = true })
+
+	writeConfigFile(t, file, "port: not-a-port\n")
+	if err := w.Reload(); err == nil {
+		t.Error("Expected reload of invalid config to fail")
+	}
+
+	if w.Config() != initial {
+		t.Error("Active config should be kept after a failed reload")
+	}
+	if called {
+		t.Error("Subscribers should not be notified after a failed reload")
+	}
+}
+
+func TestWatcherRunDetectsFileChange(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, file, "cors_origins: http://a.example\n")
+
+	w, err := NewWatcher([]string{"--config", file})
+	if err != nil {
+		t.Fatalf("NewWatcher() failed: %v", err)
+	}
+	w.PollInterval = 10 * time.Millisecond
+
+	reloaded := make(chan *Config, 1)
+	w.Subscribe(func(old, new *Config) { reloaded <- new })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	// Let the watcher record the initial file state before changing it
+	time.Sleep(30 * time.Millisecond)
+	writeConfigFile(t, file, "cors_origins: http://a.example,http://b.example\n")
+
+	select {
+	case cfg := <-reloaded:
+		if len(cfg.CORSOrigins) != 2 {
+			t.Errorf("Expected 2 CORS origins after reload, got %v", cfg.CORSOrigins)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watcher did not reload after the config file changed")
+	}
+}
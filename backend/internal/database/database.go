@@ -0,0 +1,31 @@
+// Package database opens the Postgres connection pool.
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/XSAM/otelsql"
+	_ "github.com/lib/pq"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Open creates a connection pool for cfg.DatabaseURL with the configured pool
+// settings. Connections are established lazily, so Open succeeds even when
+// the database is down; use PingContext or the readiness probe to check it.
+// Queries are traced as child spans of the span in their context.
+func Open(cfg *config.Config) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", cfg.DatabaseURL,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	return db, nil
+}
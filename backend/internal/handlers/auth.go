@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/response"
+)
+
+// AuthHandler serves the /auth endpoints and /me
+type AuthHandler struct {
+	auth *auth.Service
+}
+
+// NewAuthHandler creates handlers backed by svc
+func NewAuthHandler(svc *auth.Service) *AuthHandler {
+	return &AuthHandler{auth: svc}
+}
+
+type registerRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Register creates an account and returns the user with a token pair
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req registerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "email, name and password are required")
+		return
+	}
+
+	user, tokens, err := h.auth.Register(c.Request.Context(), req.Email, req.Name, req.Password)
+	switch {
+	case errors.Is(err, auth.ErrInvalidUser):
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, auth.ErrEmailTaken):
+		response.Error(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		h.internalError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"user": user, "tokens": tokens})
+}
+
+// Login checks the credentials and returns the user with a token pair
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "email and password are required")
+		return
+	}
+
+	user, tokens, err := h.auth.Login(c.Request.Context(), req.Email, req.Password)
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		response.Error(c, http.StatusUnauthorized, err.Error())
+		return
+	case err != nil:
+		h.internalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user, "tokens": tokens})
+}
+
+// Refresh exchanges a refresh token for a new token pair
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	tokens, err := h.auth.Refresh(c.Request.Context(), req.RefreshToken)
+	switch {
+	case errors.Is(err, auth.ErrInvalidRefreshToken):
+		response.Error(c, http.StatusUnauthorized, err.Error())
+		return
+	case err != nil:
+		h.internalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// Logout revokes the given refresh token
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	if err := h.auth.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		h.internalError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Me returns the authenticated user. It must be registered on a protected group.
+func (h *AuthHandler) Me(c *gin.Context) {
+	claims, ok := middleware.ClaimsFromContext(c.Request.Context())
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	user, err := h.auth.User(c.Request.Context(), claims.UserID)
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		response.Error(c, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		h.internalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// internalError logs err and responds with a generic 500
+func (h *AuthHandler) internalError(c *gin.Context, err error) {
+	middleware.LoggerFromContext(c.Request.Context()).Error("auth request failed", "error", err)
+	response.Error(c, http.StatusInternalServerError, "Internal server error")
+}
@@ -4,17 +4,43 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/health"
 )
 
+const serviceName = "sum25-go-flutter-course-backend"
+
 // HealthCheck returns server health status
 func HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "healthy",
-		"service": "sum25-go-flutter-course-backend",
-		"version": "1.0.0",
+		"service": serviceName,
+		"version": health.ReadBuildInfo().Version,
 	})
 }
 
+// Livez reports that the process is up and able to serve requests.
+// It does not check dependencies, so a database outage never restarts the server.
+func Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  health.StatusOK,
+		"service": serviceName,
+		"build":   health.ReadBuildInfo(),
+	})
+}
+
+// Readyz reports whether the server can take traffic, based on the dependency
+// checks in registry. It fails once graceful shutdown has begun.
+func Readyz(registry *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := registry.Run(c.Request.Context())
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
+
 // Ping returns a simple pong response
 func Ping(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
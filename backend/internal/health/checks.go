@@ -0,0 +1,76 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Pinger is implemented by *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DatabaseChecker pings the database
+func DatabaseChecker(db Pinger) Checker {
+	return CheckerFunc(db.PingContext)
+}
+
+// RedisChecker sends a PING to the Redis server at addr (host:port)
+func RedisChecker(addr string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		if _, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+			return err
+		}
+		reply, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if reply = strings.TrimSpace(reply); reply != "+PONG" {
+			return fmt.Errorf("unexpected PING reply %q", reply)
+		}
+		return nil
+	})
+}
+
+// DiskSpaceChecker fails when the filesystem holding path has less than
+// minFreeBytes available
+func DiskSpaceChecker(path string, minFreeBytes uint64) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		free, err := freeDiskSpace(path)
+		if err != nil {
+			return err
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("only %d bytes free on %s, need %d", free, path, minFreeBytes)
+		}
+		return nil
+	})
+}
+
+// MigrationChecker fails while any migration is pending. pending returns
+// the versions that are not applied yet.
+func MigrationChecker(pending func(ctx context.Context) ([]int64, error)) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		versions, err := pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(versions) > 0 {
+			return fmt.Errorf("%d pending migrations: %v", len(versions), versions)
+		}
+		return nil
+	})
+}
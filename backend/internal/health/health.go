@@ -0,0 +1,224 @@
+// Package health runs dependency checks for the readiness probe.
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default settings for registered checks
+const (
+	DefaultTimeout  = 2 * time.Second
+	DefaultCacheTTL = 5 * time.Second
+)
+
+// Check statuses
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// ErrShuttingDown is reported by readiness once graceful shutdown has begun
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Checker checks a single dependency
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx)
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Option configures a registered check
+type Option func(*check)
+
+// WithTimeout sets how long a single run of the check may take
+func WithTimeout(d time.Duration) Option {
+	return func(c *check) { c.timeout = d }
+}
+
+// WithCacheTTL sets how long a check result is reused before the check runs again
+func WithCacheTTL(d time.Duration) Option {
+	return func(c *check) { c.cacheTTL = d }
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+// Report is the combined outcome of all registered checks
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+	Build  BuildInfo              `json:"build"`
+}
+
+// OK reports whether all checks passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	last   CheckResult
+	expiry time.Time
+}
+
+// Registry holds the dependency checks that make up readiness
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]*check
+
+	shuttingDown atomic.Bool
+	now          func() time.Time
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		checks: make(map[string]*check),
+		now:    time.Now,
+	}
+}
+
+// Register adds a named check, replacing any check with the same name
+func (r *Registry) Register(name string, checker Checker, opts ...Option) {
+	c := &check{
+		name:     name,
+		checker:  checker,
+		timeout:  DefaultTimeout,
+		cacheTTL: DefaultCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = c
+}
+
+// SetShuttingDown makes readiness fail from now on, so load balancers stop
+// routing new traffic while in-flight requests drain
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether graceful shutdown has begun
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Run runs all checks concurrently, reusing cached results that have not expired
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		checks = append(checks, c)
+	}
+	r.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool { return checks[i].name < checks[j].name })
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checks)+1),
+		Build:  ReadBuildInfo(),
+	}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	if r.ShuttingDown() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = CheckResult{
+			Status:    StatusFail,
+			Error:     ErrShuttingDown.Error(),
+			Duration:  "0s",
+			CheckedAt: r.now(),
+		}
+	}
+	return report
+}
+
+// runCheck returns the cached result of c or runs it with its timeout. A
+// result is not cached when the caller's context ended mid-check, so one
+// impatient probe client cannot fail readiness for the whole cache TTL
+func (r *Registry) runCheck(ctx context.Context, c *check) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := r.now()
+	if now.Before(c.expiry) {
+		cached := c.last
+		cached.Cached = true
+		return cached
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runWithContext(checkCtx, c.checker)
+	result := CheckResult{
+		Status:    StatusOK,
+		Duration:  time.Since(start).Round(time.Microsecond).String(),
+		CheckedAt: now,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	if ctx.Err() != nil {
+		return result
+	}
+
+	c.last = result
+	c.expiry = now.Add(c.cacheTTL)
+	return result
+}
+
+// runWithContext runs the checker but gives up when ctx is done, even if the
+// checker itself ignores ctx
+func runWithContext(ctx context.Context, checker Checker) error {
+	done := make(chan error, 1)
+	go func() { done <- checker.Check(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,181 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistryRun(t *testing.T) {
+	r := NewRegistry()
+	r.Register("ok", CheckerFunc(func(ctx context.Context) error { return nil }))
+
+	report := r.Run(context.Background())
+	if !report.OK() {
+		t.Fatalf("Expected report to be ok, got %+v", report)
+	}
+	if report.Checks["ok"].Status != StatusOK {
+		t.Errorf("Expected check 'ok' to pass, got %+v", report.Checks["ok"])
+	}
+
+	r.Register("broken", CheckerFunc(func(ctx context.Context) error { return errors.New("boom") }))
+	report = r.Run(context.Background())
+	if report.OK() {
+		t.Error("Expected report to fail when a check fails")
+	}
+	if got := report.Checks["broken"].Error; got != "boom" {
+		t.Errorf("Expected error 'boom', got %q", got)
+	}
+}
+
+func TestRegistryCachesResults(t *testing.T) {
+	r := NewRegistry()
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	var calls atomic.Int32
+	r.Register("counted", CheckerFunc(func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}), WithCacheTTL(time.Minute))
+
+	r.Run(context.Background())
+	report := r.Run(context.Background())
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 check run within TTL, got %d", calls.Load())
+	}
+	if !report.Checks["counted"].Cached {
+		t.Error("Expected second result to be cached")
+	}
+
+	now = now.Add(2 * time.Minute)
+	r.Run(context.Background())
+	if calls.Load() != 2 {
+		t.Errorf("Expected check to run again after TTL, got %d runs", calls.Load())
+	}
+}
+
+func TestRegistryTimeout(t *testing.T) {
+	r := NewRegistry()
+	r.Register("slow", CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}), WithTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	report := r.Run(context.Background())
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Run should not wait for a check past its timeout")
+	}
+	if report.Checks["slow"].Status != StatusFail {
+		t.Error("Expected slow check to fail on timeout")
+	}
+}
+
+func TestRegistryDoesNotCacheCanceledRun(t *testing.T) {
+	r := NewRegistry()
+	var calls atomic.Int32
+	r.Register("slow", CheckerFunc(func(ctx context.Context) error {
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}), WithCacheTTL(time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if report := r.Run(ctx); report.OK() {
+		t.Fatal("Expected canceled run to fail")
+	}
+
+	report := r.Run(context.Background())
+	if calls.Load() != 2 {
+		t.Errorf("Expected check to run again after a canceled run, got %d runs", calls.Load())
+	}
+	if !report.OK() || report.Checks["slow"].Cached {
+		t.Errorf("Expected a fresh passing result, got %+v", report.Checks["slow"])
+	}
+}
+
+func TestRegistryShuttingDown(t *testing.T) {
+	r := NewRegistry()
+	r.Register("ok", CheckerFunc(func(ctx context.Context) error { return nil }))
+	r.SetShuttingDown()
+
+	report := r.Run(context.Background())
+	if report.OK() {
+		t.Error("Expected readiness to fail during shutdown")
+	}
+	if report.Checks["shutdown"].Status != StatusFail {
+		t.Error("Expected shutdown entry in report")
+	}
+}
+
+func TestRedisChecker(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			for range 3 { // *1, $4, PING
+				r.ReadString('\n')
+			}
+			conn.Write([]byte("+PONG\r\n"))
+			conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := RedisChecker(ln.Addr().String()).Check(ctx); err != nil {
+		t.Errorf("RedisChecker failed: %v", err)
+	}
+}
+
+func TestMigrationChecker(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending []int64
+		err     error
+		ok      bool
+	}{
+		{"up to date", nil, nil, true},
+		{"newest pending", []int64{4}, nil, false},
+		{"older pending", []int64{2}, nil, false},
+		{"status error", nil, errors.New("no database"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending := func(ctx context.Context) ([]int64, error) { return tt.pending, tt.err }
+			err := MigrationChecker(pending).Check(context.Background())
+			if tt.ok && err != nil {
+				t.Errorf("Expected check to pass, got %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Error("Expected check to fail")
+			}
+		})
+	}
+}
+
+func TestDiskSpaceChecker(t *testing.T) {
+	if err := DiskSpaceChecker(t.TempDir(), 1).Check(context.Background()); err != nil {
+		t.Errorf("Expected disk check to pass, got %v", err)
+	}
+	if err := DiskSpaceChecker(t.TempDir(), 1<<62).Check(context.Background()); err == nil {
+		t.Error("Expected disk check to fail with an impossible threshold")
+	}
+}
@@ -0,0 +1,23 @@
+package jwtservice
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Claims represents JWT token claims
+type Claims struct {
+	UserID int    `json:"user_id"`
+	Email  string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// Valid validates the claims (required by jwt.Claims interface)
+func (c Claims) Valid() error {
+	if err := c.RegisteredClaims.Valid(); err != nil {
+		return err
+	}
+	if c.UserID <= 0 || c.Email == "" {
+		return ErrInvalidClaims
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Package jwtservice issues and verifies the HS256-signed access tokens used
+// by the API.
+package jwtservice
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// DefaultTTL is how long tokens are valid unless WithTTL is given
+const DefaultTTL = 24 * time.Hour
+
+// JWTService handles JWT token operations
+type JWTService struct {
+	secretKey []byte
+	ttl       time.Duration
+	issuer    string
+	now       func() time.Time
+}
+
+// Option configures a JWTService
+type Option func(*JWTService)
+
+// WithTTL sets how long generated tokens are valid
+func WithTTL(ttl time.Duration) Option {
+	return func(j *JWTService) { j.ttl = ttl }
+}
+
+// WithIssuer sets the iss claim of generated tokens; validated tokens must carry it
+func WithIssuer(issuer string) Option {
+	return func(j *JWTService) { j.issuer = issuer }
+}
+
+// NewJWTService creates a new JWT service. secretKey must not be empty.
+func NewJWTService(secretKey string, opts ...Option) (*JWTService, error) {
+	if secretKey == "" {
+		return nil, NewValidationError("secretKey", "must not be empty")
+	}
+	j := &JWTService{secretKey: []byte(secretKey), ttl: DefaultTTL, now: time.Now}
+	for _, opt := range opts {
+		opt(j)
+	}
+	if j.ttl <= 0 {
+		return nil, NewValidationError("ttl", "must be positive")
+	}
+	return j, nil
+}
+
+// TTL returns how long generated tokens are valid
+func (j *JWTService) TTL() time.Duration {
+	return j.ttl
+}
+
+// GenerateToken creates a signed HS256 token for the user. userID must be
+// positive and email must not be empty. Every token gets a unique ID (jti).
+func (j *JWTService) GenerateToken(userID int, email string) (string, error) {
+	if userID <= 0 {
+		return "", NewValidationError("userID", "must be positive")
+	}
+	if email == "" {
+		return "", NewValidationError("email", "must not be empty")
+	}
+
+	now := j.now()
+	claims := Claims{
+		UserID: userID,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        rand.Text(),
+			Issuer:    j.issuer,
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.ttl)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secretKey)
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+	return token, nil
+}
+
+// ValidateToken verifies the signature and expiry of tokenString and returns
+// its claims. Expired tokens fail with ErrTokenExpired; every other failure
+// wraps ErrInvalidToken.
+func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	if tokenString == "" {
+		return nil, ErrEmptyToken
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, NewInvalidSigningMethodError(token.Header["alg"])
+		}
+		return j.secretKey, nil
+	})
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, ErrTokenExpired
+	case errors.Is(err, ErrInvalidClaims):
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, ErrInvalidClaims)
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if j.issuer != "" && claims.Issuer != j.issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+	return claims, nil
+}
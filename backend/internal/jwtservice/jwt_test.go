@@ -0,0 +1,105 @@
+package jwtservice
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewJWTService(t *testing.T) {
+	if _, err := NewJWTService(""); err == nil {
+		t.Error("Expected an error for an empty secret")
+	}
+	if _, err := NewJWTService("secret", WithTTL(-time.Minute)); err == nil {
+		t.Error("Expected an error for a negative TTL")
+	}
+	service, err := NewJWTService("secret", WithTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("NewJWTService() failed: %v", err)
+	}
+	if service.TTL() != time.Minute {
+		t.Errorf("Expected TTL 1m, got %s", service.TTL())
+	}
+}
+
+func TestGenerateAndValidateToken(t *testing.T) {
+	service, _ := NewJWTService("test-secret")
+
+	for _, tt := range []struct {
+		name   string
+		userID int
+		email  string
+	}{
+		{"zero userID", 0, "test@example.com"},
+		{"negative userID", -1, "test@example.com"},
+		{"empty email", 1, ""},
+	} {
+		if _, err := service.GenerateToken(tt.userID, tt.email); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	token, err := service.GenerateToken(123, "test@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken() failed: %v", err)
+	}
+	claims, err := service.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken() failed: %v", err)
+	}
+	if claims.UserID != 123 || claims.Email != "test@example.com" || claims.Subject != "123" {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+	if claims.ID == "" {
+		t.Error("Expected a token ID")
+	}
+
+	if _, err := service.ValidateToken(""); !errors.Is(err, ErrEmptyToken) {
+		t.Errorf("Expected ErrEmptyToken, got %v", err)
+	}
+	for _, invalid := range []string{"invalid.token.here", "not-a-jwt-token", token + "x"} {
+		if _, err := service.ValidateToken(invalid); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("ValidateToken(%q): expected ErrInvalidToken, got %v", invalid, err)
+		}
+	}
+}
+
+func TestValidateTokenExpired(t *testing.T) {
+	service, _ := NewJWTService("test-secret", WithTTL(time.Hour))
+	service.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+
+	token, err := service.GenerateToken(1, "test@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken() failed: %v", err)
+	}
+	if _, err := service.ValidateToken(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestValidateTokenDifferentSecrets(t *testing.T) {
+	service1, _ := NewJWTService("secret1")
+	service2, _ := NewJWTService("secret2")
+
+	token, err := service1.GenerateToken(123, "test@example.com")
+	if err != nil {
+		t.Fatalf("GenerateToken() failed: %v", err)
+	}
+	if _, err := service2.ValidateToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken with a different secret, got %v", err)
+	}
+}
+
+func TestValidateTokenIssuer(t *testing.T) {
+	issuer, _ := NewJWTService("secret", WithIssuer("api"))
+	other, _ := NewJWTService("secret", WithIssuer("other"))
+
+	token, _ := other.GenerateToken(1, "test@example.com")
+	if _, err := issuer.ValidateToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for a foreign issuer, got %v", err)
+	}
+	token, _ = issuer.GenerateToken(1, "test@example.com")
+	if _, err := issuer.ValidateToken(token); err != nil {
+		t.Errorf("Expected own token to validate, got %v", err)
+	}
+}
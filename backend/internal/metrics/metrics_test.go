@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rr.Body)
+	return string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := New()
+
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/2", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	out := scrape(t, m)
+	expected := []string{
+		`backend_http_requests_total{method="GET",route="/users/:id",status="200"} 2`,
+		`backend_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`backend_http_request_duration_seconds_count{method="GET",route="/users/:id"} 2`,
+		`backend_http_response_size_bytes_sum{method="GET",route="/users/:id"} 10`,
+		`backend_http_requests_in_flight 0`,
+		`go_goroutines`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected metrics output to contain %q", want)
+		}
+	}
+}
+
+func TestRegisterDB(t *testing.T) {
+	m := New()
+	db, err := sql.Open("postgres", "postgres://localhost/unused")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := m.RegisterDB(db, "testdb"); err != nil {
+		t.Fatalf("RegisterDB() failed: %v", err)
+	}
+	if out := scrape(t, m); !strings.Contains(out, `go_sql_max_open_connections{db_name="testdb"}`) {
+		t.Error("Expected database pool metrics in output")
+	}
+}
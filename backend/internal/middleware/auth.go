@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jwtservice"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/response"
+)
+
+// ClaimsKey is the gin context key holding the verified *jwtservice.Claims
+const ClaimsKey = "claims"
+
+// authErrorKey holds why a presented token was rejected
+const authErrorKey = "auth_error"
+
+type claimsKey struct{}
+
+// TokenValidator verifies access tokens; *jwtservice.JWTService implements it
+type TokenValidator interface {
+	ValidateToken(token string) (*jwtservice.Claims, error)
+}
+
+// Authenticate verifies the bearer token of the request, if any, and stores
+// its claims in the request context (see ClaimsFromContext), under ClaimsKey
+// and, for RateLimit, the user ID under UserIDKey. The request-scoped logger
+// gets the user ID too.
+//
+// Requests without a valid token continue anonymously; use RequireAuth or
+// Protected to reject them. Register Authenticate before RateLimit so
+// requests can be counted by user.
+func Authenticate(tokens TokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			c.Set(authErrorKey, jwtservice.ErrInvalidToken)
+			c.Next()
+			return
+		}
+
+		claims, err := tokens.ValidateToken(strings.TrimSpace(token))
+		if err != nil {
+			c.Set(authErrorKey, err)
+			c.Next()
+			return
+		}
+
+		userID := strconv.Itoa(claims.UserID)
+		c.Set(ClaimsKey, claims)
+		c.Set(UserIDKey, userID)
+		ctx := context.WithValue(c.Request.Context(), claimsKey{}, claims)
+		ctx = context.WithValue(ctx, loggerKey{}, LoggerFromContext(ctx).With(slog.String("user_id", userID)))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireAuth rejects requests that Authenticate did not verify with
+// 401 Unauthorized
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := ClaimsFromContext(c.Request.Context()); ok {
+			c.Next()
+			return
+		}
+
+		message := "authentication required"
+		if err, ok := c.Get(authErrorKey); ok {
+			message = "invalid token"
+			if errors.Is(err.(error), jwtservice.ErrTokenExpired) {
+				message = "token expired"
+			}
+		}
+		c.Header("WWW-Authenticate", `Bearer realm="api"`)
+		response.Error(c, http.StatusUnauthorized, message)
+	}
+}
+
+// Protected declares a route group whose routes all require authentication
+func Protected(r gin.IRouter, path string, handlers ...gin.HandlerFunc) *gin.RouterGroup {
+	return r.Group(path, append([]gin.HandlerFunc{RequireAuth()}, handlers...)...)
+}
+
+// ClaimsFromContext returns the claims verified by Authenticate
+func ClaimsFromContext(ctx context.Context) (*jwtservice.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*jwtservice.Claims)
+	return claims, ok
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jwtservice"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/response"
+)
+
+func TestAuthenticate(t *testing.T) {
+	tokens, _ := jwtservice.NewJWTService("test-secret")
+	expired, _ := jwtservice.NewJWTService("test-secret", jwtservice.WithTTL(time.Nanosecond))
+
+	router := gin.New()
+	router.Use(Authenticate(tokens))
+	router.GET("/public", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(UserIDKey))
+	})
+	Protected(router, "/private").GET("", func(c *gin.Context) {
+		claims, _ := ClaimsFromContext(c.Request.Context())
+		c.String(http.StatusOK, strconv.Itoa(claims.UserID))
+	})
+
+	valid, _ := tokens.GenerateToken(42, "test@example.com")
+	stale, _ := expired.GenerateToken(42, "test@example.com")
+	time.Sleep(time.Second) // expiry has second precision
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+		wantBody      string
+		wantError     string
+	}{
+		{"public anonymous", "/public", "", http.StatusOK, "", ""},
+		{"public with token", "/public", "Bearer " + valid, http.StatusOK, "42", ""},
+		{"public with invalid token", "/public", "Bearer nope", http.StatusOK, "", ""},
+		{"private with token", "/private", "Bearer " + valid, http.StatusOK, "42", ""},
+		{"private anonymous", "/private", "", http.StatusUnauthorized, "", "authentication required"},
+		{"private with invalid token", "/private", "Bearer nope", http.StatusUnauthorized, "", "invalid token"},
+		{"private with other scheme", "/private", "Basic " + valid, http.StatusUnauthorized, "", "invalid token"},
+		{"private with expired token", "/private", "Bearer " + stale, http.StatusUnauthorized, "", "token expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if tt.wantError == "" {
+				if w.Body.String() != tt.wantBody {
+					t.Errorf("Expected body %q, got %q", tt.wantBody, w.Body)
+				}
+				return
+			}
+			var body response.ErrorBody
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Invalid error body: %v", err)
+			}
+			if body.Error != tt.wantError {
+				t.Errorf("Expected error %q, got %q", tt.wantError, body.Error)
+			}
+			if w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected a WWW-Authenticate header")
+			}
+		})
+	}
+}
@@ -2,21 +2,18 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/pkg/cors"
 )
 
-// CORS middleware to handle Cross-Origin Resource Sharing
-func CORS() gin.HandlerFunc {
-	return gin.HandlerFunc(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+// CORS applies the policies of engine to every request. Register it on the
+// router (not on a group) so preflight requests for unregistered OPTIONS
+// routes are answered too; per-group policies are configured on the engine.
+func CORS(engine *cors.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if engine.Apply(c.Writer, c.Request) {
+			c.Abort()
 			return
 		}
-
 		c.Next()
-	})
+	}
 }
@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"math"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/response"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID between services and back to the client
+const RequestIDHeader = "X-Request-ID"
+
+// redactedValue replaces sensitive values in logs
+const redactedValue = "[REDACTED]"
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// LoggerConfig configures RequestLogger
+type LoggerConfig struct {
+	// Logger is the base logger; requests get a child logger with their request ID
+	Logger *slog.Logger
+	// SampleRate is the fraction (0..1) of successful requests that are logged.
+	// Client errors, server errors and slow requests are always logged.
+	SampleRate float64
+	// SampleRateVar, when set, replaces SampleRate and may change while serving
+	SampleRateVar *SampleRateVar
+	// SlowThreshold marks requests taking longer as slow
+	SlowThreshold time.Duration
+	// SkipPaths are never logged, e.g. health probes
+	SkipPaths []string
+	// Headers lists request headers to include in the log entry
+	Headers []string
+	// RedactHeaders lists headers whose values are masked, e.g. Authorization
+	RedactHeaders []string
+	// RedactQueryParams lists query parameters whose values are masked
+	RedactQueryParams []string
+}
+
+// SampleRateVar is a log sample rate that can be changed while serving, as
+// slog.LevelVar is for levels. The zero value samples nothing.
+type SampleRateVar struct {
+	bits atomic.Uint64
+}
+
+// Rate returns the current sample rate
+func (v *SampleRateVar) Rate() float64 {
+	return math.Float64frombits(v.bits.Load())
+}
+
+// Set changes the sample rate
+func (v *SampleRateVar) Set(rate float64) {
+	v.bits.Store(math.Float64bits(rate))
+}
+
+// DefaultLoggerConfig returns a LoggerConfig that logs every request with slog.Default
+func DefaultLoggerConfig() LoggerConfig {
+	return LoggerConfig{
+		Logger:            slog.Default(),
+		SampleRate:        1,
+		SlowThreshold:     time.Second,
+		Headers:           []string{"User-Agent", "Authorization"},
+		RedactHeaders:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+		RedactQueryParams: []string{"token", "access_token", "refresh_token", "password", "api_key"},
+	}
+}
+
+// RequestLogger assigns or propagates an X-Request-ID, stores a request-scoped
+// logger in the request context and logs one structured entry per request
+func RequestLogger(cfg LoggerConfig) gin.HandlerFunc {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	skip := make(map[string]bool, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skip[p] = true
+	}
+	redactHeaders := make(map[string]bool, len(cfg.RedactHeaders))
+	for _, h := range cfg.RedactHeaders {
+		redactHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(response.RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		logger := cfg.Logger.With(slog.String("request_id", id))
+		ctx := context.WithValue(c.Request.Context(), requestIDKey{}, id)
+		ctx = context.WithValue(ctx, loggerKey{}, logger)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if skip[c.Request.URL.Path] {
+			return
+		}
+
+		latency := time.Since(start)
+		status := c.Writer.Status()
+		slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+		rate := cfg.SampleRate
+		if cfg.SampleRateVar != nil {
+			rate = cfg.SampleRateVar.Rate()
+		}
+		if status < http.StatusBadRequest && !slow && rate < 1 && mathrand.Float64() >= rate {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", latency),
+			slog.Int("bytes", size),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+		}
+		if query := redactQuery(c.Request.URL.RawQuery, cfg.RedactQueryParams); query != "" {
+			attrs = append(attrs, slog.String("query", query))
+		}
+		if headers := logHeaders(c.Request.Header, cfg.Headers, redactHeaders); len(headers) > 0 {
+			attrs = append(attrs, slog.Any("headers", headers))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest || slow:
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// LoggerFromContext returns the request-scoped logger, or slog.Default outside a request
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestIDFromContext returns the request ID assigned by RequestLogger
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID accepts client-supplied IDs that are short and free of control characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	return rand.Text()
+}
+
+// logHeaders picks the configured headers, masking sensitive ones.
+// For Authorization-style headers the scheme is kept, e.g. "Bearer [REDACTED]".
+func logHeaders(h http.Header, names []string, redact map[string]bool) map[string]string {
+	result := make(map[string]string)
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		value := h.Get(name)
+		if value == "" {
+			continue
+		}
+		if redact[name] {
+			scheme, _, found := strings.Cut(value, " ")
+			if found && strings.HasSuffix(name, "Authorization") {
+				value = scheme + " " + redactedValue
+			} else {
+				value = redactedValue
+			}
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// redactQuery masks the values of sensitive query parameters
+func redactQuery(raw string, params []string) string {
+	if raw == "" || len(params) == 0 {
+		return raw
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return redactedValue
+	}
+	for _, p := range params {
+		if values.Has(p) {
+			values.Set(p, redactedValue)
+		}
+	}
+	return values.Encode()
+}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/response"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newLoggedRouter(buf *bytes.Buffer, mutate func(*LoggerConfig)) *gin.Engine {
+	cfg := DefaultLoggerConfig()
+	cfg.Logger = slog.New(slog.NewJSONHandler(buf, nil))
+	if mutate != nil {
+		mutate(&cfg)
+	}
+
+	router := gin.New()
+	router.Use(RequestLogger(cfg), Recovery())
+	router.GET("/users/:id", func(c *gin.Context) {
+		LoggerFromContext(c.Request.Context()).Info("handler")
+		c.String(http.StatusOK, "hello")
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	return router
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %s", line)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggedRouter(&buf, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42?token=abc&page=2", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	id := rr.Header().Get(RequestIDHeader)
+	if id == "" {
+		t.Fatal("Expected a generated X-Request-ID header")
+	}
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("Expected handler and request log entries, got %d", len(entries))
+	}
+	if entries[0]["request_id"] != id {
+		t.Error("Handler log entry should carry the request ID")
+	}
+
+	entry := entries[1]
+	expected := map[string]any{
+		"method":     "GET",
+		"route":      "/users/:id",
+		"status":     float64(200),
+		"bytes":      float64(5),
+		"request_id": id,
+	}
+	for key, want := range expected {
+		if entry[key] != want {
+			t.Errorf("Expected %s=%v, got %v", key, want, entry[key])
+		}
+	}
+	if strings.Contains(buf.String(), "secret-token") || strings.Contains(buf.String(), "abc") {
+		t.Errorf("Log output leaks sensitive values: %s", buf.String())
+	}
+	headers, _ := entry["headers"].(map[string]any)
+	if headers["Authorization"] != "Bearer [REDACTED]" {
+		t.Errorf("Expected redacted Authorization header, got %v", headers["Authorization"])
+	}
+}
+
+func TestRequestLoggerPropagatesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggedRouter(&buf, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(RequestIDHeader, "upstream-id-1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get(RequestIDHeader); got != "upstream-id-1" {
+		t.Errorf("Expected propagated request ID, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get(RequestIDHeader); got == "bad id\n" || got == "" {
+		t.Errorf("Expected invalid request ID to be replaced, got %q", got)
+	}
+}
+
+func TestRequestLoggerSampling(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggedRouter(&buf, func(cfg *LoggerConfig) {
+		cfg.SampleRate = 0
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	for _, entry := range decodeLines(t, &buf) {
+		if entry["msg"] == "request" {
+			t.Error("Successful request should be sampled out")
+		}
+	}
+
+	buf.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	entries := decodeLines(t, &buf)
+	if len(entries) != 1 || entries[0]["status"] != float64(404) {
+		t.Errorf("Client errors should always be logged, got %v", entries)
+	}
+}
+
+func TestRequestLoggerSampleRateVar(t *testing.T) {
+	var buf bytes.Buffer
+	rate := new(SampleRateVar)
+	router := newLoggedRouter(&buf, func(cfg *LoggerConfig) {
+		cfg.SampleRateVar = rate
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	for _, entry := range decodeLines(t, &buf) {
+		if entry["msg"] == "request" {
+			t.Error("Successful request should be sampled out at rate 0")
+		}
+	}
+
+	buf.Reset()
+	rate.Set(1)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	logged := false
+	for _, entry := range decodeLines(t, &buf) {
+		logged = logged || entry["msg"] == "request"
+	}
+	if !logged {
+		t.Error("Expected the request to be logged after raising the rate to 1")
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	router := newLoggedRouter(&buf, nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rr.Code)
+	}
+
+	var body response.ErrorBody
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Could not decode response: %v", err)
+	}
+	if body.Success || body.Error == "" || body.RequestID != rr.Header().Get(RequestIDHeader) {
+		t.Errorf("Unexpected error envelope: %+v", body)
+	}
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 || entries[0]["msg"] != "panic recovered" {
+		t.Fatalf("Expected panic and request log entries, got %v", entries)
+	}
+	if stack, _ := entries[0]["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Error("Panic log entry should include the stack trace")
+	}
+	if entries[1]["level"] != "ERROR" {
+		t.Errorf("Expected request entry at ERROR level, got %v", entries[1]["level"])
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/response"
+)
+
+// APIKeyHeader carries the client's API key
+const APIKeyHeader = "X-API-Key"
+
+// UserIDKey is the gin context key holding the authenticated user's ID
+const UserIDKey = "user_id"
+
+// Rate limit response headers
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// RateLimit rejects requests over the limit of their route group with 429
+// Too Many Requests and reports the client's quota in RateLimit-* headers.
+//
+// Clients are identified by IP, the user ID set under UserIDKey, or the
+// X-API-Key header, as configured on limiter. To count by user, register
+// RateLimit after the authentication middleware. API keys are not checked
+// here; only count by api_key when invalid keys are rejected before.
+// The IP is gin's ClientIP, which only follows X-Forwarded-For from the
+// proxies passed to the engine's SetTrustedProxies.
+//
+// If the store fails, the request is let through and the error is logged.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := ratelimit.Identity{
+			IP:     c.ClientIP(),
+			UserID: c.GetString(UserIDKey),
+			APIKey: c.GetHeader(APIKeyHeader),
+		}
+		result, ok, err := limiter.Allow(c.Request.Context(), c.Request.URL.Path, id)
+		if err != nil {
+			LoggerFromContext(c.Request.Context()).Warn("rate limit check failed, allowing request", "error", err)
+			c.Next()
+			return
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set(RateLimitLimitHeader, strconv.Itoa(result.Limit.Requests))
+		h.Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		h.Set(RateLimitResetHeader, seconds(result.Reset))
+		h.Set(RateLimitPolicyHeader, strconv.Itoa(result.Limit.Requests)+";w="+seconds(result.Limit.Period))
+
+		if !result.Allowed {
+			h.Set(RetryAfterHeader, seconds(max(result.RetryAfter, time.Second)))
+			response.Error(c, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		c.Next()
+	}
+}
+
+// seconds formats d as whole seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
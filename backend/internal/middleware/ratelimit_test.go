@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/response"
+)
+
+func newRateLimitedRouter(t *testing.T, store ratelimit.Store, keyBy ...ratelimit.KeySource) *gin.Engine {
+	t.Helper()
+	limiter, err := ratelimit.New(store, ratelimit.Config{
+		KeyBy:  keyBy,
+		Groups: map[string]ratelimit.Limit{"/api": {Algorithm: ratelimit.TokenBucket, Requests: 2, Period: time.Minute}},
+	})
+	if err != nil {
+		t.Fatalf("ratelimit.New() failed: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set(UserIDKey, user)
+		}
+	})
+	router.Use(RateLimit(limiter))
+	router.GET("/api/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	router.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return router
+}
+
+func TestRateLimit(t *testing.T) {
+	router := newRateLimitedRouter(t, ratelimit.NewMemoryStore(), ratelimit.KeyByUser, ratelimit.KeyByIP)
+	get := func(path, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/ping", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if w.Header().Get(RateLimitLimitHeader) != "2" || w.Header().Get(RateLimitRemainingHeader) != "1" {
+		t.Errorf("Unexpected headers %v", w.Header())
+	}
+	if got := w.Header().Get(RateLimitPolicyHeader); got != "2;w=60" {
+		t.Errorf("Expected policy 2;w=60, got %q", got)
+	}
+
+	get("/api/ping", "")
+	w = get("/api/ping", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get(RetryAfterHeader); got != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", got)
+	}
+	if got := w.Header().Get(RateLimitRemainingHeader); got != "0" {
+		t.Errorf("Expected no remaining requests, got %q", got)
+	}
+	var body response.ErrorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Success || body.Error == "" {
+		t.Errorf("Expected error envelope, got %s", w.Body.String())
+	}
+
+	// A signed-in user on the same IP has their own quota
+	if w := get("/api/ping", "42"); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for an authenticated user, got %d", w.Code)
+	}
+
+	// Routes outside the configured groups are not limited
+	if w := get("/health", ""); w.Code != http.StatusOK || w.Header().Get(RateLimitLimitHeader) != "" {
+		t.Errorf("Expected /health to be unlimited, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	router := newRateLimitedRouter(t, ratelimit.NewMemoryStore(), ratelimit.KeyByIP)
+	get := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Without trusted proxies a client cannot pick its own key
+	if err := router.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if got := get("192.0.2.1:1234", fmt.Sprintf("203.0.113.%d", i)); got != want {
+			t.Fatalf("request %d: expected %d, got %d", i, want, got)
+		}
+	}
+
+	// Behind a trusted proxy the forwarded address is the client
+	if err := router.SetTrustedProxies([]string{"10.0.0.0/8"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if got := get("10.0.0.1:1234", fmt.Sprintf("198.51.100.%d", i)); got != http.StatusOK {
+			t.Errorf("request %d: expected 200 for a new client behind the proxy, got %d", i, got)
+		}
+	}
+}
+
+func TestRateLimitByAPIKey(t *testing.T) {
+	router := newRateLimitedRouter(t, ratelimit.NewMemoryStore(), ratelimit.KeyByAPIKey, ratelimit.KeyByIP)
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+		req.Header.Set(APIKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("request %d: expected %d, got %d", i, want, w.Code)
+		}
+	}
+
+	// Another key from the same address is counted separately
+	req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	req.Header.Set(APIKeyHeader, "key-2")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for another API key, got %d", w.Code)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, ratelimit.Limit, time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	router := newRateLimitedRouter(t, failingStore{}, ratelimit.KeyByIP)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected requests to be let through when the store fails, got %d", w.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/response"
+)
+
+// Recovery recovers from panics in later handlers, logs the panic and its
+// stack through the request-scoped logger and responds with the standard
+// error envelope
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			logger := LoggerFromContext(c.Request.Context())
+			if brokenPipe(rec) {
+				// The client went away; there is nobody to respond to
+				logger.Warn("connection closed by client", slog.Any("error", rec))
+				c.Abort()
+				return
+			}
+
+			logger.Error("panic recovered",
+				slog.Any("panic", rec),
+				slog.String("stack", string(debug.Stack())),
+			)
+			response.Error(c, http.StatusInternalServerError, "Internal server error")
+		}()
+		c.Next()
+	}
+}
+
+// brokenPipe reports whether the panic was caused by a dropped client connection
+func brokenPipe(rec any) bool {
+	err, ok := rec.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var sysErr *os.SyscallError
+	if !errors.As(opErr, &sysErr) {
+		return false
+	}
+	msg := strings.ToLower(sysErr.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -0,0 +1,490 @@
+// Package migrate applies versioned SQL migrations to Postgres.
+//
+// Applied versions are recorded in a schema-version table together with the
+// checksum of their file, so edits to applied migrations are detected.
+// Every command holds a Postgres advisory lock, so replicas starting at the
+// same time never migrate concurrently, and every migration runs in its own
+// transaction together with its version bookkeeping.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultTable is the schema-version table used unless WithTable is given
+const DefaultTable = "schema_migrations"
+
+var (
+	// ErrIrreversible is returned when rolling back a migration without a down section
+	ErrIrreversible = errors.New("no down section")
+	// ErrChecksumMismatch is returned in strict mode when an applied
+	// migration's file has changed since it was applied
+	ErrChecksumMismatch = errors.New("applied migration has changed")
+)
+
+// Direction of a migration step
+type Direction string
+
+const (
+	Up   Direction = "up"
+	Down Direction = "down"
+)
+
+// Step is a migration to apply in a direction
+type Step struct {
+	Migration Migration
+	Direction Direction
+}
+
+// SQL returns the statements the step executes
+func (s Step) SQL() string {
+	if s.Direction == Down {
+		return s.Migration.Down
+	}
+	return s.Migration.Up
+}
+
+// Applied reports a step that was applied, or would be in dry-run mode
+type Applied struct {
+	Step
+	Duration time.Duration
+}
+
+// Status describes a migration known from the files or the database
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // nil while pending
+	Missing   bool       // applied, but its file no longer exists
+	Changed   bool       // applied, but its file has changed since
+}
+
+// record is a row of the schema-version table
+type record struct {
+	appliedAt time.Time
+	checksum  string
+}
+
+// Migrator runs migrations against a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	table      string
+	lockID     int64
+	logger     *slog.Logger
+	strict     bool
+	dryRun     io.Writer
+}
+
+// Option configures a Migrator
+type Option func(*Migrator)
+
+// WithTable sets the schema-version table
+func WithTable(table string) Option {
+	return func(m *Migrator) { m.table = table }
+}
+
+// WithLogger sets the logger used for lock waits and checksum warnings
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Migrator) { m.logger = logger }
+}
+
+// WithStrict makes commands fail with ErrChecksumMismatch instead of
+// logging a warning when an applied migration's file has changed
+func WithStrict(strict bool) Option {
+	return func(m *Migrator) { m.strict = strict }
+}
+
+// WithDryRun makes commands write the SQL they would execute to w, in
+// order, instead of executing it. Dry runs only read from the database.
+func WithDryRun(w io.Writer) Option {
+	return func(m *Migrator) { m.dryRun = w }
+}
+
+// New creates a migrator for the migrations in fsys
+func New(db *sql.DB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{db: db, migrations: migrations, table: DefaultTable, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(m)
+	}
+	h := fnv.New64a()
+	h.Write([]byte("migrate:" + m.table))
+	m.lockID = int64(h.Sum64())
+	return m, nil
+}
+
+// Migrations returns the known migrations sorted by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest returns the version of the newest migration, or 0 if there are none
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Pending returns the versions of the migrations that are not applied yet,
+// including ones older than the newest applied migration
+func (m *Migrator) Pending(ctx context.Context) ([]int64, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []int64
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Version)
+		}
+	}
+	return pending, nil
+}
+
+// Status lists every migration with its applied time, sorted by version.
+// Applied versions without a file are reported as missing, applied files
+// whose checksum differs from the recorded one as changed.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	records, err := m.records(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if r, ok := records[mig.Version]; ok {
+			s.AppliedAt = &r.appliedAt
+			s.Changed = r.checksum != mig.Checksum
+			delete(records, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for version, r := range records {
+		statuses = append(statuses, Status{Version: version, AppliedAt: &r.appliedAt, Missing: true})
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return cmp.Compare(a.Version, b.Version) })
+	return statuses, nil
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up(ctx context.Context) ([]Applied, error) {
+	return m.run(ctx, func(applied []int64) ([]Step, error) {
+		return planUp(m.migrations, applied), nil
+	})
+}
+
+// Down rolls back the n most recently applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) ([]Applied, error) {
+	return m.run(ctx, func(applied []int64) ([]Step, error) {
+		return planDown(m.migrations, applied, n)
+	})
+}
+
+// Goto migrates up or down to version; version 0 rolls back everything
+func (m *Migrator) Goto(ctx context.Context, version int64) ([]Applied, error) {
+	return m.run(ctx, func(applied []int64) ([]Step, error) {
+		return planGoto(m.migrations, applied, version)
+	})
+}
+
+// Redo rolls back and reapplies the most recently applied migration
+func (m *Migrator) Redo(ctx context.Context) ([]Applied, error) {
+	return m.run(ctx, func(applied []int64) ([]Step, error) {
+		steps, err := planDown(m.migrations, applied, 1)
+		if err != nil || len(steps) == 0 {
+			return nil, err
+		}
+		return append(steps, Step{Migration: steps[0].Migration, Direction: Up}), nil
+	})
+}
+
+// run plans and applies steps on a single connection holding the migration lock
+func (m *Migrator) run(ctx context.Context, plan func(applied []int64) ([]Step, error)) (done []Applied, err error) {
+	if m.dryRun != nil {
+		return m.dryRunPlan(ctx, plan)
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	defer conn.Close()
+
+	if err := m.lock(ctx, conn); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// Unlock even if ctx was cancelled
+		_, unlockErr := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, m.lockID)
+		if unlockErr != nil && err == nil {
+			err = fmt.Errorf("migrate: release lock: %w", unlockErr)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+m.quotedTable()+` (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`); err != nil {
+		return nil, fmt.Errorf("migrate: create %s: %w", m.table, err)
+	}
+
+	records, err := m.records(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verify(records); err != nil {
+		return nil, err
+	}
+
+	steps, err := plan(appliedVersions(records))
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		start := time.Now()
+		if err := m.apply(ctx, conn, step); err != nil {
+			return done, err
+		}
+		done = append(done, Applied{Step: step, Duration: time.Since(start)})
+	}
+	return done, nil
+}
+
+// dryRunPlan writes the SQL that run would execute without changing the database
+func (m *Migrator) dryRunPlan(ctx context.Context, plan func(applied []int64) ([]Step, error)) ([]Applied, error) {
+	records, err := m.records(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verify(records); err != nil {
+		return nil, err
+	}
+	steps, err := plan(appliedVersions(records))
+	if err != nil {
+		return nil, err
+	}
+
+	var planned []Applied
+	for _, step := range steps {
+		if _, err := io.WriteString(m.dryRun, m.script(step)); err != nil {
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+		planned = append(planned, Applied{Step: step})
+	}
+	return planned, nil
+}
+
+// script renders a step as the SQL transaction apply executes
+func (m *Migrator) script(step Step) string {
+	query, args := m.bookkeeping(step)
+	for i, arg := range args {
+		var literal string
+		switch v := arg.(type) {
+		case string:
+			literal = pq.QuoteLiteral(v)
+		default:
+			literal = fmt.Sprint(v)
+		}
+		query = strings.Replace(query, fmt.Sprintf("$%d", i+1), literal, 1)
+	}
+	return fmt.Sprintf("-- %s %s\nBEGIN;\n%s\n%s;\nCOMMIT;\n\n", step.Direction, step.Migration, step.SQL(), query)
+}
+
+// verify warns about, or in strict mode rejects, applied migrations whose
+// file has changed since they were applied
+func (m *Migrator) verify(records map[int64]record) error {
+	var changed []string
+	for _, mig := range m.migrations {
+		if r, ok := records[mig.Version]; ok && r.checksum != mig.Checksum {
+			changed = append(changed, mig.String())
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	if m.strict {
+		return fmt.Errorf("migrate: %w: %s", ErrChecksumMismatch, strings.Join(changed, ", "))
+	}
+	m.logger.Warn("applied migrations have changed since they were applied", "migrations", changed)
+	return nil
+}
+
+// lock acquires the advisory lock, logging when another process holds it
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) error {
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, m.lockID).Scan(&locked); err != nil {
+		return fmt.Errorf("migrate: acquire lock: %w", err)
+	}
+	if locked {
+		return nil
+	}
+	m.logger.Info("waiting for another process to finish migrating", "lock_id", m.lockID)
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, m.lockID); err != nil {
+		return fmt.Errorf("migrate: acquire lock: %w", err)
+	}
+	return nil
+}
+
+// apply runs a step and records it in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, step Step) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("migrate: %s %s: %w", step.Direction, step.Migration, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, step.SQL()); err != nil {
+		return fmt.Errorf("migrate: %s %s: %w", step.Direction, step.Migration, err)
+	}
+	query, args := m.bookkeeping(step)
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("migrate: record %s %s: %w", step.Direction, step.Migration, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("migrate: %s %s: %w", step.Direction, step.Migration, err)
+	}
+	return nil
+}
+
+// bookkeeping returns the statement recording step in the schema-version table
+func (m *Migrator) bookkeeping(step Step) (string, []any) {
+	if step.Direction == Up {
+		return `INSERT INTO ` + m.quotedTable() + ` (version, name, checksum) VALUES ($1, $2, $3)`,
+			[]any{step.Migration.Version, step.Migration.Name, step.Migration.Checksum}
+	}
+	return `DELETE FROM ` + m.quotedTable() + ` WHERE version = $1`, []any{step.Migration.Version}
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// records reads the schema-version table. A missing table means nothing was
+// applied yet.
+func (m *Migrator) records(ctx context.Context, q queryer) (map[int64]record, error) {
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, m.table).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	records := make(map[int64]record)
+	if !exists {
+		return records, nil
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT version, applied_at, checksum FROM `+m.quotedTable())
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read %s: %w", m.table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int64
+		var r record
+		if err := rows.Scan(&version, &r.appliedAt, &r.checksum); err != nil {
+			return nil, fmt.Errorf("migrate: read %s: %w", m.table, err)
+		}
+		records[version] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("migrate: read %s: %w", m.table, err)
+	}
+	return records, nil
+}
+
+func (m *Migrator) quotedTable() string {
+	return pq.QuoteIdentifier(m.table)
+}
+
+// appliedVersions returns the recorded versions in ascending order
+func appliedVersions(records map[int64]record) []int64 {
+	versions := make([]int64, 0, len(records))
+	for version := range records {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// planUp returns every migration that is not applied, in ascending order
+func planUp(migrations []Migration, applied []int64) []Step {
+	var steps []Step
+	for _, mig := range migrations {
+		if !slices.Contains(applied, mig.Version) {
+			steps = append(steps, Step{Migration: mig, Direction: Up})
+		}
+	}
+	return steps
+}
+
+// planDown returns the n most recently applied migrations in descending order
+func planDown(migrations []Migration, applied []int64, n int) ([]Step, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("migrate: cannot roll back %d migrations", n)
+	}
+	var steps []Step
+	for i := len(applied) - 1; i >= 0 && len(steps) < n; i-- {
+		step, err := downStep(migrations, applied[i])
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// planGoto rolls back applied migrations above target, newest first, then
+// applies pending migrations up to and including target
+func planGoto(migrations []Migration, applied []int64, target int64) ([]Step, error) {
+	if target != 0 && !slices.ContainsFunc(migrations, func(m Migration) bool { return m.Version == target }) {
+		return nil, fmt.Errorf("migrate: unknown version %d", target)
+	}
+
+	var steps []Step
+	for i := len(applied) - 1; i >= 0 && applied[i] > target; i-- {
+		step, err := downStep(migrations, applied[i])
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	for _, step := range planUp(migrations, applied) {
+		if step.Migration.Version <= target {
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// downStep returns the rollback of an applied version
+func downStep(migrations []Migration, version int64) (Step, error) {
+	i := slices.IndexFunc(migrations, func(m Migration) bool { return m.Version == version })
+	if i < 0 {
+		return Step{}, fmt.Errorf("migrate: applied version %d has no migration file", version)
+	}
+	if migrations[i].Down == "" {
+		return Step{}, fmt.Errorf("migrate: %s cannot be rolled back: %w", migrations[i], ErrIrreversible)
+	}
+	return Step{Migration: migrations[i], Direction: Down}, nil
+}
@@ -0,0 +1,147 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "one", Up: "up 1", Down: "down 1"},
+	{Version: 2, Name: "two", Up: "up 2", Down: "down 2"},
+	{Version: 3, Name: "three", Up: "up 3", Down: "down 3"},
+	{Version: 4, Name: "four", Up: "up 4"},
+}
+
+// describe renders steps as e.g. "up 1, down 2"
+func describe(steps []Step) string {
+	s := ""
+	for i, step := range steps {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s %d", step.Direction, step.Migration.Version)
+	}
+	return s
+}
+
+func TestLatest(t *testing.T) {
+	if got := (&Migrator{migrations: testMigrations}).Latest(); got != 4 {
+		t.Errorf("Latest() = %d, want 4", got)
+	}
+	if got := (&Migrator{}).Latest(); got != 0 {
+		t.Errorf("Latest() = %d, want 0 without migrations", got)
+	}
+}
+
+func TestPlanUp(t *testing.T) {
+	if got := describe(planUp(testMigrations, nil)); got != "up 1, up 2, up 3, up 4" {
+		t.Errorf("Unexpected plan %q", got)
+	}
+	// Out-of-order pending migrations are applied too
+	if got := describe(planUp(testMigrations, []int64{1, 3})); got != "up 2, up 4" {
+		t.Errorf("Unexpected plan %q", got)
+	}
+}
+
+func TestPlanDown(t *testing.T) {
+	steps, err := planDown(testMigrations, []int64{1, 2, 3}, 2)
+	if err != nil {
+		t.Fatalf("planDown() failed: %v", err)
+	}
+	if got := describe(steps); got != "down 3, down 2" {
+		t.Errorf("Unexpected plan %q", got)
+	}
+
+	steps, err = planDown(testMigrations, []int64{1}, 5)
+	if err != nil || describe(steps) != "down 1" {
+		t.Errorf("Expected to roll back only what is applied, got %q, %v", describe(steps), err)
+	}
+	if _, err := planDown(testMigrations, []int64{1, 2, 3, 4}, 1); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("Expected ErrIrreversible, got %v", err)
+	}
+	if _, err := planDown(testMigrations, []int64{1, 9}, 1); err == nil {
+		t.Error("Expected an error rolling back a version without a file")
+	}
+	if _, err := planDown(testMigrations, []int64{1}, 0); err == nil {
+		t.Error("Expected an error for a zero count")
+	}
+}
+
+func TestPlanGoto(t *testing.T) {
+	tests := []struct {
+		applied []int64
+		target  int64
+		want    string
+	}{
+		{nil, 2, "up 1, up 2"},
+		{[]int64{1, 2, 3}, 1, "down 3, down 2"},
+		{[]int64{1, 2, 3}, 0, "down 3, down 2, down 1"},
+		{[]int64{1, 3}, 3, "up 2"},
+		{[]int64{1, 3}, 2, "down 3, up 2"},
+		{[]int64{1, 2}, 2, ""},
+	}
+	for _, tt := range tests {
+		steps, err := planGoto(testMigrations, tt.applied, tt.target)
+		if err != nil {
+			t.Errorf("planGoto(%v, %d) failed: %v", tt.applied, tt.target, err)
+			continue
+		}
+		if got := describe(steps); got != tt.want {
+			t.Errorf("planGoto(%v, %d) = %q, want %q", tt.applied, tt.target, got, tt.want)
+		}
+	}
+
+	if _, err := planGoto(testMigrations, nil, 7); err == nil {
+		t.Error("Expected an error for an unknown version")
+	}
+}
+
+func TestScript(t *testing.T) {
+	m := &Migrator{table: DefaultTable}
+	mig := Migration{Version: 2, Name: "add_posts", Up: "CREATE TABLE posts (id INT);", Down: "DROP TABLE posts;", Checksum: "abc"}
+
+	want := `-- up 00002_add_posts
+BEGIN;
+CREATE TABLE posts (id INT);
+INSERT INTO "schema_migrations" (version, name, checksum) VALUES (2, 'add_posts', 'abc');
+COMMIT;
+
+`
+	if got := m.script(Step{Migration: mig, Direction: Up}); got != want {
+		t.Errorf("Unexpected up script:\n%s", got)
+	}
+	if got := m.script(Step{Migration: mig, Direction: Down}); !strings.Contains(got, `DELETE FROM "schema_migrations" WHERE version = 2;`) {
+		t.Errorf("Unexpected down script:\n%s", got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	records := map[int64]record{
+		1: {checksum: "sum1"},
+		2: {checksum: "edited"},
+		3: {checksum: "sum3"},
+	}
+	migs := []Migration{
+		{Version: 1, Name: "one", Checksum: "sum1"},
+		{Version: 2, Name: "two", Checksum: "sum2"},
+		{Version: 3, Name: "three", Checksum: "sum3"},
+	}
+
+	lenient := &Migrator{migrations: migs, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if err := lenient.verify(records); err != nil {
+		t.Errorf("Expected only a warning outside strict mode, got %v", err)
+	}
+
+	strict := &Migrator{migrations: migs, strict: true}
+	err := strict.verify(records)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Expected ErrChecksumMismatch, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "00002_two") || strings.Contains(err.Error(), "00003_three") {
+		t.Errorf("Expected only the edited migration to be reported, got %v", err)
+	}
+}
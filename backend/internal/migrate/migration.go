@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Section annotations, compatible with goose
+const (
+	upAnnotation   = "-- +goose Up"
+	downAnnotation = "-- +goose Down"
+)
+
+var (
+	// fileNamePattern matches migration file names such as 00001_create_users.sql
+	fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+	namePattern     = regexp.MustCompile(`^\w+$`)
+)
+
+// Migration is a single schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string // empty if the migration cannot be rolled back
+	// Checksum is the hex-encoded SHA-256 of the file, recorded when the
+	// migration is applied to detect later edits
+	Checksum string
+}
+
+// String returns the migration's file name without extension
+func (m Migration) String() string {
+	return fmt.Sprintf("%05d_%s", m.Version, m.Name)
+}
+
+// Load reads the migrations in the root of fsys, sorted by version.
+// Files not named VERSION_name.sql are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[int64]string)
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migrate: %s: invalid version", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrate: %s and %s have the same version", other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+		up, down, err := parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %s: %w", entry.Name(), err)
+		}
+		sum := sha256.Sum256(data)
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     match[2],
+			Up:       up,
+			Down:     down,
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parse splits a migration file into its up and down SQL. Lines before the
+// up annotation and goose statement annotations are dropped.
+func parse(source string) (up, down string, err error) {
+	var upLines, downLines []string
+	var section *[]string
+	foundUp := false
+
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch trimmed := strings.TrimSpace(line); {
+		case strings.HasPrefix(trimmed, upAnnotation):
+			if foundUp {
+				return "", "", errors.New("duplicate up section")
+			}
+			foundUp, section = true, &upLines
+		case strings.HasPrefix(trimmed, downAnnotation):
+			if !foundUp {
+				return "", "", errors.New("down section before up section")
+			}
+			if section == &downLines {
+				return "", "", errors.New("duplicate down section")
+			}
+			section = &downLines
+		case strings.HasPrefix(trimmed, "-- +goose"):
+			// StatementBegin/End: each section is executed as a whole
+		case section != nil:
+			*section = append(*section, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	up = strings.TrimSpace(strings.Join(upLines, "\n"))
+	if up == "" {
+		return "", "", errors.New("missing or empty up section")
+	}
+	return up, strings.TrimSpace(strings.Join(downLines, "\n")), nil
+}
+
+// Create writes an empty migration named name into dir, versioned with the
+// next sequence number after the files already there (e.g.
+// 00002_add_posts.sql), and returns its path
+func Create(dir, name string) (string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("migrate: invalid migration name %q", name)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("migrate: %w", err)
+	}
+	var last int64
+	for _, entry := range entries {
+		if match := fileNamePattern.FindStringSubmatch(entry.Name()); match != nil && !entry.IsDir() {
+			version, _ := strconv.ParseInt(match[1], 10, 64)
+			last = max(last, version)
+		}
+	}
+
+	path := filepath.Join(dir, Migration{Version: last + 1, Name: name}.String()+".sql")
+	content := upAnnotation + "\n\n" + downAnnotation + "\n"
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("migrate: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", fmt.Errorf("migrate: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("migrate: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"00002_add_posts.sql": {Data: []byte("-- +goose Up\nCREATE TABLE posts (id INT);\n-- +goose Down\nDROP TABLE posts;\n")},
+		"00001_init.sql": {Data: []byte(`-- header comment
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (id INT);
+-- +goose StatementEnd
+`)},
+		"README.md": {Data: []byte("not a migration")},
+	}
+
+	migs, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(migs) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migs))
+	}
+	if migs[0].Version != 1 || migs[0].Name != "init" || migs[0].Up != "CREATE TABLE users (id INT);" || migs[0].Down != "" {
+		t.Errorf("Unexpected first migration %+v", migs[0])
+	}
+	if migs[1].Version != 2 || migs[1].Down != "DROP TABLE posts;" {
+		t.Errorf("Unexpected second migration %+v", migs[1])
+	}
+
+	// Any edit, even outside the SQL sections, changes the checksum
+	before := migs[0].Checksum
+	fsys["00001_init.sql"] = &fstest.MapFile{Data: append([]byte("-- edited\n"), fsys["00001_init.sql"].Data...)}
+	migs, err = Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(before) != 64 || migs[0].Checksum == before {
+		t.Errorf("Expected a new SHA-256 checksum after an edit, got %q and %q", before, migs[0].Checksum)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"duplicate version": {
+			"1_a.sql":  {Data: []byte("-- +goose Up\nSELECT 1;")},
+			"01_b.sql": {Data: []byte("-- +goose Up\nSELECT 1;")},
+		},
+		"missing up":     {"1_a.sql": {Data: []byte("SELECT 1;")}},
+		"empty up":       {"1_a.sql": {Data: []byte("-- +goose Up\n-- +goose Down\nSELECT 1;")}},
+		"down before up": {"1_a.sql": {Data: []byte("-- +goose Down\nSELECT 1;\n-- +goose Up\nSELECT 1;")}},
+		"duplicate up":   {"1_a.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n-- +goose Up\nSELECT 2;")}},
+		"zero version":   {"0_a.sql": {Data: []byte("-- +goose Up\nSELECT 1;")}},
+		"duplicate down": {"1_a.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n-- +goose Down\n-- +goose Down")}},
+	}
+	for name, fsys := range tests {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	migs, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(migs) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+	for _, m := range migs {
+		if m.Down == "" {
+			t.Errorf("%s has no down section", m)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := Create(dir, "Create Users")
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if filepath.Base(path) != "00001_create_users.sql" {
+		t.Errorf("Unexpected file name %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), upAnnotation) || !strings.Contains(string(data), downAnnotation) {
+		t.Errorf("Expected up and down annotations, got %q", data)
+	}
+
+	// Versions continue after the highest existing one
+	if err := os.WriteFile(filepath.Join(dir, "00007_add_tags.sql"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := Create(dir, "add posts"); err != nil || filepath.Base(path) != "00008_add_posts.sql" {
+		t.Errorf("Create() = %s, %v, want 00008_add_posts.sql", path, err)
+	}
+	if _, err := Create(dir, "bad/name"); err == nil {
+		t.Error("Expected an error for an invalid name")
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops expired counters
+const sweepInterval = time.Minute
+
+// MemoryStore keeps rate limit counters in process memory. Limits are not
+// shared between replicas; use RedisStore for that.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	expires time.Time
+
+	// token bucket
+	tokens float64
+	last   time.Time
+
+	// sliding window: request times, oldest first
+	requests []time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+// Allow implements Store
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryEntry{tokens: float64(limit.Requests), last: now}
+		s.entries[key] = entry
+	}
+	// Once the period has passed, the counter is back to its initial state
+	entry.expires = now.Add(limit.Period)
+
+	switch limit.Algorithm {
+	case TokenBucket:
+		return entry.takeToken(limit, now), nil
+	case SlidingWindow:
+		return entry.addRequest(limit, now), nil
+	default:
+		return Result{}, fmt.Errorf("unknown algorithm %q", limit.Algorithm)
+	}
+}
+
+func (e *memoryEntry) takeToken(limit Limit, now time.Time) Result {
+	if elapsed := now.Sub(e.last); elapsed > 0 {
+		rate := float64(limit.Requests) / float64(limit.Period)
+		e.tokens = math.Min(float64(limit.Requests), e.tokens+float64(elapsed)*rate)
+		e.last = now
+	}
+	allowed := e.tokens >= 1
+	if allowed {
+		e.tokens--
+	}
+	return tokenBucketResult(limit, e.tokens, allowed)
+}
+
+func (e *memoryEntry) addRequest(limit Limit, now time.Time) Result {
+	cutoff := now.Add(-limit.Period)
+	drop := 0
+	for drop < len(e.requests) && !e.requests[drop].After(cutoff) {
+		drop++
+	}
+	e.requests = e.requests[drop:]
+
+	allowed := len(e.requests) < limit.Requests
+	if allowed {
+		e.requests = append(e.requests, now)
+	}
+	if len(e.requests) == 0 {
+		return slidingWindowResult(limit, 0, now, now, now, allowed)
+	}
+	return slidingWindowResult(limit, len(e.requests), e.requests[0], e.requests[len(e.requests)-1], now, allowed)
+}
+
+// sweep drops expired entries at most once per sweepInterval
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Len returns the number of tracked counters
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
@@ -0,0 +1,307 @@
+// Package ratelimit limits how often a client may call a route group.
+//
+// A Limiter maps request paths to per-group limits and asks a Store whether
+// the next request of a client fits into its quota. Two algorithms are
+// supported: a token bucket, which allows short bursts up to the limit and
+// refills continuously, and a sliding window, which allows at most Requests
+// requests in any interval of length Period. MemoryStore keeps state in the
+// process; RedisStore shares it between replicas.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Algorithm selects how requests are counted
+type Algorithm string
+
+// Supported algorithms
+const (
+	TokenBucket   Algorithm = "token_bucket"
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// Limit allows Requests requests per Period
+type Limit struct {
+	Algorithm Algorithm
+	Requests  int
+	Period    time.Duration
+}
+
+// ParseLimit parses a limit of the form "100/1m" using the given algorithm
+func ParseLimit(value string, algorithm Algorithm) (Limit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q: expected REQUESTS/PERIOD, e.g. 100/1m", value)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil {
+		return Limit{}, fmt.Errorf("limit %q: invalid request count", value)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return Limit{}, fmt.Errorf("limit %q: invalid period", value)
+	}
+	l := Limit{Algorithm: algorithm, Requests: n, Period: d}
+	return l, l.Validate()
+}
+
+// ParseGroups parses per-route-group limits of the form "/api/v1=100/1m"
+// into a map keyed by path prefix
+func ParseGroups(rules []string, algorithm Algorithm) (map[string]Limit, error) {
+	groups := make(map[string]Limit, len(rules))
+	for _, rule := range rules {
+		prefix, value, ok := strings.Cut(rule, "=")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("rule %q: expected PREFIX=REQUESTS/PERIOD, e.g. /api/v1=100/1m", rule)
+		}
+		if _, dup := groups[prefix]; dup {
+			return nil, fmt.Errorf("rule %q: duplicate prefix %s", rule, prefix)
+		}
+		limit, err := ParseLimit(value, algorithm)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule, err)
+		}
+		groups[prefix] = limit
+	}
+	return groups, nil
+}
+
+// Validate checks that the limit can be enforced
+func (l Limit) Validate() error {
+	switch l.Algorithm {
+	case TokenBucket, SlidingWindow:
+	default:
+		return fmt.Errorf("unknown algorithm %q", l.Algorithm)
+	}
+	if l.Requests < 1 {
+		return fmt.Errorf("request count must be positive, got %d", l.Requests)
+	}
+	if l.Period < time.Millisecond {
+		return fmt.Errorf("period must be at least 1ms, got %s", l.Period)
+	}
+	return nil
+}
+
+// String formats the limit as accepted by ParseLimit
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Period.String()
+}
+
+// Result is the outcome of a single rate limit check
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// Reset is the time until the quota is fully restored
+	Reset time.Duration
+	// RetryAfter is the time until the next request would be allowed; zero when Allowed
+	RetryAfter time.Duration
+}
+
+// Store records requests and decides whether the next one is allowed.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// KeySource names the client attribute requests are counted by
+type KeySource string
+
+// Supported key sources
+const (
+	KeyByIP     KeySource = "ip"
+	KeyByUser   KeySource = "user"
+	KeyByAPIKey KeySource = "api_key"
+)
+
+// ParseKeySources converts key source names such as "user" and "ip"
+func ParseKeySources(names []string) ([]KeySource, error) {
+	sources := make([]KeySource, len(names))
+	for i, name := range names {
+		sources[i] = KeySource(name)
+	}
+	return sources, validateKeySources(sources)
+}
+
+func validateKeySources(sources []KeySource) error {
+	if len(sources) == 0 {
+		return errors.New("at least one key source is required")
+	}
+	for _, source := range sources {
+		switch source {
+		case KeyByIP, KeyByUser, KeyByAPIKey:
+		default:
+			return fmt.Errorf("unknown key source %q", source)
+		}
+	}
+	return nil
+}
+
+// Identity describes the client making a request. Empty fields are unknown.
+type Identity struct {
+	IP     string
+	UserID string
+	APIKey string
+}
+
+// key returns the first known attribute in the order of sources
+func (id Identity) key(sources []KeySource) (string, bool) {
+	for _, source := range sources {
+		switch source {
+		case KeyByIP:
+			if id.IP != "" {
+				return "ip:" + id.IP, true
+			}
+		case KeyByUser:
+			if id.UserID != "" {
+				return "user:" + id.UserID, true
+			}
+		case KeyByAPIKey:
+			if id.APIKey != "" {
+				// Keep API keys out of the store
+				sum := sha256.Sum256([]byte(id.APIKey))
+				return "key:" + hex.EncodeToString(sum[:16]), true
+			}
+		}
+	}
+	return "", false
+}
+
+// Config holds the per-route-group limits keyed by path prefix and the
+// order in which client attributes are tried as the rate limit key
+type Config struct {
+	KeyBy  []KeySource
+	Groups map[string]Limit
+}
+
+// Limiter applies the configured limits to requests. The limits can be
+// replaced at runtime with Update; counters of unchanged groups are kept.
+type Limiter struct {
+	store Store
+	now   func() time.Time
+	state atomic.Pointer[state]
+}
+
+type state struct {
+	keyBy  []KeySource
+	groups []group // longest prefix first
+}
+
+type group struct {
+	prefix string
+	limit  Limit
+}
+
+// New creates a Limiter that keeps its counters in store
+func New(store Store, cfg Config) (*Limiter, error) {
+	l := &Limiter{store: store, now: time.Now}
+	if err := l.Update(cfg); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Update validates cfg and atomically replaces the active limits
+func (l *Limiter) Update(cfg Config) error {
+	if err := validateKeySources(cfg.KeyBy); err != nil {
+		return fmt.Errorf("ratelimit: %w", err)
+	}
+
+	s := &state{keyBy: cfg.KeyBy}
+	for prefix, limit := range cfg.Groups {
+		if err := limit.Validate(); err != nil {
+			return fmt.Errorf("ratelimit: limit for %s: %w", prefix, err)
+		}
+		s.groups = append(s.groups, group{prefix: prefix, limit: limit})
+	}
+	sort.Slice(s.groups, func(i, j int) bool {
+		return len(s.groups[i].prefix) > len(s.groups[j].prefix)
+	})
+	l.state.Store(s)
+	return nil
+}
+
+// Allow counts a request to path made by id. It reports false when no
+// limit applies to the request, either because no route group matches or
+// because none of the configured client attributes is known.
+func (l *Limiter) Allow(ctx context.Context, path string, id Identity) (Result, bool, error) {
+	s := l.state.Load()
+	g, ok := s.groupFor(path)
+	if !ok {
+		return Result{}, false, nil
+	}
+	client, ok := id.key(s.keyBy)
+	if !ok {
+		return Result{}, false, nil
+	}
+
+	// The limit is part of the key so that changed limits start afresh
+	key := string(g.limit.Algorithm) + ":" + g.prefix + ":" + g.limit.String() + ":" + client
+	result, err := l.store.Allow(ctx, key, g.limit, l.now())
+	if err != nil {
+		return Result{}, false, fmt.Errorf("ratelimit: %w", err)
+	}
+	return result, true, nil
+}
+
+func (s *state) groupFor(path string) (group, bool) {
+	for _, g := range s.groups {
+		if hasPathPrefix(path, g.prefix) {
+			return g, true
+		}
+	}
+	return group{}, false
+}
+
+// hasPathPrefix reports whether path is prefix or lies below it
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// tokenBucketResult builds the result of a token bucket check from the
+// number of tokens left after the request
+func tokenBucketResult(limit Limit, tokens float64, allowed bool) Result {
+	perToken := limit.Period / time.Duration(limit.Requests)
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return result
+}
+
+// slidingWindowResult builds the result of a sliding window check from the
+// number of requests in the window and the times of the oldest and newest
+func slidingWindowResult(limit Limit, count int, oldest, newest, now time.Time, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: max(limit.Requests-count, 0),
+	}
+	if count > 0 {
+		result.Reset = max(newest.Add(limit.Period).Sub(now), 0)
+	}
+	if !allowed {
+		result.RetryAfter = max(oldest.Add(limit.Period).Sub(now), 0)
+	}
+	return result
+}
@@ -0,0 +1,256 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// stores returns a fresh instance of every Store implementation
+func stores(t *testing.T) map[string]Store {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"redis":  NewRedisStore(client, ""),
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	limit := Limit{Algorithm: TokenBucket, Requests: 3, Period: 3 * time.Second}
+	start := time.UnixMilli(1_700_000_000_000)
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := range 3 {
+				result, err := store.Allow(ctx, "client", limit, start)
+				if err != nil {
+					t.Fatalf("Allow() failed: %v", err)
+				}
+				if !result.Allowed || result.Remaining != 2-i {
+					t.Fatalf("request %d: got allowed=%v remaining=%d", i, result.Allowed, result.Remaining)
+				}
+			}
+
+			result, err := store.Allow(ctx, "client", limit, start)
+			if err != nil {
+				t.Fatalf("Allow() failed: %v", err)
+			}
+			if result.Allowed {
+				t.Fatal("Expected the burst to be exhausted")
+			}
+			if result.RetryAfter != time.Second {
+				t.Errorf("Expected RetryAfter 1s, got %s", result.RetryAfter)
+			}
+			if result.Reset != 3*time.Second {
+				t.Errorf("Expected Reset 3s, got %s", result.Reset)
+			}
+
+			// One token is refilled per second
+			result, _ = store.Allow(ctx, "client", limit, start.Add(time.Second))
+			if !result.Allowed || result.Remaining != 0 {
+				t.Errorf("Expected a refilled token, got allowed=%v remaining=%d", result.Allowed, result.Remaining)
+			}
+
+			// Other clients have their own bucket
+			result, _ = store.Allow(ctx, "other", limit, start)
+			if !result.Allowed || result.Remaining != 2 {
+				t.Errorf("Expected a full bucket for another client, got remaining=%d", result.Remaining)
+			}
+		})
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limit := Limit{Algorithm: SlidingWindow, Requests: 2, Period: 10 * time.Second}
+	start := time.UnixMilli(1_700_000_000_000)
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			allow := func(at time.Duration) Result {
+				t.Helper()
+				result, err := store.Allow(ctx, "client", limit, start.Add(at))
+				if err != nil {
+					t.Fatalf("Allow() failed: %v", err)
+				}
+				return result
+			}
+
+			if r := allow(0); !r.Allowed || r.Remaining != 1 {
+				t.Fatalf("first request: got allowed=%v remaining=%d", r.Allowed, r.Remaining)
+			}
+			if r := allow(4 * time.Second); !r.Allowed || r.Remaining != 0 {
+				t.Fatalf("second request: got allowed=%v remaining=%d", r.Allowed, r.Remaining)
+			}
+
+			r := allow(6 * time.Second)
+			if r.Allowed {
+				t.Fatal("Expected the window to be full")
+			}
+			if r.RetryAfter != 4*time.Second {
+				t.Errorf("Expected RetryAfter 4s, got %s", r.RetryAfter)
+			}
+			if r.Reset != 8*time.Second {
+				t.Errorf("Expected Reset 8s, got %s", r.Reset)
+			}
+
+			// The first request leaves the window after 10s
+			if r := allow(10 * time.Second); !r.Allowed {
+				t.Error("Expected a request once the oldest left the window")
+			}
+			if r := allow(11 * time.Second); r.Allowed {
+				t.Error("Expected the window to be full again")
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("100/1m", SlidingWindow)
+	if err != nil {
+		t.Fatalf("ParseLimit() failed: %v", err)
+	}
+	if limit.Requests != 100 || limit.Period != time.Minute || limit.Algorithm != SlidingWindow {
+		t.Errorf("Unexpected limit %+v", limit)
+	}
+
+	for _, value := range []string{"100", "x/1m", "100/soon", "0/1m", "10/0s"} {
+		if _, err := ParseLimit(value, TokenBucket); err == nil {
+			t.Errorf("ParseLimit(%q): expected error", value)
+		}
+	}
+	if _, err := ParseLimit("10/1m", "leaky_bucket"); err == nil {
+		t.Error("Expected error for unknown algorithm")
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	groups, err := ParseGroups([]string{"/api/v1=100/1m", " /api/v1/auth = 5/1m"}, TokenBucket)
+	if err != nil {
+		t.Fatalf("ParseGroups() failed: %v", err)
+	}
+	if len(groups) != 2 || groups["/api/v1/auth"].Requests != 5 {
+		t.Errorf("Unexpected groups %+v", groups)
+	}
+
+	for _, rules := range [][]string{{"api=1/1s"}, {"/api"}, {"/api=1/1s", "/api=2/1s"}, {"/api=many"}} {
+		if _, err := ParseGroups(rules, TokenBucket); err == nil {
+			t.Errorf("ParseGroups(%q): expected error", rules)
+		}
+	}
+
+	if _, err := ParseKeySources([]string{"user", "cookie"}); err == nil {
+		t.Error("Expected error for unknown key source")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	store := NewMemoryStore()
+	limiter, err := New(store, Config{
+		KeyBy: []KeySource{KeyByUser, KeyByIP},
+		Groups: map[string]Limit{
+			"/api/v1":      {Algorithm: TokenBucket, Requests: 5, Period: time.Minute},
+			"/api/v1/auth": {Algorithm: TokenBucket, Requests: 1, Period: time.Minute},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+	anon := Identity{IP: "10.0.0.1"}
+
+	// The longest matching prefix wins
+	if r, ok, _ := limiter.Allow(ctx, "/api/v1/auth/login", anon); !ok || r.Limit.Requests != 1 {
+		t.Fatalf("Expected the auth limit, got ok=%v limit=%v", ok, r.Limit)
+	}
+	if r, _, _ := limiter.Allow(ctx, "/api/v1/auth/login", anon); r.Allowed {
+		t.Error("Expected the auth limit to be exhausted")
+	}
+	if r, _, _ := limiter.Allow(ctx, "/api/v1/ping", anon); !r.Allowed || r.Limit.Requests != 5 {
+		t.Errorf("Expected the API limit to be separate, got %+v", r)
+	}
+
+	// Prefixes match whole path segments only
+	if _, ok, _ := limiter.Allow(ctx, "/api/v1x", anon); ok {
+		t.Error("Expected no limit for /api/v1x")
+	}
+	if _, ok, _ := limiter.Allow(ctx, "/health", anon); ok {
+		t.Error("Expected no limit outside the configured groups")
+	}
+
+	// Authenticated users are counted separately from their IP
+	if r, _, _ := limiter.Allow(ctx, "/api/v1/auth/login", Identity{IP: "10.0.0.1", UserID: "42"}); !r.Allowed {
+		t.Error("Expected a user to have their own quota")
+	}
+
+	// Without a usable key source no limit applies
+	if _, ok, _ := limiter.Allow(ctx, "/api/v1/ping", Identity{APIKey: "k"}); ok {
+		t.Error("Expected no limit without a known client attribute")
+	}
+
+	// Updated limits take effect immediately
+	err = limiter.Update(Config{
+		KeyBy:  []KeySource{KeyByAPIKey, KeyByIP},
+		Groups: map[string]Limit{"/api": {Algorithm: SlidingWindow, Requests: 1, Period: time.Minute}},
+	})
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if r, ok, _ := limiter.Allow(ctx, "/api/v1/ping", Identity{APIKey: "k"}); !ok || !r.Allowed || r.Limit.Algorithm != SlidingWindow {
+		t.Errorf("Expected the updated limit, got ok=%v %+v", ok, r)
+	}
+}
+
+func TestLimiterInvalidConfig(t *testing.T) {
+	valid := Limit{Algorithm: TokenBucket, Requests: 1, Period: time.Second}
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no key source", Config{Groups: map[string]Limit{"/": valid}}},
+		{"unknown key source", Config{KeyBy: []KeySource{"cookie"}}},
+		{"invalid limit", Config{KeyBy: []KeySource{KeyByIP}, Groups: map[string]Limit{"/": {Algorithm: TokenBucket}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(NewMemoryStore(), tt.cfg); err == nil {
+				t.Error("Expected error, got none")
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Algorithm: TokenBucket, Requests: 1, Period: time.Second}
+	start := time.Now()
+
+	store.Allow(context.Background(), "a", limit, start)
+	store.Allow(context.Background(), "b", limit, start.Add(2*sweepInterval))
+	if n := store.Len(); n != 1 {
+		t.Errorf("Expected expired counters to be dropped, %d left", n)
+	}
+}
+
+func TestRedisStoreUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	defer client.Close()
+	server.Close()
+
+	store := NewRedisStore(client, "")
+	limit := Limit{Algorithm: TokenBucket, Requests: 1, Period: time.Second}
+	if _, err := store.Allow(context.Background(), "client", limit, time.Now()); err == nil {
+		t.Error("Expected error when Redis is down")
+	}
+}
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisPrefix namespaces the keys written by RedisStore
+const DefaultRedisPrefix = "ratelimit:"
+
+// RedisStore keeps rate limit counters in Redis so that all replicas share
+// the same limits. Each check is a single Lua script, so concurrent
+// requests from different replicas cannot overdraw a quota. Timestamps
+// come from the calling replica; keep replica clocks in sync.
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisStore creates a store using client, which may be a *redis.Client,
+// *redis.ClusterClient or *redis.Ring. An empty prefix uses DefaultRedisPrefix.
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = DefaultRedisPrefix
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// tokenBucketScript refills the bucket for the time elapsed since the last
+// request, takes a token if one is available and returns {allowed, tokens}.
+// Tokens are returned as a string because Redis truncates Lua numbers.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) * rate)
+	ts = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+// slidingWindowScript drops requests older than the window, records the
+// request if the window has room and returns {allowed, count, oldest, newest}.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+return {allowed, count, oldest[2] or tostring(now), newest[2] or tostring(now)}
+`)
+
+// Allow implements Store
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	key = s.prefix + key
+	nowMs := now.UnixMilli()
+	periodMs := limit.Period.Milliseconds()
+
+	switch limit.Algorithm {
+	case TokenBucket:
+		rate := float64(limit.Requests) / float64(periodMs) // tokens per millisecond
+		reply, err := tokenBucketScript.Run(ctx, s.client, []string{key},
+			limit.Requests, strconv.FormatFloat(rate, 'g', -1, 64), nowMs, periodMs).Slice()
+		if err != nil {
+			return Result{}, err
+		}
+		if len(reply) != 2 {
+			return Result{}, fmt.Errorf("unexpected token bucket reply %v", reply)
+		}
+		tokens, err := strconv.ParseFloat(fmt.Sprint(reply[1]), 64)
+		if err != nil {
+			return Result{}, fmt.Errorf("unexpected token count %v", reply[1])
+		}
+		return tokenBucketResult(limit, tokens, reply[0] == int64(1)), nil
+
+	case SlidingWindow:
+		// Members must be unique, or requests within the same millisecond collide
+		member := strconv.FormatInt(nowMs, 10) + "-" + rand.Text()
+		reply, err := slidingWindowScript.Run(ctx, s.client, []string{key},
+			limit.Requests, periodMs, nowMs, member).Slice()
+		if err != nil {
+			return Result{}, err
+		}
+		if len(reply) != 4 {
+			return Result{}, fmt.Errorf("unexpected sliding window reply %v", reply)
+		}
+		count, _ := reply[1].(int64)
+		// Scores are formatted as floats by Redis
+		oldest, err1 := strconv.ParseFloat(fmt.Sprint(reply[2]), 64)
+		newest, err2 := strconv.ParseFloat(fmt.Sprint(reply[3]), 64)
+		if err1 != nil || err2 != nil {
+			return Result{}, fmt.Errorf("unexpected sliding window reply %v", reply)
+		}
+		return slidingWindowResult(limit, int(count), time.UnixMilli(int64(oldest)), time.UnixMilli(int64(newest)), now, reply[0] == int64(1)), nil
+
+	default:
+		return Result{}, fmt.Errorf("unknown algorithm %q", limit.Algorithm)
+	}
+}
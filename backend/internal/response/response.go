@@ -0,0 +1,25 @@
+// Package response defines the JSON envelopes returned by the API.
+package response
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey is the gin context key holding the current request ID
+const RequestIDKey = "request_id"
+
+// ErrorBody is the standard error envelope
+type ErrorBody struct {
+	Success   bool   `json:"success"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Error aborts the request and writes the standard error envelope
+func Error(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, ErrorBody{
+		Success:   false,
+		Error:     message,
+		RequestID: c.GetString(RequestIDKey),
+	})
+}
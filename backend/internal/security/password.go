@@ -0,0 +1,46 @@
+// Package security hashes and verifies user passwords.
+package security
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultCost is the bcrypt cost used by NewPasswordService
+const DefaultCost = 10
+
+// ErrEmptyPassword is returned when hashing an empty password
+var ErrEmptyPassword = errors.New("password must not be empty")
+
+// PasswordService handles password operations
+type PasswordService struct {
+	cost int
+}
+
+// NewPasswordService creates a password service hashing with DefaultCost
+func NewPasswordService() *PasswordService {
+	return &PasswordService{cost: DefaultCost}
+}
+
+// HashPassword hashes a non-empty password using bcrypt
+func (p *PasswordService) HashPassword(password string) (string, error) {
+	if password == "" {
+		return "", ErrEmptyPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), p.cost)
+	if err != nil {
+		return "", fmt.Errorf("hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// VerifyPassword reports whether password matches the bcrypt hash.
+// Empty passwords and hashes never match.
+func (p *PasswordService) VerifyPassword(password, hash string) bool {
+	if password == "" || hash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,38 @@
+package security
+
+import "testing"
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	service := NewPasswordService()
+
+	if _, err := service.HashPassword(""); err == nil {
+		t.Error("Expected an error hashing an empty password")
+	}
+
+	password := "testpassword123"
+	hash, err := service.HashPassword(password)
+	if err != nil {
+		t.Fatalf("HashPassword() failed: %v", err)
+	}
+	if hash == "" || hash == password {
+		t.Fatalf("Expected a hash distinct from the password, got %q", hash)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{"correct password", password, hash, true},
+		{"wrong password", "wrongpassword", hash, false},
+		{"empty password", "", hash, false},
+		{"empty hash", password, "", false},
+		{"malformed hash", password, "not-a-hash", false},
+	}
+	for _, tt := range tests {
+		if got := service.VerifyPassword(tt.password, tt.hash); got != tt.want {
+			t.Errorf("%s: VerifyPassword() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
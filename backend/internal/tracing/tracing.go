@@ -0,0 +1,122 @@
+// Package tracing sets up OpenTelemetry tracing with W3C trace context
+// propagation and provides instrumentation for Gin and outbound HTTP calls.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName identifies this service in exported spans
+const ServiceName = "sum25-go-flutter-course-backend"
+
+// ExporterFactory creates a span exporter from the configuration
+type ExporterFactory func(ctx context.Context, cfg *config.Config) (sdktrace.SpanExporter, error)
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = map[string]ExporterFactory{
+		"stdout": newStdoutExporter,
+		"otlp":   newOTLPExporter,
+	}
+)
+
+// RegisterExporter makes an exporter available under name for the tracing_exporter setting
+func RegisterExporter(name string, factory ExporterFactory) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	exporters[name] = factory
+}
+
+// Exporters returns the names of the registered exporters
+func Exporters() []string {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Setup installs the global W3C trace context propagator and, when tracing
+// is enabled, a tracer provider exporting through the configured exporter.
+// The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, cfg *config.Config) (shutdown func(context.Context) error, err error) {
+	// Propagate traceparent/tracestate even when spans are not exported,
+	// so traces from upstream callers stay connected
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.EnableTracing {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exportersMu.RLock()
+	factory, ok := exporters[cfg.TracingExporter]
+	exportersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("tracing: unknown exporter %q (available: %v)", cfg.TracingExporter, Exporters())
+	}
+
+	exporter, err := factory(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: create %s exporter: %w", cfg.TracingExporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+		semconv.DeploymentEnvironment(cfg.Env),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: build resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Transport wraps base so outbound requests create client spans and carry
+// the traceparent header. A nil base uses http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
+
+// stdoutWriter is where the stdout exporter writes; replaced in tests
+var stdoutWriter io.Writer = os.Stdout
+
+func newStdoutExporter(ctx context.Context, cfg *config.Config) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(stdoutWriter))
+}
+
+func newOTLPExporter(ctx context.Context, cfg *config.Config) (sdktrace.SpanExporter, error) {
+	return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+}
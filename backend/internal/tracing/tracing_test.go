@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentID    = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testParentID + "-01"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return recorder
+}
+
+func TestMiddlewareContinuesTrace(t *testing.T) {
+	recorder := setupRecorder(t)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set("traceparent", testTraceparent)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /users/:id" {
+		t.Errorf("Expected span name 'GET /users/:id', got %q", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != testTraceID {
+		t.Errorf("Expected trace ID %s, got %s", testTraceID, span.SpanContext().TraceID())
+	}
+	if span.Parent().SpanID().String() != testParentID {
+		t.Errorf("Expected parent span %s, got %s", testParentID, span.Parent().SpanID())
+	}
+	if span.Status().Code.String() != "Error" {
+		t.Errorf("Expected error status for 500 response, got %v", span.Status().Code)
+	}
+}
+
+func TestTransportPropagatesTrace(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	var received string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	if !strings.Contains(received, parent.SpanContext().TraceID().String()) {
+		t.Errorf("Expected outbound traceparent with trace %s, got %q", parent.SpanContext().TraceID(), received)
+	}
+	if len(recorder.Ended()) != 2 {
+		t.Errorf("Expected client and parent spans, got %d", len(recorder.Ended()))
+	}
+}
+
+func TestSetupStdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+	stdoutWriter = &buf
+
+	cfg := &config.Config{Env: "test", EnableTracing: true, TracingExporter: "stdout", TracingSampleRatio: 1}
+	shutdown, err := Setup(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Setup() failed: %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "exported-span")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `"Name":"exported-span"`) {
+		t.Errorf("Expected span JSON on stdout exporter, got %s", buf.String())
+	}
+}
+
+func TestSetupUnknownExporter(t *testing.T) {
+	cfg := &config.Config{EnableTracing: true, TracingExporter: "zipkin"}
+	if _, err := Setup(context.Background(), cfg); err == nil {
+		t.Error("Expected error for unknown exporter")
+	}
+}
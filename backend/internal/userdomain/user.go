@@ -0,0 +1,135 @@
+// Package userdomain defines the user entity and its validation rules.
+package userdomain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Name length limits, counted in characters after trimming
+const (
+	MinNameLength = 2
+	MaxNameLength = 50
+)
+
+// Password length limits in bytes. bcrypt rejects passwords longer than
+// MaxPasswordLength, so longer ones are a validation error rather than a
+// hashing failure.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+var emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+
+// User represents a user entity in the domain
+type User struct {
+	ID           int       `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	Password     string    `json:"-"` // Never serialize password
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// NewUser creates a new user after validating the email, name and password.
+// The email is normalized to lower case and the name is trimmed.
+func NewUser(email, name, password string) (*User, error) {
+	now := time.Now()
+	user := &User{
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		Name:      strings.TrimSpace(name),
+		Password:  password,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Validate checks the email, name and password of the user. The password is
+// only checked while the plain text is known, i.e. before it is hashed.
+func (u *User) Validate() error {
+	errs := []error{ValidateEmail(u.Email), ValidateName(u.Name)}
+	if u.Password != "" || u.PasswordHash == "" {
+		errs = append(errs, ValidatePassword(u.Password))
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateEmail checks if email format is valid
+func ValidateEmail(email string) error {
+	if email == "" {
+		return errors.New("email must not be empty")
+	}
+	if !emailPattern.MatchString(email) {
+		return errors.New("email format is invalid")
+	}
+	return nil
+}
+
+// ValidateName checks that the trimmed name is 2-50 characters long
+func ValidateName(name string) error {
+	n := utf8.RuneCountInString(strings.TrimSpace(name))
+	if n == 0 {
+		return errors.New("name must not be empty")
+	}
+	if n < MinNameLength || n > MaxNameLength {
+		return errors.New("name must be between 2 and 50 characters")
+	}
+	return nil
+}
+
+// ValidatePassword checks that password has 8 to 72 bytes and contains an
+// uppercase letter, a lowercase letter and a number
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return errors.New("password must be at least 8 characters")
+	}
+	if len(password) > MaxPasswordLength {
+		return errors.New("password must be at most 72 bytes")
+	}
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasUpper || !hasLower || !hasDigit {
+		return errors.New("password must contain an uppercase letter, a lowercase letter and a number")
+	}
+	return nil
+}
+
+// UpdateName updates the user's name with validation
+func (u *User) UpdateName(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	u.Name = strings.TrimSpace(name)
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateEmail updates the user's email with validation
+func (u *User) UpdateEmail(email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if err := ValidateEmail(email); err != nil {
+		return err
+	}
+	u.Email = email
+	u.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,121 @@
+package userdomain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewUser(t *testing.T) {
+	user, err := NewUser("  Test@Example.com ", "  John Doe ", "Password123")
+	if err != nil {
+		t.Fatalf("NewUser() failed: %v", err)
+	}
+	if user.Email != "test@example.com" || user.Name != "John Doe" {
+		t.Errorf("Expected normalized email and name, got %q and %q", user.Email, user.Name)
+	}
+	if user.CreatedAt.IsZero() || user.UpdatedAt.IsZero() {
+		t.Error("Expected timestamps to be set")
+	}
+
+	for _, tt := range []struct{ email, name, password string }{
+		{"invalid-email", "John Doe", "Password123"},
+		{"test@example.com", "J", "Password123"},
+		{"test@example.com", "John Doe", "short"},
+	} {
+		if _, err := NewUser(tt.email, tt.name, tt.password); err == nil {
+			t.Errorf("NewUser(%q, %q, %q): expected an error", tt.email, tt.name, tt.password)
+		}
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		email   string
+		wantErr bool
+	}{
+		{"test@example.com", false},
+		{"user@mail.example.com", false},
+		{"user123@example.com", false},
+		{"", true},
+		{"testexample.com", true},
+		{"test@", true},
+		{"@example.com", true},
+		{"test @example.com", true},
+		{"test@@example.com", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateEmail(tt.email); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateEmail(%q) error = %v, wantErr %v", tt.email, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"John Doe", false},
+		{"Jo", false},
+		{strings.Repeat("a", 50), false},
+		{"Жора", false},
+		{"  John Doe  ", false},
+		{"", true},
+		{"J", true},
+		{strings.Repeat("a", 51), true},
+		{"   ", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"Password123", false},
+		{"MyP@ssw0rd!", false},
+		{"Abcdef12", false},
+		{"", true},
+		{"Pass1", true},
+		{"password123", true},
+		{"PASSWORD123", true},
+		{"Password", true},
+		{"12345678", true},
+		{"Password1" + strings.Repeat("x", 63), false},
+		{"Password1" + strings.Repeat("x", 64), true},
+	}
+	for _, tt := range tests {
+		if err := ValidatePassword(tt.password); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePassword(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateHashedUser(t *testing.T) {
+	user := &User{Email: "test@example.com", Name: "John Doe", PasswordHash: "$2a$10$hash"}
+	if err := user.Validate(); err != nil {
+		t.Errorf("Expected a user with only a password hash to be valid, got %v", err)
+	}
+	user.PasswordHash = ""
+	if err := user.Validate(); err == nil {
+		t.Error("Expected a user without any password to be invalid")
+	}
+}
+
+func TestUpdateEmail(t *testing.T) {
+	user := &User{Email: "test@example.com", Name: "John Doe"}
+	if err := user.UpdateEmail("invalid-email"); err == nil {
+		t.Error("Expected an error for an invalid email")
+	}
+	if err := user.UpdateEmail("  NEW@EXAMPLE.COM  "); err != nil {
+		t.Fatalf("UpdateEmail() failed: %v", err)
+	}
+	if user.Email != "new@example.com" {
+		t.Errorf("Expected normalized email, got %q", user.Email)
+	}
+}
@@ -0,0 +1,13 @@
+// Package migrations embeds the Postgres schema migrations.
+//
+// Each file is named VERSION_name.sql and holds an "-- +goose Up" section
+// and an optional "-- +goose Down" section. Create new files with
+// `go run ./cmd/migrate create NAME`.
+package migrations
+
+import "embed"
+
+// FS holds the migration files
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,381 @@
+// Package cors implements an origin-aware CORS policy engine for net/http
+// handlers. It is router-agnostic: the Gin backend adapts it in
+// internal/middleware and gorilla/mux servers such as lab03 use Engine.Handler.
+// It is a module of its own, so they do not depend on the whole backend.
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Policy describes which cross-origin requests are allowed
+type Policy struct {
+	// AllowedOrigins lists exact origins ("https://app.example.com"),
+	// wildcard subdomains ("https://*.example.com") or "*" for any origin.
+	// "*" cannot be combined with AllowCredentials.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge lets browsers cache preflight results; zero omits the header
+	MaxAge time.Duration
+	// PreflightStatus is the status of accepted preflights; zero means 204
+	// No Content. Some older clients expect 200.
+	PreflightStatus int
+}
+
+// DefaultPolicy returns a policy with common methods and headers and no allowed origins
+func DefaultPolicy() Policy {
+	return Policy{
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "X-CSRF-Token"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// Config holds the default policy and per-route-group policies keyed by path
+// prefix; a prefix matches whole path segments only
+type Config struct {
+	Default Policy
+	Groups  map[string]Policy
+}
+
+// Engine applies CORS policies to requests. Policies can be replaced at
+// runtime with Update; requests always see a consistent set.
+type Engine struct {
+	logger *slog.Logger
+	state  atomic.Pointer[state]
+}
+
+type state struct {
+	def    *compiledPolicy
+	groups []group // longest prefix first
+}
+
+type group struct {
+	prefix string
+	policy *compiledPolicy
+}
+
+type compiledPolicy struct {
+	anyOrigin      bool
+	exact          map[string]bool
+	wildcards      []wildcard
+	methods        map[string]bool
+	allowedMethods string
+	anyHeader      bool
+	headers        map[string]bool
+	allowedHeaders string
+	exposedHeaders string
+	credentials    bool
+	maxAge         string
+	status         int
+}
+
+// wildcard matches subdomains of suffix for the given scheme, e.g. https + ".example.com"
+type wildcard struct {
+	scheme string
+	suffix string
+}
+
+// New creates an Engine. Rejected origins are logged to logger; a nil logger uses slog.Default.
+func New(cfg Config, logger *slog.Logger) (*Engine, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	e := &Engine{logger: logger}
+	if err := e.Update(cfg); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Update validates cfg and atomically replaces the active policies
+func (e *Engine) Update(cfg Config) error {
+	def, err := compile(cfg.Default)
+	if err != nil {
+		return fmt.Errorf("cors: default policy: %w", err)
+	}
+	s := &state{def: def}
+	for prefix, p := range cfg.Groups {
+		compiled, err := compile(p)
+		if err != nil {
+			return fmt.Errorf("cors: policy for %s: %w", prefix, err)
+		}
+		s.groups = append(s.groups, group{prefix: prefix, policy: compiled})
+	}
+	sort.Slice(s.groups, func(i, j int) bool {
+		return len(s.groups[i].prefix) > len(s.groups[j].prefix)
+	})
+	e.state.Store(s)
+	return nil
+}
+
+// Handler wraps next with CORS handling; it has the signature of mux.MiddlewareFunc
+func (e *Engine) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e.Apply(w, r) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Apply sets the CORS response headers for r. It reports true when the
+// request was a preflight that has been fully answered and must not reach
+// the application handler.
+func (e *Engine) Apply(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if origin == "" {
+		return false
+	}
+
+	policy := e.policyFor(r.URL.Path)
+	h := w.Header()
+	if !policy.anyOrigin || policy.credentials {
+		h.Add("Vary", "Origin")
+	}
+
+	if !policy.allowsOrigin(origin) {
+		e.logger.Warn("cors: origin rejected",
+			slog.String("origin", origin),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+		if preflight {
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+
+	if preflight {
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+
+		method := r.Header.Get("Access-Control-Request-Method")
+		if !policy.methods[strings.ToUpper(method)] {
+			e.logger.Warn("cors: preflight method rejected", slog.String("origin", origin), slog.String("method", method))
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		requested := parseHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+		if !policy.allowsHeaders(requested) {
+			e.logger.Warn("cors: preflight headers rejected", slog.String("origin", origin), slog.Any("headers", requested))
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+
+		policy.setOrigin(h, origin)
+		h.Set("Access-Control-Allow-Methods", policy.allowedMethods)
+		if policy.anyHeader && len(requested) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+		} else if policy.allowedHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", policy.allowedHeaders)
+		}
+		if policy.maxAge != "" {
+			h.Set("Access-Control-Max-Age", policy.maxAge)
+		}
+		w.WriteHeader(policy.status)
+		return true
+	}
+
+	policy.setOrigin(h, origin)
+	if policy.exposedHeaders != "" {
+		h.Set("Access-Control-Expose-Headers", policy.exposedHeaders)
+	}
+	return false
+}
+
+func (e *Engine) policyFor(path string) *compiledPolicy {
+	s := e.state.Load()
+	for _, g := range s.groups {
+		if hasPathPrefix(path, g.prefix) {
+			return g.policy
+		}
+	}
+	return s.def
+}
+
+// hasPathPrefix reports whether path is prefix or lies below it, so
+// "/public" covers "/public/status" but not "/publications"
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// ValidateOrigin checks that origin is usable in Policy.AllowedOrigins
+func ValidateOrigin(origin string) error {
+	origin = strings.ToLower(strings.TrimSpace(origin))
+	switch {
+	case origin == "*":
+		return nil
+	case strings.Contains(origin, "*"):
+		_, err := parseWildcard(origin)
+		return err
+	default:
+		_, err := parseOrigin(origin)
+		return err
+	}
+}
+
+func compile(p Policy) (*compiledPolicy, error) {
+	c := &compiledPolicy{
+		exact:       make(map[string]bool),
+		methods:     make(map[string]bool),
+		headers:     make(map[string]bool),
+		credentials: p.AllowCredentials,
+	}
+
+	for _, origin := range p.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			if p.AllowCredentials {
+				return nil, errors.New(`origin "*" cannot be combined with credentials`)
+			}
+			c.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			w, err := parseWildcard(origin)
+			if err != nil {
+				return nil, err
+			}
+			c.wildcards = append(c.wildcards, w)
+		default:
+			if _, err := parseOrigin(origin); err != nil {
+				return nil, err
+			}
+			c.exact[origin] = true
+		}
+	}
+
+	methods := make([]string, 0, len(p.AllowedMethods)+1)
+	for _, m := range p.AllowedMethods {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if !c.methods[m] {
+			c.methods[m] = true
+			methods = append(methods, m)
+		}
+	}
+	// OPTIONS is always allowed so preflights for it succeed
+	c.methods[http.MethodOptions] = true
+	c.allowedMethods = strings.Join(methods, ", ")
+
+	headers := make([]string, 0, len(p.AllowedHeaders))
+	for _, h := range p.AllowedHeaders {
+		h = strings.TrimSpace(h)
+		if h == "*" {
+			if p.AllowCredentials {
+				return nil, errors.New(`header "*" cannot be combined with credentials`)
+			}
+			c.anyHeader = true
+			continue
+		}
+		c.headers[strings.ToLower(h)] = true
+		headers = append(headers, http.CanonicalHeaderKey(h))
+	}
+	c.allowedHeaders = strings.Join(headers, ", ")
+	c.exposedHeaders = strings.Join(p.ExposedHeaders, ", ")
+
+	if p.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(p.MaxAge.Seconds()))
+	}
+	c.status = http.StatusNoContent
+	if p.PreflightStatus != 0 {
+		if p.PreflightStatus < 200 || p.PreflightStatus > 299 {
+			return nil, fmt.Errorf("preflight status %d is not a 2xx status", p.PreflightStatus)
+		}
+		c.status = p.PreflightStatus
+	}
+	return c, nil
+}
+
+func (c *compiledPolicy) allowsOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if c.exact[origin] {
+		return true
+	}
+	if len(c.wildcards) == 0 {
+		return false
+	}
+	u, err := parseOrigin(origin)
+	if err != nil {
+		return false
+	}
+	for _, w := range c.wildcards {
+		if u.Scheme == w.scheme && strings.HasSuffix(u.Host, w.suffix) && len(u.Host) > len(w.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiledPolicy) allowsHeaders(requested []string) bool {
+	if c.anyHeader {
+		return true
+	}
+	for _, h := range requested {
+		if !c.headers[strings.ToLower(h)] {
+			return false
+		}
+	}
+	return true
+}
+
+// setOrigin echoes the matched origin; a literal "*" is only sent for
+// credential-less policies that allow any origin
+func (c *compiledPolicy) setOrigin(h http.Header, origin string) {
+	if c.anyOrigin && !c.credentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// parseOrigin checks that origin is a scheme://host[:port] value without a path
+func parseOrigin(origin string) (*url.URL, error) {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		return nil, fmt.Errorf("invalid origin %q", origin)
+	}
+	return u, nil
+}
+
+// parseWildcard accepts "scheme://*.domain[:port]"
+func parseWildcard(pattern string) (wildcard, error) {
+	scheme, rest, ok := strings.Cut(pattern, "://")
+	if !ok || scheme == "" || !strings.HasPrefix(rest, "*.") || strings.Count(rest, "*") != 1 || strings.ContainsAny(rest, "/?#") {
+		return wildcard{}, fmt.Errorf("invalid wildcard origin %q", pattern)
+	}
+	return wildcard{scheme: scheme, suffix: rest[1:]}, nil
+}
+
+func parseHeaderList(value string) []string {
+	var headers []string
+	for _, h := range strings.Split(value, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			headers = append(headers, h)
+		}
+	}
+	return headers
+}
@@ -0,0 +1,187 @@
+package cors
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestEngine(t *testing.T, logs *bytes.Buffer) *Engine {
+	t.Helper()
+	api := DefaultPolicy()
+	api.AllowedOrigins = []string{"http://localhost:3000", "https://*.example.com"}
+	api.AllowCredentials = true
+	api.MaxAge = 5 * time.Minute
+
+	public := DefaultPolicy()
+	public.AllowedOrigins = []string{"*"}
+
+	e, err := New(Config{Default: api, Groups: map[string]Policy{"/public": public}},
+		slog.New(slog.NewTextHandler(logs, nil)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return e
+}
+
+func serve(e *Engine, r *http.Request) (*httptest.ResponseRecorder, bool) {
+	called := false
+	rr := httptest.NewRecorder()
+	e.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})).ServeHTTP(rr, r)
+	return rr, called
+}
+
+func TestOriginMatching(t *testing.T) {
+	var logs bytes.Buffer
+	e := newTestEngine(t, &logs)
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"http://localhost:3000", true},
+		{"HTTP://LOCALHOST:3000", true},
+		{"https://app.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://example.com", false},
+		{"http://app.example.com", false},
+		{"https://evilexample.com", false},
+		{"http://localhost:3001", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+			req.Header.Set("Origin", tt.origin)
+			rr, called := serve(e, req)
+
+			if !called {
+				t.Error("Actual requests should always reach the handler")
+			}
+			got := rr.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && got != tt.origin {
+				t.Errorf("Expected origin to be echoed, got %q", got)
+			}
+			if !tt.allowed && got != "" {
+				t.Errorf("Expected no Allow-Origin header, got %q", got)
+			}
+			if tt.allowed && rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("Expected Allow-Credentials for credentialed policy")
+			}
+			if rr.Header().Get("Vary") != "Origin" {
+				t.Errorf("Expected Vary: Origin, got %q", rr.Header().Get("Vary"))
+			}
+		})
+	}
+
+	if !strings.Contains(logs.String(), "origin rejected") {
+		t.Error("Expected rejected origins to be logged")
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	e := newTestEngine(t, &bytes.Buffer{})
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/messages", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type, authorization")
+	rr, called := serve(e, req)
+
+	if called {
+		t.Error("Preflight should not reach the handler")
+	}
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Max-Age"); got != "300" {
+		t.Errorf("Expected Max-Age 300, got %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(got, "POST") {
+		t.Errorf("Expected POST in Allow-Methods, got %q", got)
+	}
+
+	req.Header.Set("Access-Control-Request-Headers", "X-Unknown")
+	if rr, _ := serve(e, req); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for disallowed header, got %d", rr.Code)
+	}
+
+	req.Header.Set("Origin", "https://attacker.test")
+	req.Header.Del("Access-Control-Request-Headers")
+	if rr, _ := serve(e, req); rr.Code != http.StatusForbidden || rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected rejected preflight, got %d", rr.Code)
+	}
+
+	p := DefaultPolicy()
+	p.AllowedOrigins = []string{"http://localhost:3000"}
+	p.PreflightStatus = http.StatusOK
+	if err := e.Update(Config{Default: p}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	req.Header.Set("Origin", "http://localhost:3000")
+	if rr, _ := serve(e, req); rr.Code != http.StatusOK {
+		t.Errorf("Expected the configured status 200, got %d", rr.Code)
+	}
+}
+
+func TestGroupPolicy(t *testing.T) {
+	e := newTestEngine(t, &bytes.Buffer{})
+
+	req := httptest.NewRequest(http.MethodGet, "/public/status", nil)
+	req.Header.Set("Origin", "https://anywhere.test")
+	rr, _ := serve(e, req)
+
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected '*' for public group, got %q", got)
+	}
+	if rr.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("Public group must not allow credentials")
+	}
+
+	// The prefix only matches whole path segments
+	req = httptest.NewRequest(http.MethodGet, "/publications", nil)
+	req.Header.Set("Origin", "https://anywhere.test")
+	rr, _ = serve(e, req)
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected the default policy for /publications, got %q", got)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	e := newTestEngine(t, &bytes.Buffer{})
+
+	p := DefaultPolicy()
+	p.AllowedOrigins = []string{"https://new.example.org"}
+	if err := e.Update(Config{Default: p}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+	req.Header.Set("Origin", "https://new.example.org")
+	if rr, _ := serve(e, req); rr.Header().Get("Access-Control-Allow-Origin") != "https://new.example.org" {
+		t.Error("Expected updated policy to allow new origin")
+	}
+}
+
+func TestInvalidPolicies(t *testing.T) {
+	tests := map[string]Policy{
+		"wildcard with credentials": {AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		"origin with path":          {AllowedOrigins: []string{"http://localhost:3000/app"}},
+		"bad wildcard":              {AllowedOrigins: []string{"https://app.*.com"}},
+		"any header with creds":     {AllowedOrigins: []string{"http://a.test"}, AllowedHeaders: []string{"*"}, AllowCredentials: true},
+		"non-2xx preflight status":  {AllowedOrigins: []string{"http://a.test"}, PreflightStatus: http.StatusNotFound},
+	}
+	for name, p := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(Config{Default: p}, nil); err == nil {
+				t.Error("Expected error, got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/timur-harin/sum25-go-flutter-course/backend/pkg/cors
+
+go 1.24
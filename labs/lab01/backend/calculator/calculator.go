@@ -35,3 +35,25 @@ func FloatToString(f float64, precision int) string {
 	format := fmt.Sprintf("%%.%df", precision)
 	return fmt.Sprintf(format, f)
 }
+
+// StringToFloatLocale parses s with the separators and notations of the
+// locale tag, as Locale.ParseFloat does, so StringToFloatLocale("1 234,5",
+// "ru") is 1234.5
+func StringToFloatLocale(s, tag string) (float64, error) {
+	l, err := LookupLocale(tag)
+	if err != nil {
+		return 0, err
+	}
+	return l.ParseFloat(s)
+}
+
+// FloatToStringLocale writes f with precision digits after the decimal point
+// and the separators and grouping of the locale tag; use Locale.FormatFloat
+// for the other notations
+func FloatToStringLocale(f float64, precision int, tag string) (string, error) {
+	l, err := LookupLocale(tag)
+	if err != nil {
+		return "", err
+	}
+	return l.FormatFloat(f, FormatOptions{Precision: precision}), nil
+}
@@ -1,6 +1,7 @@
 package calculator
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -158,3 +159,42 @@ func TestFloatToString(t *testing.T) {
 		})
 	}
 }
+
+func TestStringToFloatLocale(t *testing.T) {
+	tests := []struct {
+		input    string
+		tag      string
+		expected float64
+	}{
+		{"1 234,5", "ru", 1234.5},
+		{"1\u00a0234,5", "ru-RU", 1234.5},
+		{"1.234,5", "de", 1234.5},
+		{"1,234.5", "en", 1234.5},
+	}
+	for _, tt := range tests {
+		got, err := StringToFloatLocale(tt.input, tt.tag)
+		if err != nil {
+			t.Errorf("StringToFloatLocale(%q, %q) failed: %v", tt.input, tt.tag, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("StringToFloatLocale(%q, %q) = %v, want %v", tt.input, tt.tag, got, tt.expected)
+		}
+	}
+	if _, err := StringToFloatLocale("1,5", "xx"); !errors.Is(err, ErrUnknownLocale) {
+		t.Errorf("Expected ErrUnknownLocale, got %v", err)
+	}
+}
+
+func TestFloatToStringLocale(t *testing.T) {
+	got, err := FloatToStringLocale(1234.5, 1, "ru")
+	if err != nil {
+		t.Fatalf("FloatToStringLocale() failed: %v", err)
+	}
+	if got != "1\u00a0234,5" {
+		t.Errorf("FloatToStringLocale(1234.5, 1, ru) = %q, want %q", got, "1\u00a0234,5")
+	}
+	if _, err := FloatToStringLocale(1, 0, "xx"); !errors.Is(err, ErrUnknownLocale) {
+		t.Errorf("Expected ErrUnknownLocale, got %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// MaxDecimalScale bounds the scale of parsed decimals in both directions,
+// so 1e100000 and 1e-100000 parse but 1e100001 does not. Larger exponents
+// would take gigabytes to print or to line up for addition.
+const MaxDecimalScale = 100_000
+
+// RoundingMode selects how a decimal is rounded to fewer digits
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest digit and ties to the even one,
+	// like FloatToString; also known as banker's rounding
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest digit and ties away from zero
+	RoundHalfUp
+	// RoundTruncate drops the extra digits, rounding toward zero
+	RoundTruncate
+)
+
+// DecimalContext configures inexact decimal operations
+type DecimalContext struct {
+	// Precision is the number of significant digits of inexact results
+	Precision int
+	Rounding  RoundingMode
+}
+
+// DefaultDecimalContext keeps 34 significant digits, as IEEE 754 decimal128
+var DefaultDecimalContext = DecimalContext{Precision: 34, Rounding: RoundHalfEven}
+
+// Decimal is an arbitrary-precision decimal number, unscaled × 10^-scale.
+// The zero value is 0. Decimals are immutable; operations return new values.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+var bigTen = big.NewInt(10)
+
+// NewDecimal returns unscaled × 10^-scale, e.g. NewDecimal(1999, 2) is 19.99
+func NewDecimal(unscaled int64, scale int) Decimal {
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// DecimalFromFloat converts f using its shortest decimal representation,
+// so DecimalFromFloat(0.1) is exactly 0.1
+func DecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("%w: %v", ErrInvalidDecimal, f)
+	}
+	return StringToDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// StringToDecimal parses a decimal such as "-12.50" or "1e400" exactly. It
+// accepts the inputs StringToFloat does, except Inf, NaN and hexadecimal,
+// with a scale of at most MaxDecimalScale either way.
+func StringToDecimal(s string) (Decimal, error) {
+	invalid := fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e > math.MaxInt32 || e < math.MinInt32 {
+			return Decimal{}, invalid
+		}
+		mantissa, exponent = s[:i], e
+	}
+
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '+' || mantissa[0] == '-') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	whole, frac, _ := strings.Cut(mantissa, ".")
+	digits := whole + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, invalid
+	}
+
+	scale := len(frac) - exponent
+	if abs(scale) > MaxDecimalScale {
+		return Decimal{}, fmt.Errorf("%w: %q is out of range", ErrInvalidDecimal, s)
+	}
+	unscaled, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return Decimal{}, invalid
+	}
+	return Decimal{unscaled: unscaled, scale: scale}, nil
+}
+
+// String returns the exact value in plain notation, e.g. "0.0001" or "1200"
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	sign := ""
+	if d.int().Sign() < 0 {
+		sign = "-"
+	}
+	switch {
+	case d.scale <= 0:
+		if digits != "0" {
+			digits += strings.Repeat("0", -d.scale)
+		}
+		return sign + digits
+	case len(digits) <= d.scale:
+		return sign + "0." + strings.Repeat("0", d.scale-len(digits)) + digits
+	default:
+		point := len(digits) - d.scale
+		return sign + digits[:point] + "." + digits[point:]
+	}
+}
+
+// DecimalToString formats d with precision digits after the decimal point,
+// rounding half to even like FloatToString. A negative precision returns
+// the exact value.
+func DecimalToString(d Decimal, precision int) string {
+	if precision < 0 {
+		return d.String()
+	}
+	rounded := d.Round(precision, RoundHalfEven)
+	return rescale(rounded, precision).String()
+}
+
+// rescale returns d with the given scale, which must not lose digits
+func rescale(d Decimal, scale int) Decimal {
+	if d.scale >= scale {
+		return d
+	}
+	factor := new(big.Int).Exp(bigTen, big.NewInt(int64(scale-d.scale)), nil)
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), factor), scale: scale}
+}
+
+// Round rounds d to places digits after the decimal point; negative places
+// round to tens, hundreds and so on
+func (d Decimal) Round(places int, mode RoundingMode) Decimal {
+	if d.scale <= places {
+		return d
+	}
+	divisor := new(big.Int).Exp(bigTen, big.NewInt(int64(d.scale-places)), nil)
+	q, r := new(big.Int).QuoRem(d.int(), divisor, new(big.Int))
+	return Decimal{unscaled: roundQuotient(q, r, divisor, mode), scale: places}
+}
+
+// roundQuotient adjusts the truncated quotient q of n / divisor, where
+// r is the remainder and divisor is positive
+func roundQuotient(q, r, divisor *big.Int, mode RoundingMode) *big.Int {
+	if r.Sign() == 0 || mode == RoundTruncate {
+		return q
+	}
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	cmp := twice.Cmp(divisor)
+	if cmp > 0 || (cmp == 0 && (mode == RoundHalfUp || q.Bit(0) == 1)) {
+		// Away from zero, in the direction of the remainder
+		return q.Add(q, big.NewInt(int64(r.Sign())))
+	}
+	return q
+}
+
+// Cmp returns -1, 0 or +1 as d is less than, equal to or greater than o
+func (d Decimal) Cmp(o Decimal) int {
+	scale := max(d.scale, o.scale)
+	return rescale(d, scale).int().Cmp(rescale(o, scale).int())
+}
+
+// Sign returns -1, 0 or +1
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// Rat returns d as an exact rational number
+func (d Decimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(d.int())
+	factor := new(big.Int).Exp(bigTen, big.NewInt(int64(abs(d.scale))), nil)
+	if d.scale > 0 {
+		return r.Quo(r, new(big.Rat).SetInt(factor))
+	}
+	return r.Mul(r, new(big.Rat).SetInt(factor))
+}
+
+// Float64 returns the nearest float64 to d
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+func AddDecimal(a, b Decimal) Decimal {
+	scale := max(a.scale, b.scale)
+	sum := new(big.Int).Add(rescale(a, scale).int(), rescale(b, scale).int())
+	return Decimal{unscaled: sum, scale: scale}
+}
+
+func SubtractDecimal(a, b Decimal) Decimal {
+	scale := max(a.scale, b.scale)
+	diff := new(big.Int).Sub(rescale(a, scale).int(), rescale(b, scale).int())
+	return Decimal{unscaled: diff, scale: scale}
+}
+
+func MultiplyDecimal(a, b Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(a.int(), b.int()), scale: a.scale + b.scale}
+}
+
+// DivideDecimal divides a by b. A quotient with a finite decimal expansion
+// that fits in ctx.Precision digits is exact; otherwise it is rounded to
+// ctx.Precision significant digits with ctx.Rounding.
+func DivideDecimal(a, b Decimal, ctx DecimalContext) (Decimal, error) {
+	q, err := DivideRational(a, b)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return DecimalFromRat(q, ctx), nil
+}
+
+// DivideRational divides a by b exactly
+func DivideRational(a, b Decimal) (*big.Rat, error) {
+	if b.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	return new(big.Rat).Quo(a.Rat(), b.Rat()), nil
+}
+
+// DecimalFromRat converts r to a decimal of at most ctx.Precision
+// significant digits, exactly when possible, without trailing zeros
+func DecimalFromRat(r *big.Rat, ctx DecimalContext) Decimal {
+	precision := ctx.Precision
+	if precision <= 0 {
+		precision = DefaultDecimalContext.Precision
+	}
+	num, den := r.Num(), r.Denom()
+	if num.Sign() == 0 {
+		return Decimal{}
+	}
+
+	// 10^(magnitude-2) <= |r| < 10^magnitude, so the truncated quotient at
+	// this scale has precision or precision+1 digits
+	magnitude := len(new(big.Int).Abs(num).String()) - len(den.String()) + 1
+	scale := precision - magnitude + 1
+	n, d := new(big.Int).Set(num), new(big.Int).Set(den)
+	if scale >= 0 {
+		n.Mul(n, new(big.Int).Exp(bigTen, big.NewInt(int64(scale)), nil))
+	} else {
+		d.Mul(d, new(big.Int).Exp(bigTen, big.NewInt(int64(-scale)), nil))
+	}
+	q, rem := new(big.Int).QuoRem(n, d, new(big.Int))
+	if len(new(big.Int).Abs(q).String()) > precision {
+		// Drop the last digit: the remainder of n / (10·d) is that
+		// digit·d + rem
+		last := new(big.Int)
+		q.QuoRem(q, bigTen, last)
+		rem.Add(rem, last.Mul(last, d))
+		d.Mul(d, bigTen)
+		scale--
+	}
+	return Decimal{unscaled: roundQuotient(q, rem, d, ctx.Rounding), scale: scale}.normalize()
+}
+
+// normalize removes trailing zeros
+func (d Decimal) normalize() Decimal {
+	u := new(big.Int).Set(d.int())
+	scale := d.scale
+	if u.Sign() == 0 {
+		return Decimal{}
+	}
+	r := new(big.Int)
+	for {
+		q, m := new(big.Int).QuoRem(u, bigTen, r)
+		if m.Sign() != 0 {
+			break
+		}
+		u, scale = q, scale-1
+	}
+	return Decimal{unscaled: u, scale: scale}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
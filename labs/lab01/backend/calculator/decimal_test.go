@@ -0,0 +1,207 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func mustDecimal(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := StringToDecimal(s)
+	if err != nil {
+		t.Fatalf("StringToDecimal(%q) failed: %v", s, err)
+	}
+	return d
+}
+
+func TestStringToDecimal(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"42", "42", false},
+		{"-123.45", "-123.45", false},
+		{"+0.10", "0.10", false},
+		{".5", "0.5", false},
+		{"5.", "5", false},
+		{"1.5e3", "1500", false},
+		{"25E-4", "0.0025", false},
+		{"1e400", "1" + strings.Repeat("0", 400), false},
+		{"123456789012345678901234567890.000000000000000000001", "123456789012345678901234567890.000000000000000000001", false},
+		{"", "", true},
+		{"abc", "", true},
+		{"1.2.3", "", true},
+		{"1e", "", true},
+		{"-", "", true},
+		{"Inf", "", true},
+		{"1e100000", "1" + strings.Repeat("0", 100000), false},
+		{"1e100001", "", true},
+		{"1e-100001", "", true},
+		{"0.1e-100000", "", true},
+		{"1e2000000000", "", true},
+		{"1e-9223372036854775808", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := StringToDecimal(tt.input)
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidDecimal) {
+					t.Errorf("Expected ErrInvalidDecimal, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("StringToDecimal(%q) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a, b := mustDecimal(t, "0.1"), mustDecimal(t, "0.2")
+	if got := AddDecimal(a, b); got.Cmp(mustDecimal(t, "0.3")) != 0 {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", got)
+	}
+	if got := SubtractDecimal(mustDecimal(t, "10"), mustDecimal(t, "0.01")).String(); got != "9.99" {
+		t.Errorf("10 - 0.01 = %s, want 9.99", got)
+	}
+	if got := MultiplyDecimal(mustDecimal(t, "19.99"), mustDecimal(t, "3")).String(); got != "59.97" {
+		t.Errorf("19.99 * 3 = %s, want 59.97", got)
+	}
+	var zero Decimal
+	if got := AddDecimal(zero, NewDecimal(1999, 2)).String(); got != "19.99" {
+		t.Errorf("0 + 19.99 = %s, want 19.99", got)
+	}
+}
+
+func TestDivideDecimal(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		ctx      DecimalContext
+		expected string
+	}{
+		{"10", "4", DefaultDecimalContext, "2.5"},
+		{"1", "3", DecimalContext{Precision: 5}, "0.33333"},
+		{"2", "3", DecimalContext{Precision: 5, Rounding: RoundHalfEven}, "0.66667"},
+		{"2", "3", DecimalContext{Precision: 5, Rounding: RoundTruncate}, "0.66666"},
+		{"-2", "3", DecimalContext{Precision: 5, Rounding: RoundHalfUp}, "-0.66667"},
+		{"1", "8", DecimalContext{Precision: 2, Rounding: RoundHalfEven}, "0.12"},
+		{"1", "8", DecimalContext{Precision: 2, Rounding: RoundHalfUp}, "0.13"},
+		{"3", "8", DecimalContext{Precision: 2, Rounding: RoundHalfEven}, "0.38"},
+		{"100", "7", DecimalContext{Precision: 4}, "14.29"},
+		{"99999", "1", DecimalContext{Precision: 3}, "100000"},
+		{"1", "7e20", DecimalContext{Precision: 3}, "0.00000000000000000000143"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			got, err := DivideDecimal(mustDecimal(t, tt.a), mustDecimal(t, tt.b), tt.ctx)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("DivideDecimal(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := DivideDecimal(mustDecimal(t, "1"), Decimal{}, DefaultDecimalContext); err != ErrDivisionByZero {
+		t.Errorf("Expected ErrDivisionByZero, got %v", err)
+	}
+}
+
+func TestDivideRational(t *testing.T) {
+	third, err := DivideRational(mustDecimal(t, "1"), mustDecimal(t, "3"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sum := new(big.Rat).Add(third, new(big.Rat).Add(third, third))
+	if sum.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("1/3 + 1/3 + 1/3 = %s, want 1", sum)
+	}
+	if _, err := DivideRational(mustDecimal(t, "1"), mustDecimal(t, "0.00")); err != ErrDivisionByZero {
+		t.Errorf("Expected ErrDivisionByZero, got %v", err)
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		input    string
+		places   int
+		mode     RoundingMode
+		expected string
+	}{
+		{"2.345", 2, RoundHalfEven, "2.34"},
+		{"2.355", 2, RoundHalfEven, "2.36"},
+		{"2.345", 2, RoundHalfUp, "2.35"},
+		{"-2.345", 2, RoundHalfUp, "-2.35"},
+		{"2.349", 2, RoundTruncate, "2.34"},
+		{"-2.349", 2, RoundTruncate, "-2.34"},
+		{"1250", -2, RoundHalfEven, "1200"},
+		{"1.5", 3, RoundHalfEven, "1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := mustDecimal(t, tt.input).Round(tt.places, tt.mode).String(); got != tt.expected {
+				t.Errorf("Round(%s, %d) = %s, want %s", tt.input, tt.places, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDecimalToString checks that DecimalToString keeps FloatToString's
+// precision semantics
+func TestDecimalToString(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		precision int
+		expected  string
+	}{
+		{"zero precision", "3.14159", 0, "3"},
+		{"one decimal", "3.14159", 1, "3.1"},
+		{"two decimals", "3.14159", 2, "3.14"},
+		{"negative number", "-2.5", 1, "-2.5"},
+		{"large number", "123456.789", 2, "123456.79"},
+		{"zero", "0", 2, "0.00"},
+		{"tie to even", "2.5", 0, "2"},
+		{"padding", "1e2", 3, "100.000"},
+		{"exact", "0.1000", -1, "0.1000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := mustDecimal(t, tt.input)
+			if got := DecimalToString(d, tt.precision); got != tt.expected {
+				t.Errorf("DecimalToString(%s, %d) = %v, want %v", tt.input, tt.precision, got, tt.expected)
+			}
+			if tt.precision >= 0 {
+				if f, _ := StringToFloat(tt.input); FloatToString(f, tt.precision) != tt.expected {
+					t.Errorf("FloatToString disagrees: %s", FloatToString(f, tt.precision))
+				}
+			}
+		})
+	}
+}
+
+func TestDecimalFromFloat(t *testing.T) {
+	d, err := DecimalFromFloat(0.1)
+	if err != nil || d.String() != "0.1" {
+		t.Errorf("DecimalFromFloat(0.1) = %s, %v", d, err)
+	}
+	if d.Float64() != 0.1 {
+		t.Errorf("Float64() = %v, want 0.1", d.Float64())
+	}
+	if _, err := DecimalFromFloat(math.Inf(1)); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("Expected ErrInvalidDecimal for Inf, got %v", err)
+	}
+}
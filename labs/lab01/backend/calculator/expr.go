@@ -0,0 +1,264 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUndefinedVariable = errors.New("undefined variable")
+	ErrDomain            = errors.New("argument out of domain")
+)
+
+// SyntaxError is a malformed expression; Pos is the 1-based column
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", e.Pos, e.Msg)
+}
+
+// EvalError is an error evaluating the node at column Pos, such as
+// ErrDivisionByZero; it unwraps to Err
+type EvalError struct {
+	Pos int
+	Err error
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("evaluation error at column %d: %v", e.Pos, e.Err)
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// Node is a node of an expression's syntax tree
+type Node interface {
+	// Pos is the 1-based column of the node in the source
+	Pos() int
+	String() string
+	eval(vars map[string]float64) (float64, error)
+}
+
+// Number is a numeric literal
+type Number struct {
+	Column int
+	Value  float64
+}
+
+// Variable is a name bound when the expression is evaluated
+type Variable struct {
+	Column int
+	Name   string
+}
+
+// Unary is a unary minus or plus
+type Unary struct {
+	Column  int
+	Op      byte
+	Operand Node
+}
+
+// Binary is one of + - * / ^
+type Binary struct {
+	Column      int
+	Op          byte
+	Left, Right Node
+}
+
+// Call is a call of a built-in function
+type Call struct {
+	Column int
+	Name   string
+	Args   []Node
+}
+
+func (n *Number) Pos() int   { return n.Column }
+func (n *Variable) Pos() int { return n.Column }
+func (n *Unary) Pos() int    { return n.Column }
+func (n *Binary) Pos() int   { return n.Column }
+func (n *Call) Pos() int     { return n.Column }
+
+func (n *Number) String() string   { return strconv.FormatFloat(n.Value, 'g', -1, 64) }
+func (n *Variable) String() string { return n.Name }
+func (n *Unary) String() string    { return "(" + string(n.Op) + n.Operand.String() + ")" }
+func (n *Binary) String() string {
+	return "(" + n.Left.String() + " " + string(n.Op) + " " + n.Right.String() + ")"
+}
+func (n *Call) String() string {
+	args := make([]string, len(n.Args))
+	for i, a := range n.Args {
+		args[i] = a.String()
+	}
+	return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (n *Number) eval(map[string]float64) (float64, error) {
+	return n.Value, nil
+}
+
+func (n *Variable) eval(vars map[string]float64) (float64, error) {
+	v, ok := vars[n.Name]
+	if !ok {
+		return 0, &EvalError{Pos: n.Column, Err: fmt.Errorf("%w %q", ErrUndefinedVariable, n.Name)}
+	}
+	return v, nil
+}
+
+func (n *Unary) eval(vars map[string]float64) (float64, error) {
+	v, err := n.Operand.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	if n.Op == '-' {
+		return -v, nil
+	}
+	return v, nil
+}
+
+func (n *Binary) eval(vars map[string]float64) (float64, error) {
+	a, err := n.Left.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	b, err := n.Right.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	switch n.Op {
+	case '+':
+		return Add(a, b), nil
+	case '-':
+		return Subtract(a, b), nil
+	case '*':
+		return Multiply(a, b), nil
+	case '/':
+		v, err := Divide(a, b)
+		if err != nil {
+			return 0, &EvalError{Pos: n.Column, Err: err}
+		}
+		return v, nil
+	default:
+		v := math.Pow(a, b)
+		if math.IsNaN(v) {
+			return 0, &EvalError{Pos: n.Column, Err: fmt.Errorf("%w: %v ^ %v", ErrDomain, a, b)}
+		}
+		return v, nil
+	}
+}
+
+func (n *Call) eval(vars map[string]float64) (float64, error) {
+	args := make([]float64, len(n.Args))
+	for i, a := range n.Args {
+		v, err := a.eval(vars)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	v, err := functions[n.Name].call(args)
+	if err != nil {
+		return 0, &EvalError{Pos: n.Column, Err: err}
+	}
+	return v, nil
+}
+
+type function struct {
+	minArgs, maxArgs int // maxArgs < 0 means any number
+	call             func(args []float64) (float64, error)
+}
+
+var functions = map[string]function{
+	"sqrt": {1, 1, func(args []float64) (float64, error) {
+		if args[0] < 0 {
+			return 0, fmt.Errorf("%w: sqrt(%v)", ErrDomain, args[0])
+		}
+		return math.Sqrt(args[0]), nil
+	}},
+	"abs": {1, 1, func(args []float64) (float64, error) {
+		return math.Abs(args[0]), nil
+	}},
+	"min": {1, -1, func(args []float64) (float64, error) {
+		m := args[0]
+		for _, a := range args[1:] {
+			m = math.Min(m, a)
+		}
+		return m, nil
+	}},
+	"max": {1, -1, func(args []float64) (float64, error) {
+		m := args[0]
+		for _, a := range args[1:] {
+			m = math.Max(m, a)
+		}
+		return m, nil
+	}},
+	// round(x) rounds half away from zero; round(x, n) keeps n decimals
+	"round": {1, 2, func(args []float64) (float64, error) {
+		if len(args) == 1 {
+			return math.Round(args[0]), nil
+		}
+		scale := math.Pow(10, math.Trunc(args[1]))
+		return math.Round(args[0]*scale) / scale, nil
+	}},
+}
+
+// Expr is a parsed expression. It is immutable and can be evaluated many
+// times, concurrently, with different variables.
+type Expr struct {
+	Root   Node
+	source string
+}
+
+// Eval evaluates the expression with the given variables
+func (e *Expr) Eval(vars map[string]float64) (float64, error) {
+	return e.Root.eval(vars)
+}
+
+// Variables returns the names of the variables in the expression, sorted
+func (e *Expr) Variables() []string {
+	seen := map[string]bool{}
+	var walk func(Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case *Variable:
+			seen[n.Name] = true
+		case *Unary:
+			walk(n.Operand)
+		case *Binary:
+			walk(n.Left)
+			walk(n.Right)
+		case *Call:
+			for _, a := range n.Args {
+				walk(a)
+			}
+		}
+	}
+	walk(e.Root)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Evaluate parses and evaluates an expression without variables
+func Evaluate(s string) (float64, error) {
+	e, err := Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	return e.Eval(nil)
+}
@@ -0,0 +1,134 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{"precedence", "2+3*4", 14},
+		{"parentheses", "(2+3)*4", 20},
+		{"left associative", "10-4-3", 3},
+		{"left associative division", "64/4/2", 8},
+		{"right associative power", "2^3^2", 512},
+		{"unary minus binds looser than power", "-5^2", -25},
+		{"negative exponent", "2^-1", 0.5},
+		{"double negation", "--3", 3},
+		{"mixed", "2*(3+4)/-5^2", -0.56},
+		{"scientific literal", "1.5e3 + .5", 1500.5},
+		{"sqrt", "sqrt(16)", 4},
+		{"abs", "abs(-2.5)", 2.5},
+		{"min", "min(3, 1, 2)", 1},
+		{"max", "max(3, 1+4, 2)", 5},
+		{"round", "round(2.5)", 3},
+		{"round to decimals", "round(3.14159, 2)", 3.14},
+		{"nested calls", "max(abs(-7), sqrt(9))", 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(tt.input)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) failed: %v", tt.input, err)
+			}
+			if math.Abs(got-tt.expected) > 1e-12 {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"empty", "   ", 1},
+		{"unexpected character", "2 $ 3", 3},
+		{"missing operand", "2 +", 4},
+		{"unclosed parenthesis", "(1 + 2", 7},
+		{"extra parenthesis", "1 + 2)", 6},
+		{"adjacent numbers", "1 2", 3},
+		{"unknown function", "1 + foo(2)", 5},
+		{"too many arguments", "sqrt(1, 2)", 1},
+		{"no arguments", "min()", 1},
+		{"missing argument", "max(1,)", 7},
+		{"lone dot", "1 + .", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Expected SyntaxError, got %v", err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("Parse(%q) error at column %d, want %d (%v)", tt.input, syntaxErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		target error
+		pos    int
+	}{
+		{"division by zero", "1 + 4/(2-2)", ErrDivisionByZero, 6},
+		{"sqrt of negative", "sqrt(-4)", ErrDomain, 1},
+		{"fractional power of negative", "(-8)^0.5", ErrDomain, 5},
+		{"undefined variable", "2 * x", ErrUndefinedVariable, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Evaluate(tt.input)
+			if !errors.Is(err, tt.target) {
+				t.Fatalf("Expected %v, got %v", tt.target, err)
+			}
+			var evalErr *EvalError
+			if !errors.As(err, &evalErr) {
+				t.Fatalf("Expected EvalError, got %T", err)
+			}
+			if evalErr.Pos != tt.pos {
+				t.Errorf("Evaluate(%q) error at column %d, want %d", tt.input, evalErr.Pos, tt.pos)
+			}
+		})
+	}
+}
+
+func TestExprWithVariables(t *testing.T) {
+	bmi, err := Parse("round(weight / height^2, 1)")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := bmi.Variables(); len(got) != 2 || got[0] != "height" || got[1] != "weight" {
+		t.Errorf("Variables() = %v, want [height weight]", got)
+	}
+	if got := bmi.Root.String(); got != "round((weight / (height ^ 2)), 1)" {
+		t.Errorf("Root.String() = %q", got)
+	}
+
+	for _, tt := range []struct{ weight, height, expected float64 }{
+		{70, 1.75, 22.9},
+		{90, 1.8, 27.8},
+	} {
+		got, err := bmi.Eval(map[string]float64{"weight": tt.weight, "height": tt.height})
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if got != tt.expected {
+			t.Errorf("bmi(%v, %v) = %v, want %v", tt.weight, tt.height, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrInvalidNumber = errors.New("invalid number")
+
+// Notation selects how FormatFloat writes a number
+type Notation int
+
+const (
+	NotationFixed       Notation = iota // 1,234.5
+	NotationScientific                  // 1.2345E3
+	NotationEngineering                 // 1.2345E3, exponent a multiple of 3
+	NotationPercent                     // 12.5% for 0.125
+	NotationCompact                     // 1.2K
+)
+
+// FormatOptions configures FormatFloat
+type FormatOptions struct {
+	Notation Notation
+	// Precision is the number of digits after the decimal point, as in
+	// FloatToString; -1 uses as many as needed. In scientific, engineering
+	// and compact notation it applies to the scaled number.
+	Precision int
+	// SignificantDigits, when positive, rounds to that many significant
+	// digits instead of using Precision
+	SignificantDigits int
+	// NoGrouping turns off digit grouping
+	NoGrouping bool
+}
+
+// FormatFloat formats f with the locale's symbols. Numbers are rounded half
+// to even from their shortest decimal representation, so unlike
+// FloatToString 2.675 rounds to 2.68.
+func (l *Locale) FormatFloat(f float64, opts FormatOptions) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "∞"
+	case math.IsInf(f, -1):
+		return l.Minus + "∞"
+	}
+	d, _ := DecimalFromFloat(math.Abs(f))
+	sign := ""
+	if f < 0 {
+		sign = l.Minus
+	}
+
+	switch opts.Notation {
+	case NotationScientific, NotationEngineering:
+		step := 1
+		if opts.Notation == NotationEngineering {
+			step = 3
+		}
+		mantissa, exp := l.scaled(d, opts, func(e int) int { return floorTo(e, step) })
+		return sign + mantissa + l.Exponent + strconv.Itoa(exp)
+	case NotationPercent:
+		return sign + l.plain(shift(d, 2), opts) + l.PercentSuffix
+	case NotationCompact:
+		unit := -1
+		number, _ := l.scaled(d, opts, func(e int) int {
+			unit = -1
+			for i, u := range l.Compact {
+				if e >= u.Exponent {
+					unit = i
+				}
+			}
+			if unit < 0 {
+				return 0
+			}
+			return l.Compact[unit].Exponent
+		})
+		if unit < 0 {
+			return sign + number
+		}
+		return sign + number + l.Compact[unit].Suffix
+	default:
+		return sign + l.plain(d, opts)
+	}
+}
+
+// scaled writes d as m × 10^exp where exp = exponentFor(magnitude of d).
+// When rounding m carries it into the next magnitude, as 9.99 to 10.0, the
+// exponent is chosen again for the rounded number.
+func (l *Locale) scaled(d Decimal, opts FormatOptions, exponentFor func(int) int) (string, int) {
+	exp := exponentFor(magnitude(d))
+	m := round(shift(d, -exp), opts)
+	if next := exponentFor(magnitude(shift(m, exp))); next != exp {
+		exp = next
+		m = round(shift(d, -exp), opts)
+	}
+	return l.plain(m, opts), exp
+}
+
+// plain formats d in fixed notation with the locale's separators
+func (l *Locale) plain(d Decimal, opts FormatOptions) string {
+	d = round(d, opts)
+	var s string
+	switch {
+	case opts.SignificantDigits > 0:
+		s = DecimalToString(d, max(0, opts.SignificantDigits-1-magnitude(d)))
+	case opts.Precision >= 0:
+		s = DecimalToString(d, opts.Precision)
+	default:
+		s = d.String()
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if !opts.NoGrouping {
+		whole = l.group(whole)
+	}
+	if hasFrac {
+		return whole + l.Decimal + frac
+	}
+	return whole
+}
+
+// round applies the precision or significant digits of opts
+func round(d Decimal, opts FormatOptions) Decimal {
+	if opts.SignificantDigits > 0 {
+		return d.Round(opts.SignificantDigits-1-magnitude(d), RoundHalfEven)
+	}
+	if opts.Precision >= 0 {
+		return d.Round(opts.Precision, RoundHalfEven)
+	}
+	return d
+}
+
+// group inserts the group separator into a string of digits
+func (l *Locale) group(digits string) string {
+	if len(digits) < max(l.MinGroupingDigits, 1)+3 || len(l.GroupSizes) == 0 {
+		return digits
+	}
+	var groups []string
+	for i, end := 0, len(digits); end > 0; i++ {
+		size := l.GroupSizes[min(i, len(l.GroupSizes)-1)]
+		start := max(0, end-size)
+		groups = append([]string{digits[start:end]}, groups...)
+		end = start
+	}
+	return strings.Join(groups, l.Group)
+}
+
+// magnitude returns e such that 10^e <= |d| < 10^(e+1), or 0 for zero
+func magnitude(d Decimal) int {
+	if d.Sign() == 0 {
+		return 0
+	}
+	return len(new(big.Int).Abs(d.int()).String()) - 1 - d.scale
+}
+
+// shift returns d × 10^n
+func shift(d Decimal, n int) Decimal {
+	return Decimal{unscaled: d.int(), scale: d.scale - n}
+}
+
+func floorTo(e, step int) int {
+	if e >= 0 {
+		return e / step * step
+	}
+	return -((-e + step - 1) / step * step)
+}
+
+// ParseFloat parses a number written with the locale's symbols, such as
+// "1 234,5" in Russian. It accepts what FormatFloat writes: grouping,
+// scientific notation, percents ("12,5 %" is 0.125) and compact forms
+// ("1,2 тыс." is 1200), as well as "NaN" and "∞". Any space is accepted where the locale groups with
+// a space, and "." is accepted as the decimal separator where the locale
+// does not group with it.
+func (l *Locale) ParseFloat(s string) (float64, error) {
+	invalid := fmt.Errorf("%w %q for locale %s", ErrInvalidNumber, s, l.Tag)
+	s = strings.TrimSpace(asciiSpaces(s))
+
+	exp := 0
+	if rest, ok := strings.CutSuffix(s, "%"); ok {
+		s, exp = strings.TrimSpace(rest), -2
+	} else if unit, rest, ok := l.cutCompact(s); ok {
+		s, exp = rest, unit.Exponent
+	}
+
+	sign := ""
+	for _, minus := range []string{l.Minus, "-", "−"} {
+		if rest, ok := strings.CutPrefix(s, minus); ok {
+			s, sign = rest, "-"
+			break
+		}
+	}
+	if sign == "" {
+		s = strings.TrimPrefix(s, "+")
+	}
+	switch {
+	case s == "∞" && exp == 0 && sign == "":
+		return math.Inf(1), nil
+	case s == "∞" && exp == 0:
+		return math.Inf(-1), nil
+	case s == "NaN" && exp == 0 && sign == "":
+		return math.NaN(), nil
+	}
+
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(strings.Replace(s[i+1:], "−", "-", 1))
+		if err != nil {
+			return 0, invalid
+		}
+		s, exp = s[:i], exp+e
+	}
+
+	mantissa, ok := l.normalize(s)
+	if !ok {
+		return 0, invalid
+	}
+	f, err := strconv.ParseFloat(sign+mantissa+"e"+strconv.Itoa(exp), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", invalid, err)
+	}
+	return f, nil
+}
+
+// cutCompact removes a compact suffix, longest first
+func (l *Locale) cutCompact(s string) (CompactUnit, string, bool) {
+	best, bestLen := CompactUnit{}, 0
+	for _, u := range l.Compact {
+		suffix := strings.TrimSpace(asciiSpaces(u.Suffix))
+		if len(suffix) > bestLen && strings.HasSuffix(strings.ToLower(s), strings.ToLower(suffix)) {
+			best, bestLen = u, len(suffix)
+		}
+	}
+	if bestLen == 0 {
+		return CompactUnit{}, s, false
+	}
+	return best, strings.TrimSpace(s[:len(s)-bestLen]), true
+}
+
+// asciiSpaces replaces every Unicode space with " ", so that "1,5 mil M"
+// matches a suffix written with no-break spaces
+func asciiSpaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// normalize turns a localized unsigned number into "1234.5", checking
+// that group separators only appear between digits of the integer part and
+// that the groups have the locale's sizes, so "1,5" is not read as 15 in
+// English
+func (l *Locale) normalize(s string) (string, bool) {
+	spaceGroups := strings.TrimFunc(l.Group, unicode.IsSpace) == ""
+	altDecimal := l.Decimal == "," && l.Group != "."
+
+	var b strings.Builder
+	digits, prevDigit, seenDecimal := 0, false, false
+	// groups holds the digit counts of the integer groups before the last
+	// separator, and group the count since
+	var groups []int
+	group := 0
+	for s != "" {
+		r, size := utf8.DecodeRuneInString(s)
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+			digits++
+			prevDigit = true
+			if !seenDecimal {
+				group++
+			}
+		case seenDecimal:
+			return "", false
+		case strings.HasPrefix(s, l.Decimal) || (altDecimal && r == '.'):
+			if r != '.' {
+				size = len(l.Decimal)
+			}
+			b.WriteByte('.')
+			seenDecimal, prevDigit = true, false
+		case prevDigit && (strings.HasPrefix(s, l.Group) || (spaceGroups && unicode.IsSpace(r)) || (l.Group == "’" && r == '\'')):
+			if strings.HasPrefix(s, l.Group) {
+				size = len(l.Group)
+			}
+			// A separator must be followed by a digit
+			if next := s[size:]; next == "" || next[0] < '0' || next[0] > '9' {
+				return "", false
+			}
+			groups = append(groups, group)
+			group, prevDigit = 0, false
+		default:
+			return "", false
+		}
+		s = s[size:]
+	}
+	if len(groups) > 0 && !l.validGroups(append(groups, group)) {
+		return "", false
+	}
+	return b.String(), digits > 0
+}
+
+// validGroups reports whether integer digit groups, left to right, have
+// the sizes of l.GroupSizes; only the leftmost group may be shorter
+func (l *Locale) validGroups(groups []int) bool {
+	if len(l.GroupSizes) == 0 {
+		return true
+	}
+	for i := range groups {
+		// Sizes are counted from the right, the last one repeating
+		size := l.GroupSizes[min(len(groups)-1-i, len(l.GroupSizes)-1)]
+		if groups[i] > size || (i > 0 && groups[i] < size) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func mustLocale(t *testing.T, tag string) *Locale {
+	t.Helper()
+	l, err := LookupLocale(tag)
+	if err != nil {
+		t.Fatalf("LookupLocale(%q) failed: %v", tag, err)
+	}
+	return l
+}
+
+func TestLookupLocale(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected string
+	}{
+		{"ru", "ru"},
+		{"ru-RU", "ru"},
+		{"de_CH", "de-CH"},
+		{"DE-at", "de"},
+		{"en-in", "en-IN"},
+		{"en-US", "en"},
+	}
+	for _, tt := range tests {
+		if got := mustLocale(t, tt.tag).Tag; got != tt.expected {
+			t.Errorf("LookupLocale(%q) = %s, want %s", tt.tag, got, tt.expected)
+		}
+	}
+	if _, err := LookupLocale("xx"); !errors.Is(err, ErrUnknownLocale) {
+		t.Errorf("Expected ErrUnknownLocale, got %v", err)
+	}
+
+	changed := mustLocale(t, "ru")
+	changed.Decimal = "."
+	changed.GroupSizes[0] = 2
+	changed.Compact[0].Suffix = "K"
+	if l := mustLocale(t, "ru"); l.Decimal != "," || l.GroupSizes[0] != 3 || l.Compact[0].Suffix != nbsp+"тыс." {
+		t.Errorf("Changing a looked up locale changed the bundled one: %+v", l)
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		input    float64
+		opts     FormatOptions
+		expected string
+	}{
+		{"en fixed", "en", 1234567.891, FormatOptions{Precision: 2}, "1,234,567.89"},
+		{"en no grouping", "en", 1234567.891, FormatOptions{Precision: 2, NoGrouping: true}, "1234567.89"},
+		{"en shortest", "en", 0.1, FormatOptions{Precision: -1}, "0.1"},
+		{"ru fixed", "ru", -1234.5, FormatOptions{Precision: 1}, "-1 234,5"},
+		{"de fixed", "de", 1234.5, FormatOptions{Precision: 2}, "1.234,50"},
+		{"de-CH fixed", "de-CH", 1234567.5, FormatOptions{Precision: 1}, "1’234’567.5"},
+		{"fr fixed", "fr", 1234.5, FormatOptions{Precision: 1}, "1 234,5"},
+		{"es four digits", "es", 1234.5, FormatOptions{Precision: 1}, "1234,5"},
+		{"es five digits", "es", 12345.5, FormatOptions{Precision: 1}, "12.345,5"},
+		{"en-IN lakh grouping", "en-IN", 1234567, FormatOptions{}, "12,34,567"},
+		{"tie to even", "en", 2.5, FormatOptions{}, "2"},
+		{"significant", "en", 1234.5, FormatOptions{SignificantDigits: 3}, "1,230"},
+		{"significant small", "ru", 0.0012345, FormatOptions{SignificantDigits: 2}, "0,0012"},
+		{"significant keeps zeros", "en", 1.5, FormatOptions{SignificantDigits: 3}, "1.50"},
+		{"significant carry", "en", 9.996, FormatOptions{SignificantDigits: 3}, "10.0"},
+		{"scientific", "en", 12345, FormatOptions{Notation: NotationScientific, Precision: 2}, "1.23E4"},
+		{"scientific small", "de", 0.00012345, FormatOptions{Notation: NotationScientific, SignificantDigits: 3}, "1,23E-4"},
+		{"scientific carry", "en", 9.99, FormatOptions{Notation: NotationScientific, Precision: 1}, "1.0E1"},
+		{"engineering", "en", 12345, FormatOptions{Notation: NotationEngineering, Precision: -1}, "12.345E3"},
+		{"engineering small", "en", 0.00012, FormatOptions{Notation: NotationEngineering, Precision: -1}, "120E-6"},
+		{"percent", "en", 0.125, FormatOptions{Notation: NotationPercent, Precision: 1}, "12.5%"},
+		{"percent exact", "en", 0.07, FormatOptions{Notation: NotationPercent, Precision: -1}, "7%"},
+		{"ru percent", "ru", 0.125, FormatOptions{Notation: NotationPercent, Precision: 1}, "12,5 %"},
+		{"compact", "en", 1234, FormatOptions{Notation: NotationCompact, Precision: 1}, "1.2K"},
+		{"compact small", "en", 999, FormatOptions{Notation: NotationCompact, Precision: 1}, "999.0"},
+		{"compact carry", "en", 999999, FormatOptions{Notation: NotationCompact, Precision: 1}, "1.0M"},
+		{"ru compact", "ru", 1234, FormatOptions{Notation: NotationCompact, Precision: 1}, "1,2 тыс."},
+		{"en-IN compact", "en-IN", 2500000, FormatOptions{Notation: NotationCompact, Precision: 0}, "25L"},
+		{"negative compact", "en", -1500000, FormatOptions{Notation: NotationCompact, Precision: 1}, "-1.5M"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustLocale(t, tt.locale).FormatFloat(tt.input, tt.opts); got != tt.expected {
+				t.Errorf("FormatFloat(%v) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	tests := []struct {
+		locale      string
+		input       string
+		expected    float64
+		expectError bool
+	}{
+		{"ru", "1 234,5", 1234.5, false},
+		{"ru", "1 234,5", 1234.5, false},
+		{"ru", "-1 234 567", -1234567, false},
+		{"ru", "3.5", 3.5, false},
+		{"ru", "12,5 %", 0.125, false},
+		{"ru", "1,2 тыс.", 1200, false},
+		{"ru", "−2,5", -2.5, false},
+		{"en", "1,234.5", 1234.5, false},
+		{"en", "1.2K", 1200, false},
+		{"en", "1.5e3", 1500, false},
+		{"en", "+7", 7, false},
+		{"de", "1.234,5", 1234.5, false},
+		{"de", "1,23E-4", 0.000123, false},
+		{"de-CH", "1'234.5", 1234.5, false},
+		{"en-IN", "12,34,567", 1234567, false},
+		{"en-IN", "2.5Cr", 25000000, false},
+		{"es", "1,5 mil M", 1.5e9, false},
+		{"en", "", 0, true},
+		{"en", "abc", 0, true},
+		{"en", "1,,234", 0, true},
+		{"en", "1,234.5.6", 0, true},
+		{"en", "1.5,000", 0, true},
+		{"en", ",123", 0, true},
+		{"de", "1.234.", 0, true},
+		{"ru", "1 234,5 6", 0, true},
+		{"en", "%", 0, true},
+		{"en", "1,5", 0, true},
+		{"en", "1,2,3", 0, true},
+		{"en", "1234,567", 0, true},
+		{"en", "1,2345", 0, true},
+		{"en", "12,345,678.9", 12345678.9, false},
+		{"de", "1.5", 0, true},
+		{"en-IN", "1,234,567", 0, true},
+		{"en-IN", "1,23,45,678", 12345678, false},
+		{"ru", "12 34", 0, true},
+		{"en", "∞", math.Inf(1), false},
+		{"ru", "-∞", math.Inf(-1), false},
+		{"en", "-NaN", 0, true},
+		{"en", "∞%", 0, true},
+		{"en", "∞e3", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+" "+tt.input, func(t *testing.T) {
+			got, err := mustLocale(t, tt.locale).ParseFloat(tt.input)
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidNumber) {
+					t.Errorf("Expected ErrInvalidNumber, got %v (%v)", err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseFloat(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestLocaleRoundTrip checks that ParseFloat reads back what FormatFloat
+// writes in every bundled locale and notation
+func TestLocaleRoundTrip(t *testing.T) {
+	values := []float64{0, 1, -1, 0.5, 3.14159, -1234.5, 1234567.891, 0.000123, 1e21, 987654321.125}
+	notations := map[string]Notation{
+		"fixed":       NotationFixed,
+		"scientific":  NotationScientific,
+		"engineering": NotationEngineering,
+		"percent":     NotationPercent,
+		"compact":     NotationCompact,
+	}
+
+	for _, tag := range Locales() {
+		l := mustLocale(t, tag)
+		for name, notation := range notations {
+			for _, v := range values {
+				s := l.FormatFloat(v, FormatOptions{Notation: notation, Precision: -1})
+				got, err := l.ParseFloat(s)
+				if err != nil {
+					t.Errorf("%s %s: ParseFloat(%q) failed: %v", tag, name, s, err)
+					continue
+				}
+				if got != v {
+					t.Errorf("%s %s: %v formatted as %q parsed as %v", tag, name, v, s, got)
+				}
+			}
+		}
+
+		for _, v := range []float64{math.Inf(1), math.Inf(-1), math.NaN()} {
+			s := l.FormatFloat(v, FormatOptions{})
+			got, err := l.ParseFloat(s)
+			if err != nil {
+				t.Errorf("%s: ParseFloat(%q) failed: %v", tag, s, err)
+			} else if got != v && !(math.IsNaN(got) && math.IsNaN(v)) {
+				t.Errorf("%s: %v formatted as %q parsed as %v", tag, v, s, got)
+			}
+		}
+	}
+}
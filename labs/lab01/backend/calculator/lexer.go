@@ -0,0 +1,101 @@
+package calculator
+
+import (
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator // + - * / ^
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // 1-based column of the first character
+}
+
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return "\"" + t.text + "\""
+}
+
+// tokenize splits an expression into tokens, ending with tokenEOF
+func tokenize(s string) ([]token, error) {
+	runes := []rune(s)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case unicode.IsDigit(r) || r == '.':
+			i = scanNumber(runes, i)
+			text := string(runes[start:i])
+			if text == "." {
+				return nil, &SyntaxError{Pos: start + 1, Msg: "invalid number \".\""}
+			}
+			tokens = append(tokens, token{tokenNumber, text, start + 1})
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[start:i]), start + 1})
+			continue
+		case r == '+' || r == '-' || r == '*' || r == '/' || r == '^':
+			tokens = append(tokens, token{tokenOperator, string(r), start + 1})
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", start + 1})
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", start + 1})
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", start + 1})
+		default:
+			return nil, &SyntaxError{Pos: start + 1, Msg: "unexpected character " + quoteRune(r)}
+		}
+		i++
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+// scanNumber returns the end of the number starting at i: digits with an
+// optional fraction and exponent, e.g. 12, .5, 3.25e-4
+func scanNumber(runes []rune, i int) int {
+	digits := func() {
+		for i < len(runes) && unicode.IsDigit(runes[i]) {
+			i++
+		}
+	}
+	digits()
+	if i < len(runes) && runes[i] == '.' {
+		i++
+		digits()
+	}
+	if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+		j := i + 1
+		if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+			j++
+		}
+		if j < len(runes) && unicode.IsDigit(runes[j]) {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
+
+func quoteRune(r rune) string {
+	return "'" + string(r) + "'"
+}
@@ -0,0 +1,116 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownLocale = errors.New("unknown locale")
+
+// Locale holds the number symbols of a locale, as in the Unicode CLDR
+type Locale struct {
+	Tag     string
+	Decimal string
+	Group   string
+	// GroupSizes are the sizes of the integer digit groups from the right:
+	// {3} groups thousands, {3, 2} groups as in India (12,34,567); the last
+	// size repeats
+	GroupSizes []int
+	// MinGroupingDigits is the number of integer digits below which no
+	// grouping is done; Spanish writes 1234 but 12.345
+	MinGroupingDigits int
+	Minus             string
+	Exponent          string
+	// PercentSuffix follows the number in percent notation, including any space
+	PercentSuffix string
+	// Compact lists the abbreviations of compact notation, ascending
+	Compact []CompactUnit
+}
+
+// CompactUnit abbreviates 10^Exponent, e.g. {3, "K"} in English
+type CompactUnit struct {
+	Exponent int
+	Suffix   string
+}
+
+const (
+	nbsp       = "\u00a0"
+	narrowNBSP = "\u202f"
+)
+
+// locales is the bundled locale data, from CLDR 45
+var locales = map[string]*Locale{
+	"en": {
+		Tag: "en", Decimal: ".", Group: ",", GroupSizes: []int{3}, MinGroupingDigits: 1,
+		Minus: "-", Exponent: "E", PercentSuffix: "%",
+		Compact: []CompactUnit{{3, "K"}, {6, "M"}, {9, "B"}, {12, "T"}},
+	},
+	"en-IN": {
+		Tag: "en-IN", Decimal: ".", Group: ",", GroupSizes: []int{3, 2}, MinGroupingDigits: 1,
+		Minus: "-", Exponent: "E", PercentSuffix: "%",
+		Compact: []CompactUnit{{3, "K"}, {5, "L"}, {7, "Cr"}},
+	},
+	"ru": {
+		Tag: "ru", Decimal: ",", Group: nbsp, GroupSizes: []int{3}, MinGroupingDigits: 1,
+		Minus: "-", Exponent: "E", PercentSuffix: nbsp + "%",
+		Compact: []CompactUnit{{3, nbsp + "тыс."}, {6, nbsp + "млн"}, {9, nbsp + "млрд"}, {12, nbsp + "трлн"}},
+	},
+	"de": {
+		Tag: "de", Decimal: ",", Group: ".", GroupSizes: []int{3}, MinGroupingDigits: 1,
+		Minus: "-", Exponent: "E", PercentSuffix: nbsp + "%",
+		Compact: []CompactUnit{{3, nbsp + "Tsd."}, {6, nbsp + "Mio."}, {9, nbsp + "Mrd."}, {12, nbsp + "Bio."}},
+	},
+	"de-CH": {
+		Tag: "de-CH", Decimal: ".", Group: "’", GroupSizes: []int{3}, MinGroupingDigits: 1,
+		Minus: "-", Exponent: "E", PercentSuffix: "%",
+		Compact: []CompactUnit{{3, nbsp + "Tsd."}, {6, nbsp + "Mio."}, {9, nbsp + "Mrd."}, {12, nbsp + "Bio."}},
+	},
+	"fr": {
+		Tag: "fr", Decimal: ",", Group: narrowNBSP, GroupSizes: []int{3}, MinGroupingDigits: 1,
+		Minus: "-", Exponent: "E", PercentSuffix: narrowNBSP + "%",
+		Compact: []CompactUnit{{3, nbsp + "k"}, {6, nbsp + "M"}, {9, nbsp + "Md"}, {12, nbsp + "Bn"}},
+	},
+	"es": {
+		Tag: "es", Decimal: ",", Group: ".", GroupSizes: []int{3}, MinGroupingDigits: 2,
+		Minus: "-", Exponent: "E", PercentSuffix: nbsp + "%",
+		Compact: []CompactUnit{{3, nbsp + "mil"}, {6, nbsp + "M"}, {9, nbsp + "mil" + nbsp + "M"}, {12, nbsp + "B"}},
+	},
+}
+
+// LookupLocale returns a copy of the bundled locale for a BCP 47 tag such as
+// "ru", "ru-RU" or "de_CH", falling back from region to language. Changing
+// the copy does not affect other callers.
+func LookupLocale(tag string) (*Locale, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	for {
+		for key, l := range locales {
+			if strings.ToLower(key) == normalized {
+				return l.clone(), nil
+			}
+		}
+		i := strings.LastIndex(normalized, "-")
+		if i < 0 {
+			return nil, fmt.Errorf("%w %q", ErrUnknownLocale, tag)
+		}
+		normalized = normalized[:i]
+	}
+}
+
+func (l *Locale) clone() *Locale {
+	c := *l
+	c.GroupSizes = append([]int(nil), l.GroupSizes...)
+	c.Compact = append([]CompactUnit(nil), l.Compact...)
+	return &c
+}
+
+// Locales returns the tags of the bundled locales, sorted
+func Locales() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,188 @@
+package calculator
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Parse parses an infix expression such as "2*(3+4)/-5^2" or
+// "round(weight / height^2, 1)".
+//
+// From lowest to highest precedence the operators are + and -, * and /,
+// unary minus and plus, and ^. All binary operators are left-associative
+// except ^, so 2^3^2 is 2^9 and -5^2 is -25.
+func Parse(s string) (*Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, &SyntaxError{Pos: 1, Msg: "empty expression"}
+	}
+	root, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+	return &Expr{Root: root, source: s}, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) unexpected(t token) error {
+	return &SyntaxError{Pos: t.pos, Msg: "unexpected " + t.describe()}
+}
+
+func (p *parser) isOperator(ops string) bool {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return false
+	}
+	for i := 0; i < len(ops); i++ {
+		if t.text[0] == ops[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// expression := term (("+" | "-") term)*
+func (p *parser) expression() (Node, error) {
+	return p.binary("+-", p.term)
+}
+
+// term := unary (("*" | "/") unary)*
+func (p *parser) term() (Node, error) {
+	return p.binary("*/", p.unary)
+}
+
+func (p *parser) binary(ops string, operand func() (Node, error)) (Node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(ops) {
+		op := p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Column: op.pos, Op: op.text[0], Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// unary := ("-" | "+") unary | power
+func (p *parser) unary() (Node, error) {
+	if p.isOperator("+-") {
+		op := p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Column: op.pos, Op: op.text[0], Operand: operand}, nil
+	}
+	return p.power()
+}
+
+// power := primary ("^" unary)?
+func (p *parser) power() (Node, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("^") {
+		return base, nil
+	}
+	op := p.next()
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &Binary{Column: op.pos, Op: '^', Left: base, Right: exponent}, nil
+}
+
+// primary := number | name | name "(" arguments ")" | "(" expression ")"
+func (p *parser) primary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("invalid number %q", t.text)}
+		}
+		return &Number{Column: t.pos, Value: v}, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			return p.call(t)
+		}
+		return &Variable{Column: t.pos, Name: t.text}, nil
+	case tokenLParen:
+		inner, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: fmt.Sprintf("expected \")\" to close \"(\" at column %d, found %s", t.pos, closing.describe())}
+		}
+		return inner, nil
+	default:
+		return nil, p.unexpected(t)
+	}
+}
+
+func (p *parser) call(name token) (Node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, &SyntaxError{Pos: name.pos, Msg: fmt.Sprintf("unknown function %q", name.text)}
+	}
+	p.next() // (
+
+	c := &Call{Column: name.pos, Name: name.text}
+	if p.peek().kind != tokenRParen {
+		for {
+			arg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			c.Args = append(c.Args, arg)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if closing := p.next(); closing.kind != tokenRParen {
+		return nil, &SyntaxError{Pos: closing.pos, Msg: fmt.Sprintf("expected \",\" or \")\" in call of %s, found %s", name.text, closing.describe())}
+	}
+
+	if len(c.Args) < fn.minArgs || (fn.maxArgs >= 0 && len(c.Args) > fn.maxArgs) {
+		want := strconv.Itoa(fn.minArgs)
+		switch {
+		case fn.maxArgs < 0:
+			want = "at least " + want
+		case fn.maxArgs != fn.minArgs:
+			want += " or " + strconv.Itoa(fn.maxArgs)
+		}
+		return nil, &SyntaxError{Pos: name.pos, Msg: fmt.Sprintf("%s takes %s argument(s), got %d", name.text, want, len(c.Args))}
+	}
+	return c, nil
+}
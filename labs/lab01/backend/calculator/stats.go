@@ -0,0 +1,390 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+)
+
+var (
+	ErrEmptyInput        = errors.New("empty input")
+	ErrTooFewValues      = errors.New("too few values")
+	ErrInvalidPercentile = errors.New("percentile out of range")
+	ErrInvalidWindow     = errors.New("invalid window size")
+	ErrInvalidSmoothing  = errors.New("smoothing factor out of range")
+)
+
+// Interpolation selects how Percentile estimates a value that falls between
+// two data points. The names follow numpy.percentile.
+type Interpolation int
+
+const (
+	// InterpolationLinear interpolates between the neighbouring points
+	// (Hyndman and Fan type 7, the default of numpy and Excel PERCENTILE.INC)
+	InterpolationLinear Interpolation = iota
+	// InterpolationLower takes the lower neighbour
+	InterpolationLower
+	// InterpolationHigher takes the higher neighbour
+	InterpolationHigher
+	// InterpolationNearest takes the nearer neighbour, the even index on ties
+	InterpolationNearest
+	// InterpolationMidpoint averages the two neighbours
+	InterpolationMidpoint
+)
+
+// compensatedSum adds floats with Neumaier's variant of Kahan summation, so
+// that the rounding error does not grow with the number of values
+type compensatedSum struct {
+	sum, c float64
+}
+
+func (s *compensatedSum) add(x float64) {
+	t := s.sum + x
+	if math.Abs(s.sum) >= math.Abs(x) {
+		s.c += (s.sum - t) + x
+	} else {
+		s.c += (x - t) + s.sum
+	}
+	s.sum = t
+}
+
+// finite reports whether neither the sum nor its compensation is an
+// infinity or NaN
+func (s *compensatedSum) finite() bool {
+	return !math.IsInf(s.sum, 0) && !math.IsNaN(s.sum) && !math.IsInf(s.c, 0) && !math.IsNaN(s.c)
+}
+
+func (s *compensatedSum) value() float64 {
+	if math.IsInf(s.sum, 0) || math.IsNaN(s.sum) {
+		return s.sum
+	}
+	return s.sum + s.c
+}
+
+// Summary accumulates count, sum, mean, variance, min and max of a stream of
+// values in constant memory. The mean and variance use Welford's algorithm
+// and the sum is compensated. The zero value is an empty summary.
+type Summary struct {
+	n        int
+	sum      compensatedSum
+	mean, m2 float64
+	min, max float64
+}
+
+// Summarize returns the summary of all values of seq
+func Summarize(seq iter.Seq[float64]) *Summary {
+	s := &Summary{}
+	for x := range seq {
+		s.Add(x)
+	}
+	return s
+}
+
+// Add adds x to the summary
+func (s *Summary) Add(x float64) {
+	s.n++
+	s.sum.add(x)
+	delta := x - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (x - s.mean)
+	if s.n == 1 || x < s.min {
+		s.min = x
+	}
+	if s.n == 1 || x > s.max {
+		s.max = x
+	}
+}
+
+// Merge adds the values summarized by o, as if they had been added one by
+// one, using the pairwise update of Chan, Golub and LeVeque
+func (s *Summary) Merge(o *Summary) {
+	switch {
+	case o.n == 0:
+		return
+	case s.n == 0:
+		*s = *o
+		return
+	}
+	n := s.n + o.n
+	delta := o.mean - s.mean
+	s.m2 += o.m2 + delta*delta*float64(s.n)*float64(o.n)/float64(n)
+	s.mean += delta * float64(o.n) / float64(n)
+	s.sum.add(o.sum.sum)
+	s.sum.add(o.sum.c)
+	s.min = min(s.min, o.min)
+	s.max = max(s.max, o.max)
+	s.n = n
+}
+
+func (s *Summary) Count() int {
+	return s.n
+}
+
+// Sum returns the sum of the values, 0 for an empty summary
+func (s *Summary) Sum() float64 {
+	return s.sum.value()
+}
+
+func (s *Summary) Mean() (float64, error) {
+	if s.n == 0 {
+		return 0, ErrEmptyInput
+	}
+	// The compensated sum is the more accurate, unless it overflowed
+	if sum := s.sum.value(); !math.IsInf(sum, 0) || math.IsInf(s.mean, 0) {
+		return sum / float64(s.n), nil
+	}
+	return s.mean, nil
+}
+
+// Variance returns the sample variance, dividing by n-1
+func (s *Summary) Variance() (float64, error) {
+	if s.n == 0 {
+		return 0, ErrEmptyInput
+	}
+	if s.n == 1 {
+		return 0, fmt.Errorf("%w: sample variance needs at least 2 values", ErrTooFewValues)
+	}
+	return s.m2 / float64(s.n-1), nil
+}
+
+// PopulationVariance returns the population variance, dividing by n
+func (s *Summary) PopulationVariance() (float64, error) {
+	if s.n == 0 {
+		return 0, ErrEmptyInput
+	}
+	return s.m2 / float64(s.n), nil
+}
+
+// StdDev returns the sample standard deviation
+func (s *Summary) StdDev() (float64, error) {
+	v, err := s.Variance()
+	return math.Sqrt(v), err
+}
+
+// PopulationStdDev returns the population standard deviation
+func (s *Summary) PopulationStdDev() (float64, error) {
+	v, err := s.PopulationVariance()
+	return math.Sqrt(v), err
+}
+
+func (s *Summary) Min() (float64, error) {
+	if s.n == 0 {
+		return 0, ErrEmptyInput
+	}
+	return s.min, nil
+}
+
+func (s *Summary) Max() (float64, error) {
+	if s.n == 0 {
+		return 0, ErrEmptyInput
+	}
+	return s.max, nil
+}
+
+// Sum returns the compensated sum of xs, 0 if xs is empty
+func Sum(xs []float64) float64 {
+	var s compensatedSum
+	for _, x := range xs {
+		s.add(x)
+	}
+	return s.value()
+}
+
+func Mean(xs []float64) (float64, error) {
+	return Summarize(slices.Values(xs)).Mean()
+}
+
+// Variance returns the sample variance of xs
+func Variance(xs []float64) (float64, error) {
+	return Summarize(slices.Values(xs)).Variance()
+}
+
+func PopulationVariance(xs []float64) (float64, error) {
+	return Summarize(slices.Values(xs)).PopulationVariance()
+}
+
+// StdDev returns the sample standard deviation of xs
+func StdDev(xs []float64) (float64, error) {
+	return Summarize(slices.Values(xs)).StdDev()
+}
+
+func PopulationStdDev(xs []float64) (float64, error) {
+	return Summarize(slices.Values(xs)).PopulationStdDev()
+}
+
+func Min(xs []float64) (float64, error) {
+	if len(xs) == 0 {
+		return 0, ErrEmptyInput
+	}
+	return slices.Min(xs), nil
+}
+
+func Max(xs []float64) (float64, error) {
+	if len(xs) == 0 {
+		return 0, ErrEmptyInput
+	}
+	return slices.Max(xs), nil
+}
+
+// Median returns the middle value of xs, or the mean of the two middle
+// values when len(xs) is even. xs is not modified.
+func Median(xs []float64) (float64, error) {
+	return Percentile(xs, 50, InterpolationLinear)
+}
+
+// Mode returns the most frequent values of xs in ascending order; every
+// value is a mode when all occur equally often. NaNs are ignored.
+func Mode(xs []float64) ([]float64, error) {
+	counts := make(map[float64]int)
+	best := 0
+	for _, x := range xs {
+		if math.IsNaN(x) {
+			continue
+		}
+		counts[x]++
+		best = max(best, counts[x])
+	}
+	if best == 0 {
+		return nil, ErrEmptyInput
+	}
+	var modes []float64
+	for x, n := range counts {
+		if n == best {
+			modes = append(modes, x)
+		}
+	}
+	slices.Sort(modes)
+	return modes, nil
+}
+
+// Percentile returns the p-th percentile of xs, 0 <= p <= 100, estimating
+// values between data points with method. xs is not modified.
+func Percentile(xs []float64, p float64, method Interpolation) (float64, error) {
+	ps, err := Percentiles(xs, []float64{p}, method)
+	if err != nil {
+		return 0, err
+	}
+	return ps[0], nil
+}
+
+// Percentiles returns several percentiles of xs, sorting a copy of it once
+func Percentiles(xs []float64, ps []float64, method Interpolation) ([]float64, error) {
+	if len(xs) == 0 {
+		return nil, ErrEmptyInput
+	}
+	for _, p := range ps {
+		if !(p >= 0 && p <= 100) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPercentile, p)
+		}
+	}
+	sorted := slices.Clone(xs)
+	slices.Sort(sorted)
+
+	result := make([]float64, len(ps))
+	for i, p := range ps {
+		result[i] = percentileOfSorted(sorted, p, method)
+	}
+	return result, nil
+}
+
+func percentileOfSorted(sorted []float64, p float64, method Interpolation) float64 {
+	h := float64(len(sorted)-1) * p / 100
+	lo, hi := int(math.Floor(h)), int(math.Ceil(h))
+	a, b := sorted[lo], sorted[hi]
+	switch method {
+	case InterpolationLower:
+		return a
+	case InterpolationHigher:
+		return b
+	case InterpolationNearest:
+		return sorted[int(math.RoundToEven(h))]
+	case InterpolationMidpoint:
+		return a + (b-a)/2
+	default:
+		if lo == hi {
+			return a
+		}
+		return a + (h-float64(lo))*(b-a)
+	}
+}
+
+// MovingAverages returns the simple moving averages of xs over window
+// consecutive values: len(xs)-window+1 values, none if xs is shorter than
+// window
+func MovingAverages(xs []float64, window int) ([]float64, error) {
+	m, err := NewMovingAverage(window)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]float64, 0, max(0, len(xs)-window+1))
+	for _, x := range xs {
+		avg := m.Add(x)
+		if m.Full() {
+			result = append(result, avg)
+		}
+	}
+	return result, nil
+}
+
+// ExponentialMovingAverages returns the exponentially weighted moving
+// averages of xs, seeded with xs[0]: each is alpha·x + (1-alpha)·previous,
+// with 0 < alpha <= 1
+func ExponentialMovingAverages(xs []float64, alpha float64) ([]float64, error) {
+	if !(alpha > 0 && alpha <= 1) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSmoothing, alpha)
+	}
+	result := make([]float64, len(xs))
+	for i, x := range xs {
+		if i == 0 {
+			result[i] = x
+			continue
+		}
+		result[i] = result[i-1] + alpha*(x-result[i-1])
+	}
+	return result, nil
+}
+
+// MovingAverage is the simple moving average of the last values of a stream
+type MovingAverage struct {
+	values []float64
+	next   int
+	full   bool
+	sum    compensatedSum
+}
+
+func NewMovingAverage(window int) (*MovingAverage, error) {
+	if window < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidWindow, window)
+	}
+	return &MovingAverage{values: make([]float64, 0, window)}, nil
+}
+
+// Add adds x and returns the average of the last window values, or of all
+// values while fewer than window have been added
+func (m *MovingAverage) Add(x float64) float64 {
+	if m.full {
+		m.sum.add(-m.values[m.next])
+		m.values[m.next] = x
+		m.next = (m.next + 1) % len(m.values)
+	} else {
+		m.values = append(m.values, x)
+		m.full = len(m.values) == cap(m.values)
+	}
+	m.sum.add(x)
+	if !m.sum.finite() {
+		// An infinity or NaN stays in the running sum after it leaves the
+		// window, so sum the window again
+		m.sum = compensatedSum{}
+		for _, v := range m.values {
+			m.sum.add(v)
+		}
+	}
+	return m.sum.value() / float64(len(m.values))
+}
+
+// Full reports whether window values have been added
+func (m *MovingAverage) Full() bool {
+	return m.full
+}
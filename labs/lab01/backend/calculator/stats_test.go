@@ -0,0 +1,263 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestStatsBasic(t *testing.T) {
+	sleep := []float64{7.5, 6, 8, 7.5, 5.5, 9, 7}
+
+	if got := Sum(sleep); got != 50.5 {
+		t.Errorf("Sum = %v, want 50.5", got)
+	}
+	if got, _ := Mean(sleep); math.Abs(got-50.5/7) > 1e-15 {
+		t.Errorf("Mean = %v, want %v", got, 50.5/7)
+	}
+	if got, _ := Median(sleep); got != 7.5 {
+		t.Errorf("Median = %v, want 7.5", got)
+	}
+	if got, _ := Median([]float64{4, 1, 3, 2}); got != 2.5 {
+		t.Errorf("Median of even count = %v, want 2.5", got)
+	}
+	if got, _ := Min(sleep); got != 5.5 {
+		t.Errorf("Min = %v, want 5.5", got)
+	}
+	if got, _ := Max(sleep); got != 9 {
+		t.Errorf("Max = %v, want 9", got)
+	}
+	if !slices.Equal(sleep, []float64{7.5, 6, 8, 7.5, 5.5, 9, 7}) {
+		t.Errorf("Input was modified: %v", sleep)
+	}
+}
+
+func TestVariance(t *testing.T) {
+	xs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	tests := []struct {
+		name     string
+		fn       func([]float64) (float64, error)
+		expected float64
+	}{
+		{"population variance", PopulationVariance, 4},
+		{"population stddev", PopulationStdDev, 2},
+		{"sample variance", Variance, 32.0 / 7},
+		{"sample stddev", StdDev, math.Sqrt(32.0 / 7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn(xs)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(got-tt.expected) > 1e-12 {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Variance([]float64{1}); !errors.Is(err, ErrTooFewValues) {
+		t.Errorf("Expected ErrTooFewValues, got %v", err)
+	}
+	if got, err := PopulationVariance([]float64{1}); err != nil || got != 0 {
+		t.Errorf("PopulationVariance of one value = %v, %v", got, err)
+	}
+}
+
+// TestStatsStability checks values whose naive sum or variance loses all
+// precision
+func TestStatsStability(t *testing.T) {
+	tenths := make([]float64, 10)
+	for i := range tenths {
+		tenths[i] = 0.1
+	}
+	if got := Sum(tenths); got != 1 {
+		t.Errorf("Sum of ten 0.1 = %v, want 1", got)
+	}
+	if got := Sum([]float64{1, 1e100, 1, -1e100}); got != 2 {
+		t.Errorf("Sum = %v, want 2", got)
+	}
+
+	// A large offset makes the textbook formula E[x²] - E[x]² cancel out
+	shifted := []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16}
+	if got, _ := Variance(shifted); got != 30 {
+		t.Errorf("Variance = %v, want 30", got)
+	}
+
+	if got, _ := Mean([]float64{math.MaxFloat64, math.MaxFloat64}); got != math.MaxFloat64 {
+		t.Errorf("Mean = %v, want MaxFloat64", got)
+	}
+}
+
+func TestStatsEmptyInput(t *testing.T) {
+	var empty []float64
+	fns := map[string]func([]float64) (float64, error){
+		"Mean":               Mean,
+		"Median":             Median,
+		"Variance":           Variance,
+		"PopulationVariance": PopulationVariance,
+		"StdDev":             StdDev,
+		"PopulationStdDev":   PopulationStdDev,
+		"Min":                Min,
+		"Max":                Max,
+	}
+	for name, fn := range fns {
+		if _, err := fn(empty); !errors.Is(err, ErrEmptyInput) {
+			t.Errorf("%s: expected ErrEmptyInput, got %v", name, err)
+		}
+	}
+	if _, err := Mode(empty); !errors.Is(err, ErrEmptyInput) {
+		t.Errorf("Mode: expected ErrEmptyInput, got %v", err)
+	}
+	if got := Sum(empty); got != 0 {
+		t.Errorf("Sum of nothing = %v, want 0", got)
+	}
+}
+
+func TestMode(t *testing.T) {
+	tests := []struct {
+		input    []float64
+		expected []float64
+	}{
+		{[]float64{1, 2, 2, 3}, []float64{2}},
+		{[]float64{3, 1, 3, 1, 2}, []float64{1, 3}},
+		{[]float64{5}, []float64{5}},
+		{[]float64{math.NaN(), 4, math.NaN()}, []float64{4}},
+	}
+	for _, tt := range tests {
+		got, err := Mode(tt.input)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("Mode(%v) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	xs := []float64{15, 20, 35, 40, 50}
+
+	tests := []struct {
+		p        float64
+		method   Interpolation
+		expected float64
+	}{
+		{0, InterpolationLinear, 15},
+		{100, InterpolationLinear, 50},
+		{40, InterpolationLinear, 29},
+		{40, InterpolationLower, 20},
+		{40, InterpolationHigher, 35},
+		{40, InterpolationNearest, 35},
+		{40, InterpolationMidpoint, 27.5},
+		{37.5, InterpolationNearest, 35},
+		{62.5, InterpolationNearest, 35},
+		{75, InterpolationLinear, 40},
+	}
+
+	for _, tt := range tests {
+		got, err := Percentile(xs, tt.p, tt.method)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if math.Abs(got-tt.expected) > 1e-12 {
+			t.Errorf("Percentile(%v, %d) = %v, want %v", tt.p, tt.method, got, tt.expected)
+		}
+	}
+
+	quartiles, err := Percentiles([]float64{4, 1, 3, 2, 5}, []float64{25, 50, 75}, InterpolationLinear)
+	if err != nil || !slices.Equal(quartiles, []float64{2, 3, 4}) {
+		t.Errorf("Quartiles = %v, %v", quartiles, err)
+	}
+
+	for _, p := range []float64{-1, 101, math.NaN()} {
+		if _, err := Percentile(xs, p, InterpolationLinear); !errors.Is(err, ErrInvalidPercentile) {
+			t.Errorf("Percentile %v: expected ErrInvalidPercentile, got %v", p, err)
+		}
+	}
+	if _, err := Percentile(nil, 50, InterpolationLinear); !errors.Is(err, ErrEmptyInput) {
+		t.Errorf("Expected ErrEmptyInput, got %v", err)
+	}
+}
+
+func TestMovingAverages(t *testing.T) {
+	water := []float64{2, 4, 6, 8, 10}
+
+	got, err := MovingAverages(water, 3)
+	if err != nil || !slices.Equal(got, []float64{4, 6, 8}) {
+		t.Errorf("MovingAverages = %v, %v", got, err)
+	}
+	if got, _ := MovingAverages(water, 6); len(got) != 0 {
+		t.Errorf("Window longer than input: %v", got)
+	}
+	if _, err := MovingAverages(water, 0); !errors.Is(err, ErrInvalidWindow) {
+		t.Errorf("Expected ErrInvalidWindow, got %v", err)
+	}
+
+	ema, err := ExponentialMovingAverages(water, 0.5)
+	if err != nil || !slices.Equal(ema, []float64{2, 3, 4.5, 6.25, 8.125}) {
+		t.Errorf("ExponentialMovingAverages = %v, %v", ema, err)
+	}
+	if _, err := ExponentialMovingAverages(water, 0); !errors.Is(err, ErrInvalidSmoothing) {
+		t.Errorf("Expected ErrInvalidSmoothing, got %v", err)
+	}
+
+	m, _ := NewMovingAverage(2)
+	for i, expected := range []float64{2, 3, 5, 7, 9} {
+		if got := m.Add(water[i]); got != expected {
+			t.Errorf("Add(%v) = %v, want %v", water[i], got, expected)
+		}
+	}
+
+	// The average recovers once an infinity or NaN has left the window
+	for _, bad := range []float64{math.Inf(1), math.NaN()} {
+		m, _ := NewMovingAverage(2)
+		m.Add(1)
+		if got := m.Add(bad); !math.IsInf(got, 1) && !math.IsNaN(got) {
+			t.Errorf("Add(%v) = %v, want it to propagate", bad, got)
+		}
+		m.Add(3)
+		if got := m.Add(5); got != 4 {
+			t.Errorf("Expected 4 after %v left the window, got %v", bad, got)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	var s Summary
+	if _, err := s.Mean(); !errors.Is(err, ErrEmptyInput) {
+		t.Errorf("Expected ErrEmptyInput, got %v", err)
+	}
+
+	xs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	for _, x := range xs[:3] {
+		s.Add(x)
+	}
+	rest := Summarize(slices.Values(xs[3:]))
+	s.Merge(rest)
+
+	if s.Count() != 8 || s.Sum() != 40 {
+		t.Errorf("Count, Sum = %d, %v, want 8, 40", s.Count(), s.Sum())
+	}
+	if mean, _ := s.Mean(); mean != 5 {
+		t.Errorf("Mean = %v, want 5", mean)
+	}
+	if v, _ := s.PopulationVariance(); math.Abs(v-4) > 1e-12 {
+		t.Errorf("PopulationVariance = %v, want 4", v)
+	}
+	if lo, _ := s.Min(); lo != 2 {
+		t.Errorf("Min = %v, want 2", lo)
+	}
+	if hi, _ := s.Max(); hi != 9 {
+		t.Errorf("Max = %v, want 9", hi)
+	}
+
+	var empty Summary
+	empty.Merge(&s)
+	if empty.Count() != 8 {
+		t.Errorf("Merge into empty: count %d, want 8", empty.Count())
+	}
+}
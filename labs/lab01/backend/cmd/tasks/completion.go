@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// flagValues completes the values of flags that take one from a known set
+var flagValues = map[string]string{
+	"priority": "none low medium high",
+	"format":   "csv todo.txt markdown ical",
+	"sort":     "id title priority due created updated completed",
+}
+
+// idCommands take task IDs as arguments
+var idCommands = []string{"done", "edit", "rm"}
+
+// completion prints a completion script. Commands and flags are listed
+// from the command table, and task IDs come from "tasks __ids".
+//
+//	source <(tasks completion bash)
+//	tasks completion zsh > "${fpath[1]}/_tasks"
+//	tasks completion fish > ~/.config/fish/completions/tasks.fish
+func (c *cli) completion(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 1 {
+			return usageErrorf("completion: expected one of bash, zsh or fish")
+		}
+		switch args[0] {
+		case "bash":
+			fmt.Fprint(c.stdout, c.bashCompletion())
+		case "zsh":
+			// zsh runs the bash script through its compatibility layer
+			fmt.Fprint(c.stdout, "#compdef tasks\nautoload -U +X bashcompinit && bashcompinit\n"+c.bashCompletion())
+		case "fish":
+			fmt.Fprint(c.stdout, c.fishCompletion())
+		default:
+			return usageErrorf("completion: unknown shell %q, expected bash, zsh or fish", args[0])
+		}
+		return nil
+	}
+}
+
+// commandFlags returns the flags of a command
+func (c *cli) commandFlags(cmd command) []*flag.Flag {
+	fs := c.newFlagSet(cmd)
+	cmd.setup(&cli{}, fs)
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) { flags = append(flags, f) })
+	return flags
+}
+
+func (c *cli) bashCompletion() string {
+	var names, cases strings.Builder
+	for _, cmd := range commands {
+		if cmd.summary == "" {
+			continue
+		}
+		names.WriteString(cmd.name + " ")
+		var flags []string
+		for _, f := range c.commandFlags(cmd) {
+			if len(f.Name) == 1 {
+				flags = append(flags, "-"+f.Name)
+			} else {
+				flags = append(flags, "--"+f.Name)
+			}
+		}
+		fmt.Fprintf(&cases, "        %s) flags=%q ;;\n", cmd.name, strings.Join(flags, " "))
+	}
+	var values strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(flagValues)) {
+		fmt.Fprintf(&values, "        --%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", name, flagValues[name])
+	}
+
+	return `_tasks() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    local cmd="" flags i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            --file|-file) ((i++)) ;;
+            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    case "$prev" in
+        --file|-file|--output|-o) COMPREPLY=($(compgen -f -- "$cur")); return ;;
+        --parent) COMPREPLY=($(compgen -W "$(tasks __ids 2>/dev/null | cut -f1)" -- "$cur")); return ;;
+` + values.String() + `    esac
+
+    if [[ -z "$cmd" ]]; then
+        COMPREPLY=($(compgen -W "` + names.String() + `--file --json" -- "$cur"))
+        return
+    fi
+    case "$cmd" in
+` + cases.String() + `    esac
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+        return
+    fi
+    case "$cmd" in
+        ` + strings.Join(idCommands, "|") + `) COMPREPLY=($(compgen -W "$(tasks __ids 2>/dev/null | cut -f1)" -- "$cur")) ;;
+        completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+    esac
+}
+complete -F _tasks tasks
+`
+}
+
+func (c *cli) fishCompletion() string {
+	var b strings.Builder
+	b.WriteString("complete -c tasks -f\n")
+	b.WriteString("complete -c tasks -l file -r -F -d 'task file'\n")
+	b.WriteString("complete -c tasks -l json -d 'print JSON'\n")
+	for _, cmd := range commands {
+		if cmd.summary == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "complete -c tasks -n __fish_use_subcommand -a %s -d %s\n", cmd.name, fishQuote(cmd.summary))
+		for _, f := range c.commandFlags(cmd) {
+			if f.Name == "file" || f.Name == "json" {
+				continue
+			}
+			line := fmt.Sprintf("complete -c tasks -n '__fish_seen_subcommand_from %s' -l %s -d %s", cmd.name, f.Name, fishQuote(f.Usage))
+			if len(f.Name) == 1 {
+				line = fmt.Sprintf("complete -c tasks -n '__fish_seen_subcommand_from %s' -s %s -d %s", cmd.name, f.Name, fishQuote(f.Usage))
+			}
+			if values, ok := flagValues[f.Name]; ok {
+				line += " -x -a " + fishQuote(values)
+			} else if f.Name == "parent" {
+				line += " -x -a '(tasks __ids)'"
+			} else if !isBoolFlag(f) {
+				line += " -r"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	fmt.Fprintf(&b, "complete -c tasks -n '__fish_seen_subcommand_from %s' -a '(tasks __ids)'\n", strings.Join(idCommands, " "))
+	b.WriteString("complete -c tasks -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'\n")
+	return b.String()
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
@@ -0,0 +1,698 @@
+// Command tasks manages a task list kept in a JSON file.
+//
+//	go run ./cmd/tasks add "Buy milk" --priority high --due tomorrow --tag shop
+//	go run ./cmd/tasks list --tag shop
+//	go run ./cmd/tasks done 3
+//	go run ./cmd/tasks export --format ical -o tasks.ics
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"lab01/taskmanager"
+)
+
+// Exit codes, so scripts can tell a missing task from a rejected one
+const (
+	exitOK       = 0
+	exitFailure  = 1
+	exitUsage    = 2
+	exitNotFound = 3
+	exitInvalid  = 4
+)
+
+const usageHeader = `Usage: tasks [--file PATH] [--json] COMMAND [ARGS] [FLAGS]
+
+Tasks are kept in --file, by default $TASKS_FILE or ~/.tasks.json.
+Run "tasks COMMAND -h" for the flags of a command.
+
+Commands:`
+
+const usageFooter = `
+Exit codes:
+  0  success
+  1  failure, such as an unreadable task file
+  2  invalid command line
+  3  task not found
+  4  invalid task, such as an empty title, a bad date or a dependency cycle`
+
+// command is a subcommand. setup defines its flags on fs and returns the
+// function that runs it with the remaining arguments.
+type command struct {
+	name    string
+	args    string
+	summary string
+	setup   func(c *cli, fs *flag.FlagSet) func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"add", "TITLE", "add a task", (*cli).add},
+		{"list", "", "list tasks, open ones unless --all or --done", (*cli).list},
+		{"done", "ID...", "complete tasks, adding the next occurrence of recurring ones", (*cli).done},
+		{"edit", "ID", "change the fields given as flags", (*cli).edit},
+		{"rm", "ID...", "delete tasks", (*cli).rm},
+		{"export", "", "write all tasks as CSV, todo.txt, Markdown or iCalendar", (*cli).export},
+		{"completion", "bash|zsh|fish", "print a shell completion script", (*cli).completion},
+		// __ids lists task IDs and titles for the completion scripts
+		{"__ids", "", "", (*cli).ids},
+	}
+}
+
+type cli struct {
+	stdout, stderr io.Writer
+	file           string
+	json           bool
+}
+
+// errInvalidDate is a due date parseDue cannot read; like a bad priority,
+// it is an invalid field value rather than a mistake on the command line
+var errInvalidDate = errors.New("invalid date")
+
+// usageError is a mistake on the command line
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	c := &cli{stdout: stdout, stderr: stderr, file: defaultFile()}
+
+	global := flag.NewFlagSet("tasks", flag.ContinueOnError)
+	global.SetOutput(io.Discard)
+	c.commonFlags(global)
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			c.usage(stdout)
+			return exitOK
+		}
+		return c.fail(usageErrorf("%v", err))
+	}
+	args = global.Args()
+	if len(args) == 0 {
+		c.usage(stderr)
+		return exitUsage
+	}
+	if args[0] == "help" {
+		c.usage(stdout)
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		fs := c.newFlagSet(cmd)
+		runCmd := cmd.setup(c, fs)
+		positional, err := parseInterspersed(fs, args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, cmd, fs)
+			return exitOK
+		}
+		if err != nil {
+			return c.fail(usageErrorf("%s: %v", cmd.name, err))
+		}
+		return c.fail(runCmd(positional))
+	}
+	return c.fail(usageErrorf("unknown command %q, run \"tasks help\" for the list", args[0]))
+}
+
+// defaultFile is $TASKS_FILE, or .tasks.json in the home directory
+func defaultFile() string {
+	if path := os.Getenv("TASKS_FILE"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".tasks.json"
+	}
+	return filepath.Join(home, ".tasks.json")
+}
+
+// commonFlags defines the flags every command accepts, before or after
+// its name
+func (c *cli) commonFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.file, "file", c.file, "task file")
+	fs.BoolVar(&c.json, "json", c.json, "print JSON, for scripts")
+}
+
+func (c *cli) newFlagSet(cmd command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c.commonFlags(fs)
+	return fs
+}
+
+// parseInterspersed parses flags wherever they appear among the
+// positional arguments, which the flag package alone stops at. Everything
+// after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		args = rest
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (c *cli) usage(w io.Writer) {
+	fmt.Fprintln(w, usageHeader)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		if cmd.summary != "" {
+			fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.summary)
+		}
+	}
+	tw.Flush()
+	fmt.Fprintln(w, usageFooter)
+}
+
+func printCommandHelp(w io.Writer, cmd command, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: tasks %s %s [FLAGS]\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// fail reports err, as JSON with --json, and returns the exit code for it
+func (c *cli) fail(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	code := exitCode(err)
+	if c.json {
+		json.NewEncoder(c.stderr).Encode(map[string]any{"error": err.Error(), "exit_code": code})
+	} else {
+		fmt.Fprintf(c.stderr, "tasks: %v\n", err)
+	}
+	return code
+}
+
+func exitCode(err error) int {
+	var usage *usageError
+	switch {
+	case errors.As(err, &usage), errors.Is(err, taskmanager.ErrUnknownFormat):
+		return exitUsage
+	case errors.Is(err, taskmanager.ErrTaskNotFound):
+		return exitNotFound
+	case errors.Is(err, taskmanager.ErrEmptyTitle),
+		errors.Is(err, taskmanager.ErrInvalidPriority),
+		errors.Is(err, errInvalidDate),
+		errors.Is(err, taskmanager.ErrInvalidRecurrence),
+		errors.Is(err, taskmanager.ErrCycle),
+		errors.Is(err, taskmanager.ErrOpenSubtasks),
+		errors.Is(err, taskmanager.ErrParentDone),
+		errors.Is(err, taskmanager.ErrHasSubtasks),
+		errors.Is(err, taskmanager.ErrHasDependents):
+		return exitInvalid
+	}
+	return exitFailure
+}
+
+func (c *cli) open() (*taskmanager.TaskManager, error) {
+	return taskmanager.OpenTaskManager(taskmanager.NewFileStore(c.file))
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c *cli) add(fs *flag.FlagSet) func(args []string) error {
+	description := fs.String("desc", "", "description")
+	priority := fs.String("priority", "", "priority: none, low, medium or high")
+	due := fs.String("due", "", "due date: YYYY-MM-DD, \"YYYY-MM-DD HH:MM\", today or tomorrow")
+	var tags listFlag
+	fs.Var(&tags, "tag", "tag; repeat or separate with commas for several")
+	parent := fs.Int("parent", 0, "ID of the parent task")
+	repeat := fs.String("repeat", "", "recurrence rule such as FREQ=WEEKLY;BYDAY=MO")
+
+	return func(args []string) error {
+		// Quoting a title is optional
+		title := strings.Join(args, " ")
+		var opts []taskmanager.TaskOption
+		if *priority != "" {
+			p, err := taskmanager.ParsePriority(*priority)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, taskmanager.WithPriority(p))
+		}
+		if *due != "" {
+			d, err := parseDue(*due, time.Now())
+			if err != nil {
+				return err
+			}
+			opts = append(opts, taskmanager.WithDue(d))
+		}
+		if len(tags) > 0 {
+			opts = append(opts, taskmanager.WithTags(tags...))
+		}
+		if *parent != 0 {
+			opts = append(opts, taskmanager.WithParent(*parent))
+		}
+		if *repeat != "" {
+			opts = append(opts, taskmanager.WithRecurrence(*repeat))
+		}
+
+		tm, err := c.open()
+		if err != nil {
+			return err
+		}
+		task, err := tm.AddTask(title, *description, opts...)
+		if err != nil {
+			return err
+		}
+		if c.json {
+			return c.printJSON(task)
+		}
+		fmt.Fprintf(c.stdout, "✅ Added %d: %s\n", task.ID, task.Title)
+		return nil
+	}
+}
+
+func (c *cli) list(fs *flag.FlagSet) func(args []string) error {
+	all := fs.Bool("all", false, "list open and completed tasks")
+	done := fs.Bool("done", false, "list completed tasks only")
+	var tags listFlag
+	fs.Var(&tags, "tag", "keep tasks with this tag; repeat to require several")
+	priority := fs.String("priority", "", "keep tasks of at least this priority")
+	overdue := fs.Bool("overdue", false, "keep open tasks past their due date")
+	dueBefore := fs.String("due-before", "", "keep tasks due before this date")
+	search := fs.String("search", "", "keep tasks with this text in the title or description")
+	sortBy := fs.String("sort", "", "sort on id, title, priority, due, created, updated or completed; a leading - reverses, commas separate keys")
+	limit := fs.Int("limit", 0, "show at most this many tasks")
+	offset := fs.Int("offset", 0, "skip this many tasks")
+
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageErrorf("list: unexpected argument %q", args[0])
+		}
+		q := taskmanager.Query{Tags: tags, Overdue: *overdue, Text: *search, Limit: *limit, Offset: *offset}
+		switch {
+		case *done:
+			q.Done = ptr(true)
+		case !*all:
+			q.Done = ptr(false)
+		}
+		if *priority != "" {
+			p, err := taskmanager.ParsePriority(*priority)
+			if err != nil {
+				return err
+			}
+			q.MinPriority = p
+		}
+		if *dueBefore != "" {
+			d, err := parseDue(*dueBefore, time.Now())
+			if err != nil {
+				return err
+			}
+			q.DueBefore = d
+		}
+		if *sortBy != "" {
+			keys, err := parseSort(*sortBy)
+			if err != nil {
+				return err
+			}
+			q.SortBy = keys
+		}
+
+		tm, err := c.open()
+		if err != nil {
+			return err
+		}
+		tasks := tm.ListTasks(q)
+		if c.json {
+			if tasks == nil {
+				tasks = []*taskmanager.Task{}
+			}
+			return c.printJSON(tasks)
+		}
+		if len(tasks) == 0 {
+			fmt.Fprintln(c.stdout, "No tasks")
+			return nil
+		}
+		now := time.Now()
+		w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tDONE\tPRIORITY\tDUE\tTITLE\tTAGS")
+		for _, t := range tasks {
+			status := ""
+			if t.Done {
+				status = "x"
+			} else if t.Overdue(now) {
+				status = "!"
+			}
+			priority := ""
+			if t.Priority != taskmanager.PriorityNone {
+				priority = t.Priority.String()
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", t.ID, status, priority, formatDue(t.Due), t.Title, strings.Join(t.Tags, ","))
+		}
+		return w.Flush()
+	}
+}
+
+func (c *cli) done(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		ids, err := parseIDs("done", args)
+		if err != nil {
+			return err
+		}
+		tm, err := c.open()
+		if err != nil {
+			return err
+		}
+		type result struct {
+			Completed *taskmanager.Task `json:"completed"`
+			Next      *taskmanager.Task `json:"next,omitempty"`
+		}
+		var results []result
+		for _, id := range ids {
+			completed, next, err := tm.CompleteTask(id)
+			if err != nil {
+				// Report the tasks already completed so scripts see what changed
+				if c.json && len(results) > 0 {
+					c.printJSON(results)
+				}
+				return fmt.Errorf("task %d: %w", id, err)
+			}
+			results = append(results, result{completed, next})
+			if c.json {
+				continue
+			}
+			fmt.Fprintf(c.stdout, "✅ Completed %d: %s\n", completed.ID, completed.Title)
+			if next != nil {
+				fmt.Fprintf(c.stdout, "🔁 Next is %d, due %s\n", next.ID, formatDue(next.Due))
+			}
+		}
+		if c.json {
+			return c.printJSON(results)
+		}
+		return nil
+	}
+}
+
+func (c *cli) edit(fs *flag.FlagSet) func(args []string) error {
+	title := fs.String("title", "", "new title")
+	description := fs.String("desc", "", "new description")
+	priority := fs.String("priority", "", "new priority: none, low, medium or high")
+	due := fs.String("due", "", "new due date")
+	noDue := fs.Bool("no-due", false, "remove the due date")
+	var tags listFlag
+	fs.Var(&tags, "tag", "replace the tags; pass an empty value to remove them all")
+	parent := fs.Int("parent", 0, "move under this task; 0 makes the task top-level")
+	repeat := fs.String("repeat", "", "new recurrence rule; empty stops the recurrence")
+	reopen := fs.Bool("reopen", false, "mark a completed task open again")
+
+	return func(args []string) error {
+		ids, err := parseIDs("edit", args)
+		if err != nil {
+			return err
+		}
+		if len(ids) != 1 {
+			return usageErrorf("edit: expected one ID, got %d", len(ids))
+		}
+
+		var u taskmanager.TaskUpdate
+		var parseErr error
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "title":
+				u.Title = title
+			case "desc":
+				u.Description = description
+			case "priority":
+				p, err := taskmanager.ParsePriority(*priority)
+				if err != nil {
+					parseErr = err
+				}
+				u.Priority = &p
+			case "due":
+				d, err := parseDue(*due, time.Now())
+				if err != nil {
+					parseErr = err
+				}
+				u.Due = &d
+			case "no-due":
+				u.ClearDue = *noDue
+			case "tag":
+				u.Tags = ptr([]string(tags))
+			case "parent":
+				u.ParentID = parent
+			case "repeat":
+				u.Recurrence = repeat
+			case "reopen":
+				if *reopen {
+					u.Done = ptr(false)
+				}
+			}
+		})
+		if parseErr != nil {
+			return parseErr
+		}
+		if u == (taskmanager.TaskUpdate{}) {
+			return usageErrorf("edit: nothing to change, see \"tasks edit -h\"")
+		}
+
+		tm, err := c.open()
+		if err != nil {
+			return err
+		}
+		task, err := tm.UpdateTask(ids[0], u)
+		if err != nil {
+			return err
+		}
+		if c.json {
+			return c.printJSON(task)
+		}
+		fmt.Fprintf(c.stdout, "✅ Updated %d: %s\n", task.ID, task.Title)
+		return nil
+	}
+}
+
+func (c *cli) rm(fs *flag.FlagSet) func(args []string) error {
+	cascade := fs.Bool("cascade", false, "also delete subtasks, and drop the task from the tasks it blocks")
+
+	return func(args []string) error {
+		ids, err := parseIDs("rm", args)
+		if err != nil {
+			return err
+		}
+		policy := taskmanager.DeleteRefuse
+		if *cascade {
+			policy = taskmanager.DeleteCascade
+		}
+		tm, err := c.open()
+		if err != nil {
+			return err
+		}
+		deleted := []int{}
+		for _, id := range ids {
+			if err := tm.DeleteTaskWithPolicy(id, policy); err != nil {
+				// Report the tasks already deleted so scripts see what changed
+				if c.json && len(deleted) > 0 {
+					c.printJSON(map[string][]int{"deleted": deleted})
+				}
+				return fmt.Errorf("task %d: %w", id, err)
+			}
+			deleted = append(deleted, id)
+			if !c.json {
+				fmt.Fprintf(c.stdout, "🗑️  Deleted %d\n", id)
+			}
+		}
+		if c.json {
+			return c.printJSON(map[string][]int{"deleted": deleted})
+		}
+		return nil
+	}
+}
+
+func (c *cli) export(fs *flag.FlagSet) func(args []string) error {
+	format := fs.String("format", "", "csv, todo.txt, markdown or ical; by default from the --output extension, else csv")
+	output := fs.String("output", "", "file to write instead of standard output")
+	fs.StringVar(output, "o", "", "shorthand for --output")
+
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageErrorf("export: unexpected argument %q", args[0])
+		}
+		f := taskmanager.FormatCSV
+		var err error
+		switch {
+		case *format != "":
+			f, err = taskmanager.ParseFormat(*format)
+		case *output != "":
+			if ext := filepath.Ext(*output); ext != "" {
+				f, err = taskmanager.ParseFormat(ext)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		tm, err := c.open()
+		if err != nil {
+			return err
+		}
+		tasks := tm.ListTasks(taskmanager.Query{})
+		if *output == "" {
+			return taskmanager.Export(c.stdout, tasks, f)
+		}
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		if err := taskmanager.Export(file, tasks, f); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		if c.json {
+			return c.printJSON(map[string]any{"file": *output, "format": f, "tasks": len(tasks)})
+		}
+		fmt.Fprintf(c.stdout, "✅ Exported %d tasks to %s\n", len(tasks), *output)
+		return nil
+	}
+}
+
+func (c *cli) ids(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		tm, err := c.open()
+		if err != nil {
+			return err
+		}
+		for _, t := range tm.ListTasks(taskmanager.Query{}) {
+			fmt.Fprintf(c.stdout, "%d\t%s\n", t.ID, t.Title)
+		}
+		return nil
+	}
+}
+
+// listFlag collects a flag given several times or with comma-separated
+// values
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+func parseIDs(name string, args []string) ([]int, error) {
+	if len(args) == 0 {
+		return nil, usageErrorf("%s: expected a task ID", name)
+	}
+	ids := make([]int, len(args))
+	for i, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id <= 0 {
+			return nil, usageErrorf("%s: invalid task ID %q", name, arg)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// parseDue reads a date, a local date and time, an RFC 3339 time, today
+// or tomorrow
+func parseDue(s string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch strings.ToLower(s) {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{time.DateOnly, "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w %q, expected YYYY-MM-DD or \"YYYY-MM-DD HH:MM\"", errInvalidDate, s)
+}
+
+// formatDue shows the date, and the time unless it is midnight
+func formatDue(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	local := due.Local()
+	if local.Hour() == 0 && local.Minute() == 0 {
+		return local.Format(time.DateOnly)
+	}
+	return local.Format("2006-01-02 15:04")
+}
+
+var sortFields = map[string]taskmanager.SortField{
+	"id":        taskmanager.SortByID,
+	"title":     taskmanager.SortByTitle,
+	"priority":  taskmanager.SortByPriority,
+	"due":       taskmanager.SortByDue,
+	"created":   taskmanager.SortByCreated,
+	"updated":   taskmanager.SortByUpdated,
+	"completed": taskmanager.SortByCompleted,
+}
+
+// parseSort reads sort keys such as "due,-priority"
+func parseSort(s string) ([]taskmanager.SortKey, error) {
+	var keys []taskmanager.SortKey
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		descending := strings.HasPrefix(name, "-")
+		field, ok := sortFields[strings.ToLower(strings.TrimPrefix(name, "-"))]
+		if !ok {
+			return nil, usageErrorf("unknown sort field %q", name)
+		}
+		keys = append(keys, taskmanager.SortKey{Field: field, Descending: descending})
+	}
+	return keys, nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
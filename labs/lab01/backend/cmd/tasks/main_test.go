@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"lab01/taskmanager"
+)
+
+// runTasks runs the command against a task file and returns the exit code
+// and output
+func runTasks(t *testing.T, file string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"--file", file}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func mustRun(t *testing.T, file string, args ...string) string {
+	t.Helper()
+	code, stdout, stderr := runTasks(t, file, args...)
+	if code != exitOK {
+		t.Fatalf("tasks %v exited %d: %s", args, code, stderr)
+	}
+	return stdout
+}
+
+func TestExitCodes(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.json")
+	mustRun(t, file, "add", "Run a 10K")
+	mustRun(t, file, "add", "Buy shoes", "--parent", "1")
+	mustRun(t, file, "add", "Run a 5K")
+	mustRun(t, file, "done", "3")
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"empty title", []string{"add", ""}, exitInvalid},
+		{"bad priority", []string{"add", "A", "--priority", "bogus"}, exitInvalid},
+		{"bad date", []string{"add", "A", "--due", "bogus"}, exitInvalid},
+		{"bad recurrence", []string{"add", "A", "--repeat", "FREQ=HOURLY"}, exitInvalid},
+		{"open subtasks", []string{"done", "1"}, exitInvalid},
+		{"has subtasks", []string{"rm", "1"}, exitInvalid},
+		{"done parent", []string{"add", "A", "--parent", "3"}, exitInvalid},
+		{"done missing", []string{"done", "99"}, exitNotFound},
+		{"edit missing", []string{"edit", "99", "--title", "X"}, exitNotFound},
+		{"rm missing", []string{"rm", "99"}, exitNotFound},
+		{"missing parent", []string{"add", "A", "--parent", "99"}, exitNotFound},
+		{"unknown command", []string{"frob"}, exitUsage},
+		{"unknown flag", []string{"list", "--bogus"}, exitUsage},
+		{"bad ID", []string{"done", "one"}, exitUsage},
+		{"nothing to edit", []string{"edit", "1"}, exitUsage},
+		{"unknown format", []string{"export", "--format", "xlsx"}, exitUsage},
+		{"no command", nil, exitUsage},
+		{"help", []string{"help"}, exitOK},
+		{"command help", []string{"add", "-h"}, exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runTasks(t, file, tt.args...)
+			if code != tt.code {
+				t.Errorf("Expected exit code %d, got %d: %s", tt.code, code, stderr)
+			}
+		})
+	}
+}
+
+func TestCommands(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.json")
+
+	if out := mustRun(t, file, "add", "Buy", "milk", "--priority", "high", "--tag", "shop,home", "--due", "2025-07-05"); out != "✅ Added 1: Buy milk\n" {
+		t.Errorf("Unexpected add output %q", out)
+	}
+	mustRun(t, file, "add", "Stretch", "--repeat", "FREQ=DAILY", "--due", "2099-07-01")
+
+	out := mustRun(t, file, "list")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "Buy milk") || !strings.Contains(lines[1], "home,shop") {
+		t.Errorf("Unexpected list output:\n%s", out)
+	}
+
+	if out := mustRun(t, file, "done", "2"); !strings.Contains(out, "Completed 2: Stretch") || !strings.Contains(out, "Next is 3, due 2099-07-02") {
+		t.Errorf("Unexpected done output %q", out)
+	}
+	if out := mustRun(t, file, "list", "--done"); !strings.Contains(out, "Stretch") || strings.Contains(out, "Buy milk") {
+		t.Errorf("Expected only the completed task, got:\n%s", out)
+	}
+
+	if out := mustRun(t, file, "edit", "1", "--title", "Buy oat milk", "--no-due", "--tag", ""); out != "✅ Updated 1: Buy oat milk\n" {
+		t.Errorf("Unexpected edit output %q", out)
+	}
+	if out := mustRun(t, file, "list", "--tag", "shop"); out != "No tasks\n" {
+		t.Errorf("Expected the tags removed, got %q", out)
+	}
+
+	if out := mustRun(t, file, "add", "--priority", "low", "--", "Read", "-h", "--json"); out != "✅ Added 4: Read -h --json\n" {
+		t.Errorf("Expected everything after -- in the title, got %q", out)
+	}
+
+	if out := mustRun(t, file, "rm", "1", "3"); out != "🗑️  Deleted 1\n🗑️  Deleted 3\n" {
+		t.Errorf("Unexpected rm output %q", out)
+	}
+	if out := mustRun(t, file, "list", "--all"); strings.Contains(out, "Buy oat milk") {
+		t.Errorf("Expected task 1 deleted, got:\n%s", out)
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.json")
+
+	if out := mustRun(t, file, "list", "--json"); strings.TrimSpace(out) != "[]" {
+		t.Errorf("Expected an empty JSON list, got %q", out)
+	}
+
+	var task taskmanager.Task
+	if err := json.Unmarshal([]byte(mustRun(t, file, "--json", "add", "Stretch", "--repeat", "FREQ=DAILY")), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.ID != 1 || task.Title != "Stretch" || task.Recurrence != "FREQ=DAILY" {
+		t.Errorf("Unexpected task %+v", task)
+	}
+
+	var done []struct {
+		Completed *taskmanager.Task `json:"completed"`
+		Next      *taskmanager.Task `json:"next"`
+	}
+	if err := json.Unmarshal([]byte(mustRun(t, file, "done", "1", "--json")), &done); err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || !done[0].Completed.Done || done[0].Next == nil || done[0].Next.ID != 2 {
+		t.Errorf("Unexpected done output %+v", done)
+	}
+
+	var tasks []*taskmanager.Task
+	if err := json.Unmarshal([]byte(mustRun(t, file, "list", "--all", "--json")), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Expected 2 tasks, got %d", len(tasks))
+	}
+
+	var edited taskmanager.Task
+	if err := json.Unmarshal([]byte(mustRun(t, file, "edit", "2", "--priority", "low", "--json")), &edited); err != nil {
+		t.Fatal(err)
+	}
+	if edited.Priority != taskmanager.PriorityLow {
+		t.Errorf("Expected low priority, got %v", edited.Priority)
+	}
+
+	var deleted map[string][]int
+	if err := json.Unmarshal([]byte(mustRun(t, file, "rm", "2", "--json")), &deleted); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(deleted["deleted"], []int{2}) {
+		t.Errorf("Unexpected rm output %v", deleted)
+	}
+
+	mustRun(t, file, "add", "Read")
+	mustRun(t, file, "add", "Write")
+	code, stdout, _ := runTasks(t, file, "--json", "done", "3", "99")
+	if code != exitNotFound {
+		t.Errorf("Expected exit code %d, got %d", exitNotFound, code)
+	}
+	done = nil
+	if err := json.Unmarshal([]byte(stdout), &done); err != nil {
+		t.Fatalf("Expected the completed tasks on stdout, got %q: %v", stdout, err)
+	}
+	if len(done) != 1 || done[0].Completed.ID != 3 {
+		t.Errorf("Unexpected partial done output %+v", done)
+	}
+	code, stdout, _ = runTasks(t, file, "--json", "rm", "4", "99")
+	if code != exitNotFound {
+		t.Errorf("Expected exit code %d, got %d", exitNotFound, code)
+	}
+	deleted = nil
+	if err := json.Unmarshal([]byte(stdout), &deleted); err != nil {
+		t.Fatalf("Expected the deleted tasks on stdout, got %q: %v", stdout, err)
+	}
+	if !slices.Equal(deleted["deleted"], []int{4}) {
+		t.Errorf("Unexpected partial rm output %v", deleted)
+	}
+
+	code, _, stderr := runTasks(t, file, "--json", "done", "99")
+	var failure struct {
+		Error    string `json:"error"`
+		ExitCode int    `json:"exit_code"`
+	}
+	if err := json.Unmarshal([]byte(stderr), &failure); err != nil {
+		t.Fatalf("Expected a JSON error, got %q: %v", stderr, err)
+	}
+	if code != exitNotFound || failure.ExitCode != exitNotFound || !strings.Contains(failure.Error, "not found") {
+		t.Errorf("Unexpected error %d %+v", code, failure)
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.json")
+	mustRun(t, file, "add", "Buy milk", "--tag", "shop")
+
+	if out := mustRun(t, file, "export"); !strings.HasPrefix(out, "id,title,") || !strings.Contains(out, "Buy milk") {
+		t.Errorf("Expected CSV by default, got:\n%s", out)
+	}
+	if out := mustRun(t, file, "export", "--format", "todo.txt"); !strings.Contains(out, "Buy milk +shop id:1") {
+		t.Errorf("Unexpected todo.txt export %q", out)
+	}
+
+	path := filepath.Join(dir, "tasks.ics")
+	if out := mustRun(t, file, "export", "-o", path); out != "✅ Exported 1 tasks to "+path+"\n" {
+		t.Errorf("Unexpected export output %q", out)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "BEGIN:VCALENDAR") || !strings.Contains(string(data), "SUMMARY:Buy milk") {
+		t.Errorf("Expected iCalendar from the extension, got:\n%s", data)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.json")
+	for shell, want := range map[string]string{
+		"bash": "complete -F _tasks tasks",
+		"zsh":  "#compdef tasks",
+		"fish": "complete -c tasks -n __fish_use_subcommand -a add",
+	} {
+		out := mustRun(t, file, "completion", shell)
+		if !strings.Contains(out, want) || !strings.Contains(out, "--priority") && !strings.Contains(out, "-l priority") {
+			t.Errorf("Unexpected %s completion:\n%s", shell, out)
+		}
+	}
+	if code, _, _ := runTasks(t, file, "completion", "tcsh"); code != exitUsage {
+		t.Errorf("Expected exit code %d for an unknown shell, got %d", exitUsage, code)
+	}
+
+	mustRun(t, file, "add", "Buy milk")
+	if out := mustRun(t, file, "__ids"); out != "1\tBuy milk\n" {
+		t.Errorf("Unexpected __ids output %q", out)
+	}
+}
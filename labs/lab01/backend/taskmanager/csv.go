@@ -0,0 +1,130 @@
+package taskmanager
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var csvHeader = []string{
+	"id", "title", "description", "done", "priority", "due", "tags", "recurrence",
+	"parent_id", "blocked_by", "created_at", "updated_at", "completed_at",
+}
+
+func writeCSV(w io.Writer, tasks []*Task) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, t := range tasks {
+		parent := ""
+		if t.ParentID != 0 {
+			parent = strconv.Itoa(t.ParentID)
+		}
+		priority := ""
+		if t.Priority != PriorityNone {
+			priority = t.Priority.String()
+		}
+		cw.Write([]string{
+			strconv.Itoa(t.ID), t.Title, t.Description, strconv.FormatBool(t.Done), priority,
+			formatOptionalTime(t.Due), strings.Join(t.Tags, ";"), t.Recurrence,
+			parent, formatIDs(t.BlockedBy), formatOptionalTime(&t.CreatedAt),
+			formatOptionalTime(&t.UpdatedAt), formatOptionalTime(t.CompletedAt),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readCSV reads a CSV file with a header row naming the columns, in any
+// order; only title is required
+func readCSV(r io.Reader) ([]*record, []*LineError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, []*LineError{{Line: 1, Err: err}}, nil
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, []*LineError{{Line: 1, Err: errors.New("missing title column")}}, nil
+	}
+
+	var records []*record
+	var errs []*LineError
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, errs, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			errs = append(errs, &LineError{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("taskmanager: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		rec, err := csvRecord(row, columns)
+		if err != nil {
+			errs = append(errs, &LineError{Line: line, Err: err})
+			continue
+		}
+		rec.line = line
+		records = append(records, rec)
+	}
+}
+
+func csvRecord(row []string, columns map[string]int) (*record, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	t := &Task{Title: field("title"), Description: field("description"), Recurrence: field("recurrence")}
+	rec := &record{ref: field("id"), task: t, parent: field("parent_id"), blockedBy: splitList(field("blocked_by"))}
+	t.Tags = splitList(field("tags"))
+
+	var err error
+	if done := field("done"); done != "" {
+		if t.Done, err = strconv.ParseBool(done); err != nil {
+			return nil, fmt.Errorf("invalid done %q", done)
+		}
+	}
+	if priority := field("priority"); priority != "" {
+		if t.Priority, err = ParsePriority(priority); err != nil {
+			return nil, err
+		}
+	}
+	if t.Due, err = parseOptionalTime(field("due")); err != nil {
+		return nil, err
+	}
+	if t.CompletedAt, err = parseOptionalTime(field("completed_at")); err != nil {
+		return nil, err
+	}
+	created, err := parseOptionalTime(field("created_at"))
+	if err != nil {
+		return nil, err
+	}
+	if created != nil {
+		t.CreatedAt = *created
+	}
+	updated, err := parseOptionalTime(field("updated_at"))
+	if err != nil {
+		return nil, err
+	}
+	if updated != nil {
+		t.UpdatedAt = *updated
+	}
+	return rec, nil
+}
@@ -0,0 +1,333 @@
+package taskmanager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCycle         = errors.New("dependency cycle")
+	ErrOpenSubtasks  = errors.New("task has open subtasks")
+	ErrParentDone    = errors.New("parent task is done")
+	ErrHasSubtasks   = errors.New("task has subtasks")
+	ErrHasDependents = errors.New("task blocks other tasks")
+)
+
+// CycleError is returned for a change that would make tasks wait for each
+// other. Path lists the cycle, each task waiting for the next, and ends
+// where it starts.
+type CycleError struct {
+	Path []int
+}
+
+func (e *CycleError) Error() string {
+	ids := make([]string, len(e.Path))
+	for i, id := range e.Path {
+		ids[i] = strconv.Itoa(id)
+	}
+	return "dependency cycle: " + strings.Join(ids, " -> ")
+}
+
+func (e *CycleError) Unwrap() error {
+	return ErrCycle
+}
+
+// DeletePolicy chooses what DeleteTaskWithPolicy does with the subtasks
+// and dependents of a task
+type DeletePolicy int
+
+const (
+	// DeleteRefuse fails with ErrHasSubtasks or ErrHasDependents
+	DeleteRefuse DeletePolicy = iota
+	// DeleteCascade deletes all subtasks, recursively, and unblocks the
+	// tasks that were blocked by a deleted task
+	DeleteCascade
+)
+
+// AddDependency records that task id cannot start before blockerID is
+// done. It returns a *CycleError if blockerID already waits for id,
+// directly, through other dependencies or through subtasks.
+func (tm *TaskManager) AddDependency(id, blockerID int) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, ok := tm.tasks[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	if _, ok := tm.tasks[blockerID]; !ok {
+		return fmt.Errorf("blocker %d: %w", blockerID, ErrTaskNotFound)
+	}
+	if slices.Contains(task.BlockedBy, blockerID) {
+		return nil
+	}
+	if path := findPath(prerequisites(tm.tasks), blockerID, id); path != nil {
+		return &CycleError{Path: append([]int{id}, path...)}
+	}
+
+	updated := task.clone()
+	updated.BlockedBy = append(updated.BlockedBy, blockerID)
+	slices.Sort(updated.BlockedBy)
+	updated.UpdatedAt = time.Now()
+	return tm.commit(EventUpdate, tm.nextID, []*Task{updated}, nil)
+}
+
+// RemoveDependency removes the dependency of task id on blockerID, if any
+func (tm *TaskManager) RemoveDependency(id, blockerID int) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	task, ok := tm.tasks[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	if !slices.Contains(task.BlockedBy, blockerID) {
+		return nil
+	}
+	updated := task.clone()
+	updated.BlockedBy = slices.DeleteFunc(updated.BlockedBy, func(b int) bool { return b == blockerID })
+	updated.UpdatedAt = time.Now()
+	return tm.commit(EventUpdate, tm.nextID, []*Task{updated}, nil)
+}
+
+// Subtasks returns the direct subtasks of a task by ID
+func (tm *TaskManager) Subtasks(id int) ([]*Task, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	if _, ok := tm.tasks[id]; !ok {
+		return nil, ErrTaskNotFound
+	}
+	var result []*Task
+	for _, childID := range tm.children(id) {
+		result = append(result, tm.tasks[childID].clone())
+	}
+	return result, nil
+}
+
+// TopologicalOrder returns all tasks so that every task comes after the
+// tasks blocking it and after its subtasks; ties are ordered by ID
+func (tm *TaskManager) TopologicalOrder() ([]*Task, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	order, err := topologicalOrder(tm.tasks)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Task, len(order))
+	for i, id := range order {
+		result[i] = tm.tasks[id].clone()
+	}
+	return result, nil
+}
+
+// ReadyTasks returns the open tasks whose blockers and subtasks are all
+// done, by ID
+func (tm *TaskManager) ReadyTasks() []*Task {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	prereqs := prerequisites(tm.tasks)
+	var result []*Task
+	for _, task := range tm.tasks {
+		if task.Done {
+			continue
+		}
+		ready := true
+		for _, id := range prereqs[task.ID] {
+			if !tm.tasks[id].Done {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			result = append(result, task.clone())
+		}
+	}
+	slices.SortFunc(result, func(a, b *Task) int { return a.ID - b.ID })
+	return result
+}
+
+// DeleteTaskWithPolicy deletes a task, handling its subtasks and the tasks
+// it blocks according to policy
+func (tm *TaskManager) DeleteTaskWithPolicy(id int, policy DeletePolicy) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if _, ok := tm.tasks[id]; !ok {
+		return ErrTaskNotFound
+	}
+
+	if policy == DeleteRefuse {
+		if children := tm.children(id); len(children) > 0 {
+			return fmt.Errorf("%w: %v", ErrHasSubtasks, children)
+		}
+		if dependents := tm.dependents(id); len(dependents) > 0 {
+			return fmt.Errorf("%w: %v", ErrHasDependents, dependents)
+		}
+		return tm.commit(EventDelete, tm.nextID, nil, []int{id})
+	}
+
+	del := []int{id}
+	for i := 0; i < len(del); i++ {
+		del = append(del, tm.children(del[i])...)
+	}
+	now := time.Now()
+	var put []*Task
+	for _, task := range tm.tasks {
+		if slices.Contains(del, task.ID) || !slices.ContainsFunc(task.BlockedBy, func(b int) bool { return slices.Contains(del, b) }) {
+			continue
+		}
+		updated := task.clone()
+		updated.BlockedBy = slices.DeleteFunc(updated.BlockedBy, func(b int) bool { return slices.Contains(del, b) })
+		updated.UpdatedAt = now
+		put = append(put, updated)
+	}
+	return tm.commit(EventDelete, tm.nextID, put, del)
+}
+
+// checkParent checks that task id can become a subtask of parentID; the
+// caller holds tm.mu
+func (tm *TaskManager) checkParent(id, parentID int) error {
+	if parentID == 0 {
+		return nil
+	}
+	if _, ok := tm.tasks[parentID]; !ok {
+		return fmt.Errorf("parent %d: %w", parentID, ErrTaskNotFound)
+	}
+	// The parent will wait for the task, so the task must not wait for it
+	if path := findPath(prerequisites(tm.tasks), id, parentID); path != nil {
+		return &CycleError{Path: append([]int{parentID}, path...)}
+	}
+	return nil
+}
+
+// children returns the IDs of the direct subtasks of id, sorted; the caller
+// holds tm.mu
+func (tm *TaskManager) children(id int) []int {
+	var result []int
+	for _, task := range tm.tasks {
+		if task.ParentID == id {
+			result = append(result, task.ID)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// openSubtasks returns the IDs of the subtasks of id that are not done
+func (tm *TaskManager) openSubtasks(id int) []int {
+	return slices.DeleteFunc(tm.children(id), func(child int) bool { return tm.tasks[child].Done })
+}
+
+// dependents returns the IDs of the tasks blocked by id, sorted
+func (tm *TaskManager) dependents(id int) []int {
+	var result []int
+	for _, task := range tm.tasks {
+		if slices.Contains(task.BlockedBy, id) {
+			result = append(result, task.ID)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// prerequisites maps each task to the tasks it waits for: its blockers
+// and its subtasks
+func prerequisites(tasks map[int]*Task) map[int][]int {
+	result := make(map[int][]int, len(tasks))
+	for _, task := range tasks {
+		for _, id := range task.BlockedBy {
+			if _, ok := tasks[id]; ok {
+				result[task.ID] = append(result[task.ID], id)
+			}
+		}
+		if _, ok := tasks[task.ParentID]; ok {
+			result[task.ParentID] = append(result[task.ParentID], task.ID)
+		}
+	}
+	for _, ids := range result {
+		slices.Sort(ids)
+	}
+	return result
+}
+
+// findPath returns a path from one task to another following
+// prerequisites, both ends included, or nil if there is none
+func findPath(prereqs map[int][]int, from, to int) []int {
+	visited := make(map[int]bool)
+	var visit func(id int) []int
+	visit = func(id int) []int {
+		if id == to {
+			return []int{id}
+		}
+		if visited[id] {
+			return nil
+		}
+		visited[id] = true
+		for _, next := range prereqs[id] {
+			if path := visit(next); path != nil {
+				return append([]int{id}, path...)
+			}
+		}
+		return nil
+	}
+	return visit(from)
+}
+
+// topologicalOrder sorts task IDs with Kahn's algorithm, taking the lowest
+// ready ID first
+func topologicalOrder(tasks map[int]*Task) ([]int, error) {
+	prereqs := prerequisites(tasks)
+	waiting := make(map[int]int, len(tasks))
+	unblocks := make(map[int][]int)
+	var ready []int
+	for id := range tasks {
+		waiting[id] = len(prereqs[id])
+		for _, p := range prereqs[id] {
+			unblocks[p] = append(unblocks[p], id)
+		}
+		if waiting[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	order := make([]int, 0, len(tasks))
+	for len(ready) > 0 {
+		slices.Sort(ready)
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, next := range unblocks[id] {
+			if waiting[next]--; waiting[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+	if len(order) == len(tasks) {
+		return order, nil
+	}
+
+	// Every task left waits for another task left, so following those
+	// waits must come back to a task already seen
+	var left []int
+	for id, n := range waiting {
+		if n > 0 {
+			left = append(left, id)
+		}
+	}
+	var path []int
+	id := slices.Min(left)
+	for !slices.Contains(path, id) {
+		path = append(path, id)
+		i := slices.IndexFunc(prereqs[id], func(p int) bool { return waiting[p] > 0 })
+		id = prereqs[id][i]
+	}
+	cycle := path[slices.Index(path, id):]
+	return nil, &CycleError{Path: append(cycle, id)}
+}
@@ -0,0 +1,177 @@
+package taskmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newGoal adds a goal with two steps, the second blocked by the first,
+// and an unrelated task
+func newGoal(t *testing.T) *TaskManager {
+	t.Helper()
+	tm := NewTaskManager()
+	goal, _ := tm.AddTask("Run a 10K", "")
+	shoes, err := tm.AddTask("Buy shoes", "", WithParent(goal.ID))
+	if err != nil {
+		t.Fatalf("Failed to add subtask: %v", err)
+	}
+	train, _ := tm.AddTask("Train", "", WithParent(goal.ID))
+	tm.AddTask("Read", "")
+	if err := tm.AddDependency(train.ID, shoes.ID); err != nil {
+		t.Fatalf("Failed to add dependency: %v", err)
+	}
+	return tm
+}
+
+func TestAddDependencyCycle(t *testing.T) {
+	tm := newGoal(t)
+
+	tests := []struct {
+		name        string
+		id, blocker int
+		path        []int
+	}{
+		{"self", 4, 4, []int{4, 4}},
+		{"direct", 2, 3, []int{2, 3, 2}},
+		{"through parent", 2, 1, []int{2, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tm.AddDependency(tt.id, tt.blocker)
+			var cycle *CycleError
+			if !errors.As(err, &cycle) || !errors.Is(err, ErrCycle) {
+				t.Fatalf("Expected a *CycleError, got %v", err)
+			}
+			if !slices.Equal(cycle.Path, tt.path) {
+				t.Errorf("Path = %v, want %v", cycle.Path, tt.path)
+			}
+		})
+	}
+
+	if err := tm.AddDependency(1, 4); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := tm.AddDependency(1, 99); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	if _, err := tm.UpdateTask(1, TaskUpdate{ParentID: ptr(3)}); !errors.Is(err, ErrCycle) {
+		t.Errorf("Expected a parent cycle to be rejected, got %v", err)
+	}
+}
+
+func TestTopologicalOrderAndReady(t *testing.T) {
+	tm := newGoal(t)
+
+	order, err := tm.TopologicalOrder()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := ids(order); !slices.Equal(got, []int{2, 3, 1, 4}) {
+		t.Errorf("TopologicalOrder() = %v, want [2 3 1 4]", got)
+	}
+	if got := ids(tm.ReadyTasks()); !slices.Equal(got, []int{2, 4}) {
+		t.Errorf("ReadyTasks() = %v, want [2 4]", got)
+	}
+
+	tm.CompleteTask(2)
+	if got := ids(tm.ReadyTasks()); !slices.Equal(got, []int{3, 4}) {
+		t.Errorf("ReadyTasks() = %v, want [3 4]", got)
+	}
+	if err := tm.RemoveDependency(3, 2); err != nil {
+		t.Fatal(err)
+	}
+	if task, _ := tm.GetTask(3); len(task.BlockedBy) != 0 {
+		t.Errorf("Expected no blockers, got %v", task.BlockedBy)
+	}
+
+	subtasks, _ := tm.Subtasks(1)
+	if got := ids(subtasks); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("Subtasks(1) = %v, want [2 3]", got)
+	}
+}
+
+func TestCompleteParent(t *testing.T) {
+	tm := newGoal(t)
+
+	if _, _, err := tm.CompleteTask(1); !errors.Is(err, ErrOpenSubtasks) {
+		t.Errorf("Expected ErrOpenSubtasks, got %v", err)
+	}
+	tm.CompleteTask(2)
+	tm.CompleteTask(3)
+	if _, _, err := tm.CompleteTask(1); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestDoneParentHasNoOpenSubtasks(t *testing.T) {
+	tm := newGoal(t)
+	tm.CompleteTask(2)
+	tm.CompleteTask(3)
+	tm.CompleteTask(1)
+
+	if _, err := tm.AddTask("Stretch", "", WithParent(1)); !errors.Is(err, ErrParentDone) {
+		t.Errorf("Expected ErrParentDone adding under a done parent, got %v", err)
+	}
+	if _, err := tm.UpdateTask(4, TaskUpdate{ParentID: ptr(1)}); !errors.Is(err, ErrParentDone) {
+		t.Errorf("Expected ErrParentDone moving an open task, got %v", err)
+	}
+	if _, err := tm.UpdateTask(2, TaskUpdate{Done: ptr(false)}); !errors.Is(err, ErrParentDone) {
+		t.Errorf("Expected ErrParentDone reopening a subtask, got %v", err)
+	}
+	if task, _ := tm.GetTask(2); !task.Done {
+		t.Error("Expected the subtask to stay done")
+	}
+
+	// Done tasks may move under a done parent, and an open parent takes
+	// its subtasks back
+	tm.CompleteTask(4)
+	if _, err := tm.UpdateTask(4, TaskUpdate{ParentID: ptr(1)}); err != nil {
+		t.Errorf("Unexpected error moving a done task: %v", err)
+	}
+	if _, err := tm.UpdateTask(1, TaskUpdate{Done: ptr(false)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.UpdateTask(2, TaskUpdate{Done: ptr(false)}); err != nil {
+		t.Errorf("Unexpected error reopening a subtask of an open parent: %v", err)
+	}
+}
+
+func TestDeletePolicy(t *testing.T) {
+	tm := newGoal(t)
+
+	if err := tm.DeleteTask(1); !errors.Is(err, ErrHasSubtasks) {
+		t.Errorf("Expected ErrHasSubtasks, got %v", err)
+	}
+	if err := tm.DeleteTask(2); !errors.Is(err, ErrHasDependents) {
+		t.Errorf("Expected ErrHasDependents, got %v", err)
+	}
+
+	tm.AddDependency(4, 3)
+	if err := tm.DeleteTaskWithPolicy(1, DeleteCascade); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := ids(tm.ListTasks(Query{})); !slices.Equal(got, []int{4}) {
+		t.Errorf("Expected only task 4 to remain, got %v", got)
+	}
+	if task, _ := tm.GetTask(4); len(task.BlockedBy) != 0 {
+		t.Errorf("Expected the deleted blocker to be removed, got %v", task.BlockedBy)
+	}
+}
+
+func TestFileStoreRejectsCycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	content := `{"version": 1, "next_id": 3, "tasks": [
+		{"id": 1, "title": "a", "blocked_by": [2]},
+		{"id": 2, "title": "b", "blocked_by": [1]}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := OpenTaskManager(NewFileStore(path))
+	if !errors.Is(err, ErrCorruptFile) {
+		t.Errorf("Expected ErrCorruptFile, got %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+package taskmanager
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// maxUndo is the number of changes Undo can revert
+const maxUndo = 100
+
+type EventType string
+
+const (
+	EventAdd    EventType = "add"
+	EventUpdate EventType = "update"
+	EventDelete EventType = "delete"
+	EventUndo   EventType = "undo"
+	EventRedo   EventType = "redo"
+)
+
+// Event is one change to a TaskManager. Applying the After state of every
+// change, in order of Seq, rebuilds the tasks.
+type Event struct {
+	Seq  int64     `json:"seq"`
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+	// Ref is the Seq of the event an undo or redo reverts or repeats
+	Ref     int64    `json:"ref,omitempty"`
+	NextID  int      `json:"next_id"`
+	Changes []Change `json:"changes"`
+}
+
+// Change is the state of one task before and after an event; Before is
+// nil for a new task and After is nil for a deleted one
+type Change struct {
+	TaskID int   `json:"task_id"`
+	Before *Task `json:"before,omitempty"`
+	After  *Task `json:"after,omitempty"`
+}
+
+// EventLog is an append-only log of events
+type EventLog interface {
+	Append(e *Event) error
+	// Events returns the events with a Seq above after, in order
+	Events(after int64) ([]*Event, error)
+}
+
+// Undo reverts the last change that was not undone yet, recording the
+// revert as an event of its own, and returns that event
+func (tm *TaskManager) Undo() (*Event, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if len(tm.undo) == 0 {
+		return nil, ErrNothingToUndo
+	}
+	undone := tm.undo[len(tm.undo)-1]
+	e := &Event{Type: EventUndo, Ref: undone.Seq, NextID: tm.nextID}
+	for i := len(undone.Changes) - 1; i >= 0; i-- {
+		c := undone.Changes[i]
+		e.Changes = append(e.Changes, Change{TaskID: c.TaskID, Before: c.After, After: c.Before})
+	}
+	if err := tm.record(e); err != nil {
+		return nil, err
+	}
+	return e.clone(), nil
+}
+
+// Redo repeats the last change reverted by Undo, unless another change was
+// made since
+func (tm *TaskManager) Redo() (*Event, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if len(tm.redo) == 0 {
+		return nil, ErrNothingToRedo
+	}
+	redone := tm.redo[len(tm.redo)-1]
+	e := &Event{Type: EventRedo, Ref: redone.Seq, NextID: tm.nextID, Changes: redone.Changes}
+	if err := tm.record(e); err != nil {
+		return nil, err
+	}
+	return e.clone(), nil
+}
+
+// History returns the events that changed a task, oldest first. Without
+// WithEventLog only the last 100 events since the TaskManager was created
+// are kept, as many as Undo can revert.
+func (tm *TaskManager) History(id int) ([]*Event, error) {
+	events, err := tm.log.Events(0)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(events, func(e *Event) bool {
+		return !slices.ContainsFunc(e.Changes, func(c Change) bool { return c.TaskID == id })
+	}), nil
+}
+
+// Replay rebuilds a TaskManager from all events in log. It keeps appending
+// to log, without snapshots.
+func Replay(log EventLog) (*TaskManager, error) {
+	return OpenTaskManager(nil, WithEventLog(log))
+}
+
+// commit records a change of type typ: the tasks in put are stored, the
+// tasks in del deleted and nextID set. The caller holds tm.mu.
+func (tm *TaskManager) commit(typ EventType, nextID int, put []*Task, del []int) error {
+	e := &Event{Type: typ, NextID: nextID}
+	for _, task := range put {
+		e.Changes = append(e.Changes, Change{TaskID: task.ID, Before: tm.tasks[task.ID].cloneOrNil(), After: task.clone()})
+	}
+	for _, id := range del {
+		e.Changes = append(e.Changes, Change{TaskID: id, Before: tm.tasks[id].clone()})
+	}
+	return tm.record(e)
+}
+
+// record numbers, applies and persists e. If persisting fails it puts
+// everything back, so memory never gets ahead of the store or log.
+func (tm *TaskManager) record(e *Event) error {
+	e.Seq = tm.seq + 1
+	e.Time = time.Now()
+
+	previous := make(map[int]*Task, len(e.Changes))
+	for _, c := range e.Changes {
+		if _, seen := previous[c.TaskID]; !seen {
+			previous[c.TaskID] = tm.tasks[c.TaskID]
+		}
+	}
+	previousNextID, previousSeq := tm.nextID, tm.seq
+	previousUndo, previousRedo := tm.undo, tm.redo
+
+	if err := tm.replay(e); err != nil {
+		return err
+	}
+	if err := tm.persist(e); err != nil {
+		for id, task := range previous {
+			if task == nil {
+				delete(tm.tasks, id)
+			} else {
+				tm.tasks[id] = task
+			}
+		}
+		tm.nextID, tm.seq = previousNextID, previousSeq
+		tm.undo, tm.redo = previousUndo, previousRedo
+		return err
+	}
+	return nil
+}
+
+// replay applies e to the tasks and the undo and redo stacks
+func (tm *TaskManager) replay(e *Event) error {
+	if e.Seq != tm.seq+1 {
+		return fmt.Errorf("expected event %d, got %d", tm.seq+1, e.Seq)
+	}
+	switch e.Type {
+	case EventUndo:
+		if len(tm.undo) == 0 || tm.undo[len(tm.undo)-1].Seq != e.Ref {
+			return fmt.Errorf("event %d cannot be undone now", e.Ref)
+		}
+		tm.redo = append(slices.Clip(tm.redo), tm.undo[len(tm.undo)-1])
+		tm.undo = tm.undo[:len(tm.undo)-1]
+	case EventRedo:
+		if len(tm.redo) == 0 || tm.redo[len(tm.redo)-1].Seq != e.Ref {
+			return fmt.Errorf("event %d cannot be redone now", e.Ref)
+		}
+		tm.undo = append(slices.Clip(tm.undo), tm.redo[len(tm.redo)-1])
+		tm.redo = tm.redo[:len(tm.redo)-1]
+	default:
+		tm.undo = append(slices.Clip(tm.undo), e)
+		if len(tm.undo) > maxUndo {
+			tm.undo = tm.undo[len(tm.undo)-maxUndo:]
+		}
+		tm.redo = nil
+	}
+
+	for _, c := range e.Changes {
+		if c.After == nil {
+			delete(tm.tasks, c.TaskID)
+		} else {
+			tm.tasks[c.TaskID] = c.After.clone()
+		}
+	}
+	tm.nextID, tm.seq = e.NextID, e.Seq
+	return nil
+}
+
+// persist saves e. With a durable log, appending e is what makes the
+// change stick and the store gets a snapshot every snapshotEvery events;
+// otherwise the store saves the whole state every time.
+func (tm *TaskManager) persist(e *Event) error {
+	if tm.durable {
+		if err := tm.log.Append(e); err != nil {
+			return err
+		}
+		if tm.store != nil && e.Seq-tm.snapshotSeq >= tm.snapshotEvery {
+			// The log already holds the event, so a failed snapshot is
+			// simply retried after the next one
+			if tm.store.Save(tm.snapshot()) == nil {
+				tm.snapshotSeq = e.Seq
+			}
+		}
+		return nil
+	}
+	if tm.store != nil {
+		if err := tm.store.Save(tm.snapshot()); err != nil {
+			return err
+		}
+	}
+	return tm.log.Append(e)
+}
+
+func (t *Task) cloneOrNil() *Task {
+	if t == nil {
+		return nil
+	}
+	return t.clone()
+}
+
+func (e *Event) clone() *Event {
+	c := *e
+	c.Changes = make([]Change, len(e.Changes))
+	for i, change := range e.Changes {
+		c.Changes[i] = Change{TaskID: change.TaskID, Before: change.Before.cloneOrNil(), After: change.After.cloneOrNil()}
+	}
+	return &c
+}
+
+// MemoryEventLog keeps events in memory; it is the default log of a
+// TaskManager, limited to the last maxUndo events
+type MemoryEventLog struct {
+	// Limit is the number of most recent events kept, 0 for all. A limited
+	// log cannot be replayed, so it is no use with WithEventLog.
+	Limit int
+
+	mu     sync.Mutex
+	events []*Event
+}
+
+func (l *MemoryEventLog) Append(e *Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e.clone())
+	if l.Limit > 0 && len(l.events) > l.Limit {
+		l.events = slices.Delete(l.events, 0, len(l.events)-l.Limit)
+	}
+	return nil
+}
+
+func (l *MemoryEventLog) Events(after int64) ([]*Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var result []*Event
+	for _, e := range l.events {
+		if e.Seq > after {
+			result = append(result, e.clone())
+		}
+	}
+	return result, nil
+}
+
+// FileEventLog keeps events in a file, one JSON object per line. Every
+// append is synced to disk. A last line cut short by a crash is ignored
+// and overwritten by the next append.
+type FileEventLog struct {
+	Path string
+
+	mu       sync.Mutex
+	repaired bool
+}
+
+func NewFileEventLog(path string) *FileEventLog {
+	return &FileEventLog{Path: path}
+}
+
+func (l *FileEventLog) Append(e *Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	f, err := os.OpenFile(l.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	defer f.Close()
+
+	if !l.repaired {
+		// Drop a partial last line left by a crash
+		_, end, err := readEvents(f, l.Path, 0)
+		if err != nil {
+			return err
+		}
+		if err := f.Truncate(end); err != nil {
+			return fmt.Errorf("taskmanager: %w", err)
+		}
+		l.repaired = true
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	return nil
+}
+
+func (l *FileEventLog) Events(after int64) ([]*Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("taskmanager: %w", err)
+	}
+	defer f.Close()
+	events, _, err := readEvents(f, l.Path, after)
+	return events, err
+}
+
+// readEvents reads the complete lines of an event file and returns the
+// events with a Seq above after and the length of the complete lines
+func readEvents(r io.Reader, path string, after int64) ([]*Event, int64, error) {
+	var events []*Event
+	var end int64
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return events, end, nil
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("taskmanager: %w", err)
+		}
+		end += int64(len(data))
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, 0, fmt.Errorf("taskmanager: %w %s: line %d: %v", ErrCorruptFile, path, line, err)
+		}
+		if e.Seq > after {
+			events = append(events, &e)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package taskmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// sameTasks compares tasks as saved, since times read back from a file
+// have no monotonic clock reading
+func sameTasks(t *testing.T, got, want []*Task) bool {
+	t.Helper()
+	a, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(a) == string(b)
+}
+
+func TestUndoRedo(t *testing.T) {
+	tm := NewTaskManager()
+	if _, err := tm.Undo(); err != ErrNothingToUndo {
+		t.Errorf("Expected ErrNothingToUndo, got %v", err)
+	}
+
+	task, _ := tm.AddTask("Drink water", "")
+	tm.UpdateTask(task.ID, TaskUpdate{Title: ptr("Drink more water")})
+	if err := tm.DeleteTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := tm.Undo()
+	if err != nil || e.Type != EventUndo {
+		t.Fatalf("Undo() = %+v, %v", e, err)
+	}
+	got, err := tm.GetTask(task.ID)
+	if err != nil || got.Title != "Drink more water" {
+		t.Fatalf("Expected the deleted task back, got %+v, %v", got, err)
+	}
+
+	tm.Undo()
+	if got, _ := tm.GetTask(task.ID); got.Title != "Drink water" {
+		t.Errorf("Expected the original title, got %q", got.Title)
+	}
+	tm.Undo()
+	if _, err := tm.GetTask(task.ID); err != ErrTaskNotFound {
+		t.Errorf("Expected the add to be undone, got %v", err)
+	}
+
+	tm.Redo()
+	tm.Redo()
+	if got, _ := tm.GetTask(task.ID); got.Title != "Drink more water" {
+		t.Errorf("Expected the update to be redone, got %q", got.Title)
+	}
+
+	// A new change drops what is left to redo
+	other, _ := tm.AddTask("Sleep", "")
+	if other.ID != 2 {
+		t.Errorf("IDs must not be reused after undo, got %d", other.ID)
+	}
+	if _, err := tm.Redo(); err != ErrNothingToRedo {
+		t.Errorf("Expected ErrNothingToRedo, got %v", err)
+	}
+}
+
+func TestUndoCompleteRecurring(t *testing.T) {
+	tm := NewTaskManager()
+	task, _ := tm.AddTask("Stretch", "", WithRecurrence("FREQ=DAILY"))
+	_, next, _ := tm.CompleteTask(task.ID)
+
+	tm.Undo()
+	if got, _ := tm.GetTask(task.ID); got.Done {
+		t.Error("Expected the task to be open again")
+	}
+	if _, err := tm.GetTask(next.ID); err != ErrTaskNotFound {
+		t.Errorf("Expected the next occurrence to be removed, got %v", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	tm := NewTaskManager()
+	first, _ := tm.AddTask("First", "")
+	second, _ := tm.AddTask("Second", "")
+	tm.UpdateTask(first.ID, TaskUpdate{Done: ptr(true)})
+	tm.Undo()
+
+	history, err := tm.History(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []EventType
+	for _, e := range history {
+		types = append(types, e.Type)
+	}
+	if !slices.Equal(types, []EventType{EventAdd, EventUpdate, EventUndo}) {
+		t.Errorf("History(%d) = %v", first.ID, types)
+	}
+	if history[1].Changes[0].Before.Done || !history[1].Changes[0].After.Done {
+		t.Errorf("Expected the update to record before and after: %+v", history[1].Changes[0])
+	}
+	if h, _ := tm.History(second.ID); len(h) != 1 {
+		t.Errorf("Expected one event for task %d, got %d", second.ID, len(h))
+	}
+}
+
+func openLogged(t *testing.T, dir string) *TaskManager {
+	t.Helper()
+	tm, err := OpenTaskManager(NewFileStore(filepath.Join(dir, "tasks.json")),
+		WithEventLog(NewFileEventLog(filepath.Join(dir, "events.ndjson"))),
+		WithSnapshotInterval(10))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	return tm
+}
+
+func TestEventLogReplay(t *testing.T) {
+	dir := t.TempDir()
+	tm := openLogged(t, dir)
+	for i := 0; i < 12; i++ {
+		task, _ := tm.AddTask("Task", "")
+		tm.UpdateTask(task.ID, TaskUpdate{Done: ptr(i%2 == 0)})
+	}
+	tm.DeleteTask(3)
+	tm.Undo()
+	tm.Undo()
+	tm.Redo()
+	want := tm.ListTasks(Query{})
+
+	snap, err := NewFileStore(filepath.Join(dir, "tasks.json")).Load()
+	if err != nil || snap.Seq != 20 {
+		t.Fatalf("Expected a snapshot at event 20, got %v, %v", snap, err)
+	}
+
+	reopened := openLogged(t, dir)
+	if got := reopened.ListTasks(Query{}); !sameTasks(t, got, want) {
+		t.Errorf("Reopened state differs")
+	}
+	replayed, err := Replay(NewFileEventLog(filepath.Join(dir, "events.ndjson")))
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if got := replayed.ListTasks(Query{}); !sameTasks(t, got, want) {
+		t.Errorf("Replayed state differs")
+	}
+
+	// The undo stack survives a restart: of the two changes undone, one is
+	// left to redo
+	if _, err := reopened.Redo(); err != nil {
+		t.Errorf("Expected one change left to redo: %v", err)
+	}
+	if _, err := reopened.GetTask(3); err != ErrTaskNotFound {
+		t.Errorf("Expected task 3 deleted after redo, got %v", err)
+	}
+}
+
+func TestEventLogManyEvents(t *testing.T) {
+	dir := t.TempDir()
+	tm, err := OpenTaskManager(NewFileStore(filepath.Join(dir, "tasks.json")),
+		WithEventLog(NewFileEventLog(filepath.Join(dir, "events.ndjson"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _ := tm.AddTask("Count", "")
+	for i := 0; i < 2000; i++ {
+		tm.UpdateTask(task.ID, TaskUpdate{Done: ptr(i%2 == 0)})
+	}
+
+	reopened, err := OpenTaskManager(NewFileStore(filepath.Join(dir, "tasks.json")),
+		WithEventLog(NewFileEventLog(filepath.Join(dir, "events.ndjson"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed := reopened.seq - reopened.snapshotSeq; replayed >= 100 {
+		t.Errorf("Expected fewer than 100 events replayed, got %d", replayed)
+	}
+	if got, _ := reopened.GetTask(task.ID); got.Done {
+		t.Error("Expected the last update to leave the task open")
+	}
+}
+
+func TestFileEventLogDamage(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.ndjson")
+	tm := openLogged(t, dir)
+	tm.AddTask("Kept", "")
+
+	// A crash in the middle of an append leaves a partial line
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"seq": 2, "type": "ad`)
+	f.Close()
+
+	reopened := openLogged(t, dir)
+	if _, err := reopened.AddTask("After crash", ""); err != nil {
+		t.Fatalf("Failed to append after a partial line: %v", err)
+	}
+	if _, err := Replay(NewFileEventLog(path)); err != nil {
+		t.Errorf("Expected the log to be repaired, got %v", err)
+	}
+
+	os.WriteFile(path, []byte("not json\n"), 0o644)
+	if _, err := Replay(NewFileEventLog(path)); !errors.Is(err, ErrCorruptFile) {
+		t.Errorf("Expected ErrCorruptFile, got %v", err)
+	}
+}
+
+func TestDefaultLogIsLimited(t *testing.T) {
+	tm := NewTaskManager()
+	task, _ := tm.AddTask("Count", "")
+	for i := range maxUndo + 10 {
+		tm.UpdateTask(task.ID, TaskUpdate{Description: ptr(strconv.Itoa(i))})
+	}
+
+	history, err := tm.History(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != maxUndo {
+		t.Fatalf("Expected the last %d events, got %d", maxUndo, len(history))
+	}
+	if last := history[len(history)-1]; last.Changes[0].After.Description != strconv.Itoa(maxUndo+9) {
+		t.Errorf("Expected the newest event last, got %+v", last.Changes[0].After)
+	}
+
+	// Undo still reaches back as far as the log does
+	for range maxUndo {
+		if _, err := tm.Undo(); err != nil {
+			t.Fatalf("Undo() failed: %v", err)
+		}
+	}
+	if _, err := tm.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo, got %v", err)
+	}
+}
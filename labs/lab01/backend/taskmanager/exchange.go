@@ -0,0 +1,299 @@
+package taskmanager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	ErrInvalidImport = errors.New("invalid import")
+)
+
+// Format is a file format tasks can be exported to and imported from
+type Format string
+
+const (
+	// FormatCSV has a header row and one task per row with every field
+	FormatCSV Format = "csv"
+	// FormatTodoTxt is http://todotxt.org. Tags are written as +projects,
+	// or as @contexts if they start with @; descriptions are not kept.
+	FormatTodoTxt Format = "todo.txt"
+	// FormatMarkdown is a checklist with subtasks nested under their
+	// parent; dependencies and IDs are not kept
+	FormatMarkdown Format = "markdown"
+	// FormatICal is an iCalendar file of VTODO components
+	FormatICal Format = "ical"
+)
+
+// Formats returns the supported formats
+func Formats() []Format {
+	return []Format{FormatCSV, FormatTodoTxt, FormatMarkdown, FormatICal}
+}
+
+// ParseFormat returns the format for a name or file extension such as
+// "csv", "txt", "md" or "ics"
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "csv":
+		return FormatCSV, nil
+	case "todo.txt", "todotxt", "txt":
+		return FormatTodoTxt, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "ical", "icalendar", "ics":
+		return FormatICal, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownFormat, s)
+}
+
+// LineError is a problem with one task of an imported file
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+type ImportOptions struct {
+	// DryRun checks the file and reports what would be imported without
+	// changing anything
+	DryRun bool
+	// SkipInvalid imports the valid tasks of a file with errors, instead
+	// of nothing
+	SkipInvalid bool
+}
+
+type ImportResult struct {
+	// Tasks are the tasks added, or that would be added in a dry run
+	Tasks []*Task
+	// IDs maps the IDs or UIDs in the file to the new task IDs
+	IDs    map[string]int
+	Errors []*LineError
+}
+
+// record is a task read from a file, referring to other tasks by their ID
+// or UID in the file
+type record struct {
+	line      int
+	ref       string
+	task      *Task
+	parent    string
+	blockedBy []string
+}
+
+// Export writes tasks in format f
+func Export(w io.Writer, tasks []*Task, f Format) error {
+	switch f {
+	case FormatCSV:
+		return writeCSV(w, tasks)
+	case FormatTodoTxt:
+		return writeTodoTxt(w, tasks)
+	case FormatMarkdown:
+		return writeMarkdown(w, tasks)
+	case FormatICal:
+		return writeICal(w, tasks)
+	}
+	return fmt.Errorf("%w %q", ErrUnknownFormat, f)
+}
+
+func decode(r io.Reader, f Format) ([]*record, []*LineError, error) {
+	switch f {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatTodoTxt:
+		return readTodoTxt(r)
+	case FormatMarkdown:
+		return readMarkdown(r)
+	case FormatICal:
+		return readICal(r)
+	}
+	return nil, nil, fmt.Errorf("%w %q", ErrUnknownFormat, f)
+}
+
+// Import adds the tasks of a file in format f as new tasks, in one change
+// that a single Undo reverts. Tasks get new IDs, and references between
+// them (subtasks and dependencies) are remapped to those IDs, so importing
+// never touches the tasks already held. Problems are reported per line in
+// the result; unless opts.SkipInvalid is set, any problem stops the import
+// with ErrInvalidImport.
+func (tm *TaskManager) Import(r io.Reader, f Format, opts ImportOptions) (*ImportResult, error) {
+	records, errs, err := decode(r, f)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	valid := make(map[string]*record)
+	var accepted []*record
+	for _, rec := range records {
+		if err := rec.normalize(now); err != nil {
+			errs = append(errs, &LineError{Line: rec.line, Err: err})
+			continue
+		}
+		if rec.ref != "" {
+			if _, dup := valid[rec.ref]; dup {
+				errs = append(errs, &LineError{Line: rec.line, Err: fmt.Errorf("duplicate id %s", rec.ref)})
+				continue
+			}
+			valid[rec.ref] = rec
+		}
+		accepted = append(accepted, rec)
+	}
+	// References to tasks that are not imported are dropped
+	for _, rec := range accepted {
+		if _, ok := valid[rec.parent]; rec.parent != "" && !ok {
+			errs = append(errs, &LineError{Line: rec.line, Err: fmt.Errorf("parent %s: %w", rec.parent, ErrTaskNotFound)})
+			rec.parent = ""
+		} else if rec.parent != "" && valid[rec.parent].task.Done && !rec.task.Done {
+			errs = append(errs, &LineError{Line: rec.line, Err: fmt.Errorf("parent %s: %w", rec.parent, ErrParentDone)})
+			rec.parent = ""
+		}
+		rec.blockedBy = slices.DeleteFunc(rec.blockedBy, func(ref string) bool {
+			if _, ok := valid[ref]; !ok {
+				errs = append(errs, &LineError{Line: rec.line, Err: fmt.Errorf("blocker %s: %w", ref, ErrTaskNotFound)})
+				return true
+			}
+			return false
+		})
+	}
+	slices.SortStableFunc(errs, func(a, b *LineError) int { return a.Line - b.Line })
+
+	result := &ImportResult{IDs: make(map[string]int), Errors: errs}
+	if len(errs) > 0 && !opts.SkipInvalid {
+		return result, fmt.Errorf("%w: %d errors, first %v", ErrInvalidImport, len(errs), errs[0])
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	nextID := tm.nextID
+	for _, rec := range accepted {
+		rec.task.ID = nextID
+		nextID++
+		if rec.ref != "" {
+			result.IDs[rec.ref] = rec.task.ID
+		}
+	}
+	imported := make(map[int]*Task, len(accepted))
+	for _, rec := range accepted {
+		if rec.parent != "" {
+			rec.task.ParentID = result.IDs[rec.parent]
+		}
+		for _, ref := range rec.blockedBy {
+			rec.task.BlockedBy = append(rec.task.BlockedBy, result.IDs[ref])
+		}
+		slices.Sort(rec.task.BlockedBy)
+		rec.task.BlockedBy = slices.Compact(rec.task.BlockedBy)
+		imported[rec.task.ID] = rec.task
+		result.Tasks = append(result.Tasks, rec.task.clone())
+	}
+	if _, err := topologicalOrder(imported); err != nil {
+		return result, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+
+	if opts.DryRun || len(accepted) == 0 {
+		return result, nil
+	}
+	put := make([]*Task, len(accepted))
+	for i, rec := range accepted {
+		put[i] = rec.task
+	}
+	if err := tm.commit(EventAdd, nextID, put, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// normalize checks an imported task like AddTask does and fills in
+// missing times
+func (rec *record) normalize(now time.Time) error {
+	t := rec.task
+	if t.Title == "" {
+		return ErrEmptyTitle
+	}
+	if !t.Priority.valid() {
+		return ErrInvalidPriority
+	}
+	rule, err := normalizeRecurrence(t.Recurrence)
+	if err != nil {
+		return err
+	}
+	t.Recurrence = rule
+	t.Tags = normalizeTags(t.Tags)
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+	if t.UpdatedAt.IsZero() {
+		t.UpdatedAt = now
+	}
+	if !t.Done {
+		t.CompletedAt = nil
+	} else if t.CompletedAt == nil {
+		t.CompletedAt = &now
+	}
+	return nil
+}
+
+// parseTime reads an RFC 3339 time, or a date as midnight local time
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	return t, nil
+}
+
+func parseOptionalTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := parseTime(s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatDate writes the date of t, or "" for nil
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+func formatIDs(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
+
+// splitList splits a list separated by commas or semicolons, dropping
+// empty items
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' })
+}
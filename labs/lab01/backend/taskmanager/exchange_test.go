@@ -0,0 +1,243 @@
+package taskmanager
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	source := newGoal(t)
+	source.UpdateTask(1, TaskUpdate{
+		Description: ptr("Spring race, then a long rest"),
+		Priority:    ptr(PriorityHigh),
+		Due:         ptr(day),
+		Tags:        ptr([]string{"health", "@gym"}),
+	})
+	source.UpdateTask(4, TaskUpdate{Recurrence: ptr("FREQ=WEEKLY;BYDAY=MO")})
+	source.CompleteTask(2)
+	want := source.ListTasks(Query{})
+
+	for _, format := range Formats() {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Export(&buf, want, format); err != nil {
+				t.Fatalf("Failed to export: %v", err)
+			}
+
+			tm := NewTaskManager()
+			tm.AddTask("Already here", "")
+			result, err := tm.Import(&buf, format, ImportOptions{})
+			if err != nil {
+				t.Fatalf("Failed to import: %v\n%s", err, buf.String())
+			}
+			if got := ids(result.Tasks); !slices.Equal(got, []int{2, 3, 4, 5}) {
+				t.Fatalf("Expected new IDs [2 3 4 5], got %v", got)
+			}
+
+			goal, _ := tm.GetTask(2)
+			if goal.Title != "Run a 10K" || goal.Priority != PriorityHigh || !slices.Equal(goal.Tags, []string{"@gym", "health"}) {
+				t.Errorf("Unexpected goal %+v", goal)
+			}
+			if goal.Due == nil || goal.Due.Format(time.DateOnly) != day.Format(time.DateOnly) {
+				t.Errorf("Expected due %s, got %v", day.Format(time.DateOnly), goal.Due)
+			}
+			if format != FormatTodoTxt && goal.Description != "Spring race, then a long rest" {
+				t.Errorf("Expected the description to be kept, got %q", goal.Description)
+			}
+			shoes, _ := tm.GetTask(3)
+			if !shoes.Done || shoes.CompletedAt == nil || shoes.ParentID != 2 {
+				t.Errorf("Expected a completed subtask of 2, got %+v", shoes)
+			}
+			if read, _ := tm.GetTask(5); read.Recurrence != "FREQ=WEEKLY;BYDAY=MO" {
+				t.Errorf("Expected the recurrence to be kept, got %q", read.Recurrence)
+			}
+			train, _ := tm.GetTask(4)
+			if format != FormatMarkdown && !slices.Equal(train.BlockedBy, []int{3}) {
+				t.Errorf("Expected the dependency remapped to 3, got %v", train.BlockedBy)
+			}
+		})
+	}
+}
+
+func TestImportLineErrors(t *testing.T) {
+	input := "(A) Call mom at 10:30 @phone due:2025-07-05\n" +
+		"Pay rent due:tomorrow\n" +
+		"\n" +
+		"Water plants id:7 dep:8\n" +
+		"+garden\n"
+	tm := NewTaskManager()
+
+	result, err := tm.Import(strings.NewReader(input), FormatTodoTxt, ImportOptions{})
+	if !errors.Is(err, ErrInvalidImport) {
+		t.Fatalf("Expected ErrInvalidImport, got %v", err)
+	}
+	var lines []int
+	for _, e := range result.Errors {
+		lines = append(lines, e.Line)
+	}
+	if !slices.Equal(lines, []int{2, 4, 5}) {
+		t.Errorf("Expected errors on lines [2 4 5], got %v", result.Errors)
+	}
+	if !errors.Is(result.Errors[1], ErrTaskNotFound) || !errors.Is(result.Errors[2], ErrEmptyTitle) {
+		t.Errorf("Unexpected errors %v", result.Errors)
+	}
+	if n := len(tm.ListTasks(Query{})); n != 0 {
+		t.Errorf("Expected nothing imported, got %d tasks", n)
+	}
+
+	result, err = tm.Import(strings.NewReader(input), FormatTodoTxt, ImportOptions{SkipInvalid: true})
+	if err != nil || len(result.Errors) != 3 {
+		t.Fatalf("Expected the errors reported, got %v, %v", result, err)
+	}
+	got := tm.ListTasks(Query{})
+	if len(got) != 2 || got[0].Title != "Call mom at 10:30" || len(got[1].BlockedBy) != 0 {
+		t.Errorf("Expected the valid tasks imported without the missing blocker, got %+v", got)
+	}
+}
+
+func TestImportDryRunAndUndo(t *testing.T) {
+	input := "id,title,parent_id,blocked_by\n" +
+		"a,Plan trip,,\n" +
+		"b,Book flights,a,\n" +
+		"c,Pack,a,b\n"
+	tm := NewTaskManager()
+	tm.AddTask("Existing", "")
+
+	result, err := tm.Import(strings.NewReader(input), FormatCSV, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IDs["a"] != 2 || result.IDs["c"] != 4 || result.Tasks[2].BlockedBy[0] != 3 {
+		t.Errorf("Unexpected dry run result %+v", result)
+	}
+	if n := len(tm.ListTasks(Query{})); n != 1 {
+		t.Errorf("Expected a dry run to change nothing, got %d tasks", n)
+	}
+
+	if _, err := tm.Import(strings.NewReader(input), FormatCSV, ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if subtasks, _ := tm.Subtasks(2); len(subtasks) != 2 {
+		t.Errorf("Expected 2 subtasks, got %d", len(subtasks))
+	}
+	tm.Undo()
+	if got := ids(tm.ListTasks(Query{})); !slices.Equal(got, []int{1}) {
+		t.Errorf("Expected one undo to revert the import, got %v", got)
+	}
+
+	cyclic := "id,title,blocked_by\n1,a,2\n2,b,1\n"
+	if _, err := tm.Import(strings.NewReader(cyclic), FormatCSV, ImportOptions{}); !errors.Is(err, ErrCycle) {
+		t.Errorf("Expected ErrCycle, got %v", err)
+	}
+}
+
+func TestImportICal(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:abc@example.com\r\n" +
+		"SUMMARY:Buy milk\\, eggs and a very long list of other things that needs fo\r\n" +
+		" lding\r\n" +
+		"DUE;TZID=Europe/Moscow:20250705T180000\r\n" +
+		"CATEGORIES:shop,home\\,kitchen\r\n" +
+		"PRIORITY:3\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:Done thing\r\n" +
+		"STATUS:COMPLETED\r\n" +
+		"DUE;VALUE=DATE:20250701\r\n" +
+		"RELATED-TO:abc@example.com\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:Broken\r\n" +
+		"DUE:tomorrow\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+	tm := NewTaskManager()
+
+	result, err := tm.Import(strings.NewReader(input), FormatICal, ImportOptions{SkipInvalid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 18 {
+		t.Errorf("Expected an error on line 18, got %v", result.Errors)
+	}
+	milk, _ := tm.GetTask(1)
+	if !strings.HasPrefix(milk.Title, "Buy milk, eggs") || !strings.HasSuffix(milk.Title, "folding") {
+		t.Errorf("Unexpected title %q", milk.Title)
+	}
+	if !slices.Equal(milk.Tags, []string{"home,kitchen", "shop"}) || milk.Priority != PriorityHigh {
+		t.Errorf("Unexpected task %+v", milk)
+	}
+	if want := time.Date(2025, 7, 5, 15, 0, 0, 0, time.UTC); milk.Due == nil || !milk.Due.Equal(want) {
+		t.Errorf("Expected due %v, got %v", want, milk.Due)
+	}
+	if done, _ := tm.GetTask(2); !done.Done || done.ParentID != 1 {
+		t.Errorf("Expected a done subtask of 1, got %+v", done)
+	}
+}
+
+func TestImportMarkdown(t *testing.T) {
+	input := "# Weekend\n" +
+		"\n" +
+		"Some notes, not a task.\n" +
+		"- [ ] Clean the house !medium #home\n" +
+		"  Start upstairs\n" +
+		"  - [x] Kitchen\n" +
+		"  - [ ] Bathroom due:2025-07-06\n" +
+		"    - [ ] Buy soap\n" +
+		"- plain bullet\n" +
+		"* [X] Call Alex\n"
+	tm := NewTaskManager()
+
+	result, err := tm.Import(strings.NewReader(input), FormatMarkdown, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parents []int
+	for _, task := range result.Tasks {
+		parents = append(parents, task.ParentID)
+	}
+	if !slices.Equal(parents, []int{0, 1, 1, 3, 0}) {
+		t.Errorf("Expected parents [0 1 1 3 0], got %v", parents)
+	}
+	house := result.Tasks[0]
+	if house.Description != "Start upstairs" || house.Priority != PriorityMedium || !slices.Equal(house.Tags, []string{"home"}) {
+		t.Errorf("Unexpected task %+v", house)
+	}
+	if !result.Tasks[1].Done || !result.Tasks[4].Done || result.Tasks[2].Due == nil {
+		t.Errorf("Unexpected tasks %+v", result.Tasks)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"CSV": FormatCSV, ".ics": FormatICal, "md": FormatMarkdown, "txt": FormatTodoTxt} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseFormat("xlsx"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestImportOpenSubtaskOfDoneParent(t *testing.T) {
+	input := "- [x] Run a 10K\n  - [ ] Buy shoes\n"
+	tm := NewTaskManager()
+
+	result, err := tm.Import(strings.NewReader(input), FormatMarkdown, ImportOptions{})
+	if !errors.Is(err, ErrInvalidImport) || len(result.Errors) != 1 || !errors.Is(result.Errors[0], ErrParentDone) || result.Errors[0].Line != 2 {
+		t.Fatalf("Expected ErrParentDone on line 2, got %v, %v", result.Errors, err)
+	}
+
+	// Skipped, the subtask is imported on its own
+	if _, err := tm.Import(strings.NewReader(input), FormatMarkdown, ImportOptions{SkipInvalid: true}); err != nil {
+		t.Fatal(err)
+	}
+	if shoes, _ := tm.GetTask(2); shoes.ParentID != 0 {
+		t.Errorf("Expected the subtask imported without its parent, got %+v", shoes)
+	}
+}
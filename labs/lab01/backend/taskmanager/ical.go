@@ -0,0 +1,327 @@
+package taskmanager
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	icalDateTime = "20060102T150405Z"
+	icalDate     = "20060102"
+	// icalLineLength is the longest line in octets, before folding
+	icalLineLength = 75
+)
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// icalUID identifies a task in iCalendar files
+func icalUID(id int) string {
+	return fmt.Sprintf("task-%d@taskmanager", id)
+}
+
+// icalPriority maps priorities to the 1 (highest) to 9 (lowest) scale of
+// RFC 5545, where 0 means none
+func icalPriority(p Priority) int {
+	switch p {
+	case PriorityHigh:
+		return 1
+	case PriorityMedium:
+		return 5
+	case PriorityLow:
+		return 9
+	}
+	return 0
+}
+
+// writeICal writes a VCALENDAR with one VTODO per task. Subtasks and
+// dependencies become RELATED-TO properties with the PARENT and DEPENDS-ON
+// (RFC 9253) relation types.
+func writeICal(w io.Writer, tasks []*Task) error {
+	bw := bufio.NewWriter(w)
+	write := func(name, value string) {
+		line := name + ":" + value
+		// Fold long lines without splitting a UTF-8 sequence
+		for len(line) > icalLineLength {
+			cut := icalLineLength
+			for cut > 0 && line[cut]&0xC0 == 0x80 {
+				cut--
+			}
+			bw.WriteString(line[:cut] + "\r\n")
+			line = " " + line[cut:]
+		}
+		bw.WriteString(line + "\r\n")
+	}
+	utc := func(t time.Time) string {
+		return t.UTC().Format(icalDateTime)
+	}
+
+	write("BEGIN", "VCALENDAR")
+	write("VERSION", "2.0")
+	write("PRODID", "-//lab01//taskmanager//EN")
+	for _, t := range tasks {
+		write("BEGIN", "VTODO")
+		write("UID", icalUID(t.ID))
+		write("DTSTAMP", utc(t.UpdatedAt))
+		write("CREATED", utc(t.CreatedAt))
+		write("LAST-MODIFIED", utc(t.UpdatedAt))
+		write("SUMMARY", icalEscaper.Replace(t.Title))
+		if t.Description != "" {
+			write("DESCRIPTION", icalEscaper.Replace(t.Description))
+		}
+		if t.Done {
+			write("STATUS", "COMPLETED")
+			if t.CompletedAt != nil {
+				write("COMPLETED", utc(*t.CompletedAt))
+			}
+		} else {
+			write("STATUS", "NEEDS-ACTION")
+		}
+		if t.Due != nil {
+			write("DUE", utc(*t.Due))
+		}
+		if t.Priority != PriorityNone {
+			write("PRIORITY", strconv.Itoa(icalPriority(t.Priority)))
+		}
+		if len(t.Tags) > 0 {
+			categories := make([]string, len(t.Tags))
+			for i, tag := range t.Tags {
+				categories[i] = icalEscaper.Replace(tag)
+			}
+			write("CATEGORIES", strings.Join(categories, ","))
+		}
+		if t.Recurrence != "" {
+			write("RRULE", t.Recurrence)
+		}
+		if t.ParentID != 0 {
+			write("RELATED-TO;RELTYPE=PARENT", icalUID(t.ParentID))
+		}
+		for _, id := range t.BlockedBy {
+			write("RELATED-TO;RELTYPE=DEPENDS-ON", icalUID(id))
+		}
+		write("END", "VTODO")
+	}
+	write("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// icalProperty is one unfolded content line
+type icalProperty struct {
+	line   int
+	name   string
+	params map[string]string
+	value  string
+}
+
+// readICal reads the VTODO components of an iCalendar file, wherever they
+// are nested, and ignores everything else
+func readICal(r io.Reader) ([]*record, []*LineError, error) {
+	props, err := readICalProperties(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []*record
+	var errs []*LineError
+	var todo []icalProperty
+	inTodo := false
+	for _, p := range props {
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VTODO"):
+			inTodo, todo = true, []icalProperty{p}
+		case p.name == "END" && strings.EqualFold(p.value, "VTODO") && inTodo:
+			inTodo = false
+			rec, err := icalRecord(todo)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			records = append(records, rec)
+		case inTodo:
+			todo = append(todo, p)
+		}
+	}
+	if inTodo {
+		errs = append(errs, &LineError{Line: todo[0].line, Err: errors.New("VTODO without END")})
+	}
+	return records, errs, nil
+}
+
+// readICalProperties unfolds the content lines of r and splits them into
+// name, parameters and value
+func readICalProperties(r io.Reader) ([]icalProperty, error) {
+	var props []icalProperty
+	var text strings.Builder
+	start := 0
+	flush := func() {
+		if text.Len() > 0 {
+			props = append(props, parseICalProperty(start, text.String()))
+			text.Reset()
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		s := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\t") {
+			text.WriteString(s[1:])
+			continue
+		}
+		flush()
+		start = line
+		text.WriteString(s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("taskmanager: %w", err)
+	}
+	flush()
+	return props, nil
+}
+
+func parseICalProperty(line int, s string) icalProperty {
+	p := icalProperty{line: line, params: make(map[string]string)}
+	// The value starts at the first colon outside a quoted parameter
+	quoted := false
+	for i, c := range s {
+		if c == '"' {
+			quoted = !quoted
+		}
+		if c == ':' && !quoted {
+			s, p.value = s[:i], s[i+1:]
+			break
+		}
+	}
+	parts := strings.Split(s, ";")
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return p
+}
+
+func icalRecord(props []icalProperty) (*record, *LineError) {
+	t := &Task{}
+	rec := &record{line: props[0].line, task: t}
+	for _, p := range props[1:] {
+		if err := icalApply(rec, p); err != nil {
+			return nil, &LineError{Line: p.line, Err: fmt.Errorf("%s: %w", p.name, err)}
+		}
+	}
+	return rec, nil
+}
+
+func icalApply(rec *record, p icalProperty) error {
+	t := rec.task
+	var err error
+	switch p.name {
+	case "UID":
+		rec.ref = p.value
+	case "SUMMARY":
+		t.Title = icalUnescape(p.value)
+	case "DESCRIPTION":
+		t.Description = icalUnescape(p.value)
+	case "STATUS":
+		t.Done = strings.EqualFold(p.value, "COMPLETED")
+	case "COMPLETED":
+		var completed time.Time
+		if completed, err = icalTime(p); err == nil {
+			t.Done, t.CompletedAt = true, &completed
+		}
+	case "DUE":
+		var due time.Time
+		if due, err = icalTime(p); err == nil {
+			t.Due = &due
+		}
+	case "CREATED":
+		t.CreatedAt, err = icalTime(p)
+	case "LAST-MODIFIED":
+		t.UpdatedAt, err = icalTime(p)
+	case "PRIORITY":
+		var n int
+		n, err = strconv.Atoi(p.value)
+		switch {
+		case err != nil || n < 0 || n > 9:
+			err = fmt.Errorf("%w %q", ErrInvalidPriority, p.value)
+		case n == 0:
+			t.Priority = PriorityNone
+		case n <= 4:
+			t.Priority = PriorityHigh
+		case n == 5:
+			t.Priority = PriorityMedium
+		default:
+			t.Priority = PriorityLow
+		}
+	case "CATEGORIES":
+		t.Tags = append(t.Tags, icalSplit(p.value)...)
+	case "RRULE":
+		t.Recurrence = p.value
+	case "RELATED-TO":
+		switch strings.ToUpper(p.params["RELTYPE"]) {
+		case "", "PARENT":
+			rec.parent = p.value
+		case "DEPENDS-ON":
+			rec.blockedBy = append(rec.blockedBy, p.value)
+		}
+	}
+	return err
+}
+
+// icalTime reads a UTC, floating or TZID date-time, or a date as midnight
+// local time
+func icalTime(p icalProperty) (time.Time, error) {
+	loc := time.Local
+	if tzid := p.params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q", tzid)
+		}
+	}
+	if t, err := time.Parse(icalDateTime, p.value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{strings.TrimSuffix(icalDateTime, "Z"), icalDate} {
+		if t, err := time.ParseInLocation(layout, p.value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", p.value)
+}
+
+func icalUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// icalSplit splits a list value on the commas that are not escaped
+func icalSplit(s string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, icalUnescape(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, icalUnescape(s[start:]))
+}
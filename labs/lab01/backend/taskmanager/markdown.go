@@ -0,0 +1,169 @@
+package taskmanager
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// markdownItem matches a checklist item: indentation, the box and the text
+var markdownItem = regexp.MustCompile(`^(\s*)[-*+] \[([ xX])\](?:\s+(.*))?$`)
+
+// writeMarkdown writes a checklist with one item per task, such as
+// "- [ ] Title !high due:2025-07-05 rrule:FREQ=WEEKLY done:2025-07-02 #tag".
+// Subtasks are nested under their parent and the description is indented
+// below the title.
+func writeMarkdown(w io.Writer, tasks []*Task) error {
+	bw := bufio.NewWriter(w)
+	included := make(map[int]bool, len(tasks))
+	for _, t := range tasks {
+		included[t.ID] = true
+	}
+	children := make(map[int][]*Task)
+	var roots []*Task
+	for _, t := range tasks {
+		if included[t.ParentID] {
+			children[t.ParentID] = append(children[t.ParentID], t)
+		} else {
+			roots = append(roots, t)
+		}
+	}
+
+	var write func(t *Task, indent string)
+	write = func(t *Task, indent string) {
+		box := " "
+		if t.Done {
+			box = "x"
+		}
+		parts := []string{indent + "- [" + box + "]", t.Title}
+		if t.Priority != PriorityNone {
+			parts = append(parts, "!"+t.Priority.String())
+		}
+		if t.Due != nil {
+			parts = append(parts, "due:"+formatDate(t.Due))
+		}
+		if t.Recurrence != "" {
+			parts = append(parts, "rrule:"+t.Recurrence)
+		}
+		if t.CompletedAt != nil {
+			parts = append(parts, "done:"+formatDate(t.CompletedAt))
+		}
+		for _, tag := range t.Tags {
+			parts = append(parts, "#"+strings.ReplaceAll(tag, " ", "_"))
+		}
+		bw.WriteString(strings.Join(parts, " ") + "\n")
+		for _, line := range strings.Split(t.Description, "\n") {
+			if strings.TrimSpace(line) != "" {
+				bw.WriteString(indent + "  " + strings.TrimSpace(line) + "\n")
+			}
+		}
+		for _, child := range children[t.ID] {
+			write(child, indent+"  ")
+		}
+	}
+	for _, t := range roots {
+		write(t, "")
+	}
+	return bw.Flush()
+}
+
+// readMarkdown reads the checklist items of a Markdown file and ignores
+// everything else. Items have no IDs, so they are referred to by line.
+func readMarkdown(r io.Reader) ([]*record, []*LineError, error) {
+	type open struct {
+		indent int
+		rec    *record
+	}
+	var records []*record
+	var errs []*LineError
+	// stack holds the items the next line may be nested in
+	var stack []open
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.ReplaceAll(scanner.Text(), "\t", "    ")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		m := markdownItem.FindStringSubmatch(text)
+		if m == nil {
+			// Text indented below an item is its description; anything else
+			// ends the list
+			if len(stack) == 0 {
+				continue
+			}
+			t := stack[len(stack)-1].rec.task
+			if t.Description != "" {
+				t.Description += "\n"
+			}
+			t.Description += strings.TrimSpace(text)
+			continue
+		}
+
+		rec, err := markdownRecord(m[3])
+		if err != nil {
+			errs = append(errs, &LineError{Line: line, Err: err})
+			continue
+		}
+		rec.line = line
+		rec.ref = strconv.Itoa(line)
+		rec.task.Done = m[2] != " "
+		if len(stack) > 0 {
+			rec.parent = stack[len(stack)-1].rec.ref
+		}
+		records = append(records, rec)
+		stack = append(stack, open{indent: indent, rec: rec})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("taskmanager: %w", err)
+	}
+	return records, errs, nil
+}
+
+func markdownRecord(text string) (*record, error) {
+	t := &Task{}
+	var title []string
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, "#") && len(word) > 1 {
+			t.Tags = append(t.Tags, word[1:])
+			continue
+		}
+		if strings.HasPrefix(word, "!") {
+			if p, err := ParsePriority(word[1:]); err == nil {
+				t.Priority = p
+				continue
+			}
+		}
+		key, value, _ := strings.Cut(word, ":")
+		switch {
+		case value == "":
+			title = append(title, word)
+		case key == "due" || key == "done":
+			d, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s date %q", key, value)
+			}
+			if key == "due" {
+				t.Due = &d
+			} else {
+				t.CompletedAt = &d
+			}
+		case key == "rrule":
+			t.Recurrence = value
+		default:
+			title = append(title, word)
+		}
+	}
+	t.Title = strings.Join(title, " ")
+	return &record{task: t}, nil
+}
@@ -0,0 +1,57 @@
+package taskmanager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority orders tasks by importance; the zero value is PriorityNone
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+var priorityNames = []string{"none", "low", "medium", "high"}
+
+func (p Priority) valid() bool {
+	return p >= PriorityNone && p <= PriorityHigh
+}
+
+func (p Priority) String() string {
+	if !p.valid() {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// ParsePriority parses a priority name such as "high", ignoring case
+func ParsePriority(s string) (Priority, error) {
+	for i, name := range priorityNames {
+		if strings.EqualFold(s, name) {
+			return Priority(i), nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("%w %q", ErrInvalidPriority, s)
+}
+
+// MarshalText writes the priority name, so task files read "high" rather
+// than 3
+func (p Priority) MarshalText() ([]byte, error) {
+	if !p.valid() {
+		return nil, fmt.Errorf("%w %d", ErrInvalidPriority, int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+func (p *Priority) UnmarshalText(text []byte) error {
+	parsed, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
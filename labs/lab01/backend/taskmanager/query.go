@@ -0,0 +1,156 @@
+package taskmanager
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SortField is a task field ListTasks can sort on
+type SortField int
+
+const (
+	SortByID SortField = iota
+	SortByTitle
+	SortByPriority
+	SortByDue
+	SortByCreated
+	SortByUpdated
+	SortByCompleted
+)
+
+// SortKey sorts on one field. Tasks without a due or completion date sort
+// after those with one, in either direction.
+type SortKey struct {
+	Field      SortField
+	Descending bool
+}
+
+// Query selects, orders and pages tasks for ListTasks. The zero value
+// lists all tasks by ID.
+type Query struct {
+	Done *bool
+	// Tags keeps tasks having all of these tags
+	Tags []string
+	// MinPriority keeps tasks of at least this priority
+	MinPriority Priority
+	// Overdue keeps open tasks due before Now
+	Overdue bool
+	// DueBefore, if set, keeps tasks due before it
+	DueBefore time.Time
+	// Text keeps tasks whose title or description contains it, ignoring case
+	Text string
+
+	// SortBy orders the results, ties broken by the following keys and
+	// finally by ID
+	SortBy []SortKey
+	Offset int
+	// Limit caps the number of results; 0 means no limit
+	Limit int
+
+	// Now is the reference time for Overdue; zero means time.Now()
+	Now time.Time
+}
+
+// ListTasks returns copies of the tasks matching q
+func (tm *TaskManager) ListTasks(q Query) []*Task {
+	now := q.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	tm.mu.RLock()
+	var result []*Task
+	for _, task := range tm.tasks {
+		if q.matches(task, now) {
+			result = append(result, task.clone())
+		}
+	}
+	tm.mu.RUnlock()
+
+	slices.SortFunc(result, func(a, b *Task) int {
+		for _, key := range q.SortBy {
+			if c := compareTasks(a, b, key); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	if q.Offset > 0 {
+		result = result[min(q.Offset, len(result)):]
+	}
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result
+}
+
+func (q *Query) matches(t *Task, now time.Time) bool {
+	switch {
+	case q.Done != nil && t.Done != *q.Done:
+		return false
+	case t.Priority < q.MinPriority:
+		return false
+	case q.Overdue && !t.Overdue(now):
+		return false
+	case !q.DueBefore.IsZero() && (t.Due == nil || !t.Due.Before(q.DueBefore)):
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !t.HasTag(tag) {
+			return false
+		}
+	}
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		if !strings.Contains(strings.ToLower(t.Title), text) && !strings.Contains(strings.ToLower(t.Description), text) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareTasks(a, b *Task, key SortKey) int {
+	var c int
+	switch key.Field {
+	case SortByTitle:
+		c = cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	case SortByPriority:
+		c = cmp.Compare(a.Priority, b.Priority)
+	case SortByDue:
+		if c, ok := compareMissing(a.Due, b.Due); !ok {
+			return c
+		}
+		c = a.Due.Compare(*b.Due)
+	case SortByCreated:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case SortByUpdated:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	case SortByCompleted:
+		if c, ok := compareMissing(a.CompletedAt, b.CompletedAt); !ok {
+			return c
+		}
+		c = a.CompletedAt.Compare(*b.CompletedAt)
+	default:
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	if key.Descending {
+		return -c
+	}
+	return c
+}
+
+// compareMissing orders nil times last; ok is true when both are set
+func compareMissing(a, b *time.Time) (int, bool) {
+	switch {
+	case a == nil && b == nil:
+		return 0, false
+	case a == nil:
+		return 1, false
+	case b == nil:
+		return -1, false
+	}
+	return 0, true
+}
@@ -0,0 +1,127 @@
+package taskmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var day = time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+func ids(tasks []*Task) []int {
+	var result []int
+	for _, task := range tasks {
+		result = append(result, task.ID)
+	}
+	return result
+}
+
+func newQueryFixture(t *testing.T) *TaskManager {
+	t.Helper()
+	tm := NewTaskManager()
+	add := func(title, description string, opts ...TaskOption) {
+		if _, err := tm.AddTask(title, description, opts...); err != nil {
+			t.Fatalf("Failed to add %q: %v", title, err)
+		}
+	}
+	add("Drink water", "8 glasses", WithPriority(PriorityHigh), WithTags("Health", "daily"), WithDue(day.Add(-time.Hour)))
+	add("Sleep 8 hours", "", WithPriority(PriorityMedium), WithTags("health"), WithDue(day.Add(48*time.Hour)))
+	add("Read a book", "twenty pages", WithTags("fun"))
+	add("Stretch", "", WithPriority(PriorityLow), WithDue(day.Add(-48*time.Hour)))
+	tm.UpdateTask(4, TaskUpdate{Done: ptr(true)})
+	return tm
+}
+
+func TestListTasksQuery(t *testing.T) {
+	tm := newQueryFixture(t)
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected []int
+	}{
+		{"all by id", Query{}, []int{1, 2, 3, 4}},
+		{"open", Query{Done: ptr(false)}, []int{1, 2, 3}},
+		{"tag ignores case", Query{Tags: []string{"HEALTH"}}, []int{1, 2}},
+		{"all tags", Query{Tags: []string{"health", "daily"}}, []int{1}},
+		{"overdue skips done", Query{Overdue: true, Now: day}, []int{1}},
+		{"due before", Query{DueBefore: day}, []int{1, 4}},
+		{"text in title", Query{Text: "SLEEP"}, []int{2}},
+		{"text in description", Query{Text: "pages"}, []int{3}},
+		{"min priority", Query{MinPriority: PriorityMedium}, []int{1, 2}},
+		{"priority desc", Query{SortBy: []SortKey{{Field: SortByPriority, Descending: true}}}, []int{1, 2, 4, 3}},
+		{"due, missing last", Query{SortBy: []SortKey{{Field: SortByDue}}}, []int{4, 1, 2, 3}},
+		{"due desc, missing last", Query{SortBy: []SortKey{{Field: SortByDue, Descending: true}}}, []int{2, 1, 4, 3}},
+		{"title", Query{SortBy: []SortKey{{Field: SortByTitle}}}, []int{1, 3, 2, 4}},
+		{"multi-key", Query{SortBy: []SortKey{{Field: SortByPriority}, {Field: SortByID, Descending: true}}}, []int{3, 4, 2, 1}},
+		{"offset and limit", Query{Offset: 1, Limit: 2}, []int{2, 3}},
+		{"offset past end", Query{Offset: 10}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(tm.ListTasks(tt.query)); !slices.Equal(got, tt.expected) {
+				t.Errorf("ListTasks() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPartialUpdate(t *testing.T) {
+	tm := newQueryFixture(t)
+	before, _ := tm.GetTask(1)
+
+	got, err := tm.UpdateTask(1, TaskUpdate{Description: ptr("10 glasses")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.Title != before.Title || got.Priority != PriorityHigh || !slices.Equal(got.Tags, []string{"daily", "health"}) {
+		t.Errorf("Untouched fields changed: %+v", got)
+	}
+	if got.Description != "10 glasses" {
+		t.Errorf("Expected description to change, got %q", got.Description)
+	}
+	if got.UpdatedAt.Before(before.UpdatedAt) {
+		t.Errorf("UpdatedAt went backwards")
+	}
+
+	got, _ = tm.UpdateTask(1, TaskUpdate{Done: ptr(true), ClearDue: true, Tags: &[]string{}})
+	if got.CompletedAt == nil || got.Due != nil || len(got.Tags) != 0 {
+		t.Errorf("Expected completed task without due date or tags: %+v", got)
+	}
+	got, _ = tm.UpdateTask(1, TaskUpdate{Done: ptr(false)})
+	if got.CompletedAt != nil {
+		t.Errorf("Reopening should clear CompletedAt")
+	}
+
+	if _, err := tm.UpdateTask(1, TaskUpdate{Title: ptr("")}); err != ErrEmptyTitle {
+		t.Errorf("Expected ErrEmptyTitle, got %v", err)
+	}
+	if _, err := tm.UpdateTask(1, TaskUpdate{Priority: ptr(Priority(9))}); err != ErrInvalidPriority {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+	if _, err := tm.AddTask("Bad", "", WithPriority(-1)); err != ErrInvalidPriority {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+}
+
+func TestPriorityText(t *testing.T) {
+	data, err := json.Marshal(&Task{ID: 1, Title: "a", Priority: PriorityHigh})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"priority":"high"`) {
+		t.Errorf("Expected priority name in %s", data)
+	}
+
+	var task Task
+	if err := json.Unmarshal([]byte(`{"priority": "Medium"}`), &task); err != nil || task.Priority != PriorityMedium {
+		t.Errorf("Unmarshal = %v, %v", task.Priority, err)
+	}
+	if _, err := ParsePriority("urgent"); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+}
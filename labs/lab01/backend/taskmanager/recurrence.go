@@ -0,0 +1,293 @@
+package taskmanager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+type Frequency int
+
+const (
+	Daily Frequency = iota + 1
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyNames = map[Frequency]string{Daily: "DAILY", Weekly: "WEEKLY", Monthly: "MONTHLY", Yearly: "YEARLY"}
+
+var weekdayNames = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Recurrence is a repetition rule, the subset of an RFC 5545 RRULE with
+// FREQ, INTERVAL, BYDAY (weekly), BYMONTHDAY (monthly), COUNT and UNTIL.
+// Weeks start on Monday.
+type Recurrence struct {
+	Frequency Frequency
+	// Interval repeats every Interval days, weeks, months or years
+	Interval int
+	// ByDay lists the weekdays of a weekly rule; empty means the weekday
+	// of the start
+	ByDay []time.Weekday
+	// ByMonthDay lists the days of a monthly rule, negative counting from
+	// the end of the month; empty means the day of the start. Months
+	// without the day are skipped.
+	ByMonthDay []int
+	// Count is the number of occurrences, including the current one; 0 is
+	// unlimited
+	Count int
+	// Until, if set, is the last time an occurrence may fall on
+	Until time.Time
+}
+
+// ParseRecurrence parses a rule such as "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+// with or without the "RRULE:" prefix
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w %q: %s", ErrInvalidRecurrence, rule, fmt.Sprintf(format, args...))
+	}
+	r := &Recurrence{Interval: 1}
+	body := strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	for _, part := range strings.Split(body, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, invalid("expected KEY=VALUE, got %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			for f, name := range frequencyNames {
+				if strings.EqualFold(value, name) {
+					r.Frequency = f
+				}
+			}
+			if r.Frequency == 0 {
+				return nil, invalid("unsupported FREQ %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return nil, invalid("INTERVAL must be a positive integer")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return nil, invalid("COUNT must be a positive integer")
+			}
+		case "UNTIL":
+			if r.Until, err = time.Parse("20060102T150405Z", value); err != nil {
+				// A date alone includes the whole day
+				date, err := time.Parse("20060102", value)
+				if err != nil {
+					return nil, invalid("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ")
+				}
+				r.Until = date.Add(24*time.Hour - time.Second)
+			}
+		case "BYDAY":
+			for _, name := range strings.Split(value, ",") {
+				i := slices.Index(weekdayNames, strings.ToUpper(name))
+				if i < 0 {
+					return nil, invalid("unsupported BYDAY %s", name)
+				}
+				if !slices.Contains(r.ByDay, time.Weekday(i)) {
+					r.ByDay = append(r.ByDay, time.Weekday(i))
+				}
+			}
+			slices.SortFunc(r.ByDay, func(a, b time.Weekday) int { return fromMonday(a) - fromMonday(b) })
+		case "BYMONTHDAY":
+			for _, s := range strings.Split(value, ",") {
+				day, err := strconv.Atoi(s)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, invalid("BYMONTHDAY must be 1 to 31 or -31 to -1")
+				}
+				if !slices.Contains(r.ByMonthDay, day) {
+					r.ByMonthDay = append(r.ByMonthDay, day)
+				}
+			}
+		default:
+			return nil, invalid("unsupported part %s", key)
+		}
+	}
+
+	switch {
+	case r.Frequency == 0:
+		return nil, invalid("FREQ is required")
+	case r.Count > 0 && !r.Until.IsZero():
+		return nil, invalid("COUNT and UNTIL cannot be combined")
+	case len(r.ByDay) > 0 && r.Frequency != Weekly:
+		return nil, invalid("BYDAY is only supported with FREQ=WEEKLY")
+	case len(r.ByMonthDay) > 0 && r.Frequency != Monthly:
+		return nil, invalid("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return r, nil
+}
+
+// String returns the rule in RRULE syntax, without the "RRULE:" prefix
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + frequencyNames[r.Frequency]}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = weekdayNames[d]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// maxPeriods bounds the search for an occurrence, for rules such as
+// February 29th every year that match rarely
+const maxPeriods = 1000
+
+// Next returns the first occurrence of the rule started at start that
+// falls after the given time, keeping the clock time and location of start.
+// ok is false when the rule has ended.
+func (r *Recurrence) Next(start, after time.Time) (next time.Time, ok bool) {
+	first := r.firstPeriod(start, after)
+	for k := first; k < first+maxPeriods; k++ {
+		for _, t := range r.period(start, k) {
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return time.Time{}, false
+			}
+			if t.After(after) && !t.Before(start) {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// firstPeriod estimates, from below, the index of the period holding the
+// first occurrence after the given time
+func (r *Recurrence) firstPeriod(start, after time.Time) int {
+	if !after.After(start) {
+		return 0
+	}
+	var periods int
+	switch r.Frequency {
+	case Daily:
+		periods = int(after.Sub(start).Hours()/24) / r.Interval
+	case Weekly:
+		periods = int(after.Sub(start).Hours()/24/7) / r.Interval
+	case Monthly:
+		periods = ((after.Year()-start.Year())*12 + int(after.Month()-start.Month())) / r.Interval
+	case Yearly:
+		periods = (after.Year() - start.Year()) / r.Interval
+	}
+	return max(0, periods-1)
+}
+
+// period returns the occurrences in the k-th period after start, sorted
+func (r *Recurrence) period(start time.Time, k int) []time.Time {
+	n := k * r.Interval
+	switch r.Frequency {
+	case Daily:
+		return []time.Time{start.AddDate(0, 0, n)}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{start.AddDate(0, 0, 7*n)}
+		}
+		monday := start.AddDate(0, 0, 7*n-fromMonday(start.Weekday()))
+		result := make([]time.Time, len(r.ByDay))
+		for i, d := range r.ByDay {
+			result[i] = monday.AddDate(0, 0, fromMonday(d))
+		}
+		return result
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1,
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		daysIn := first.AddDate(0, 1, -1).Day()
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{start.Day()}
+		}
+		var result []time.Time
+		for _, d := range days {
+			if d < 0 {
+				d = daysIn + d + 1
+			}
+			if d >= 1 && d <= daysIn {
+				result = append(result, first.AddDate(0, 0, d-1))
+			}
+		}
+		slices.SortFunc(result, time.Time.Compare)
+		return slices.CompactFunc(result, time.Time.Equal)
+	case Yearly:
+		t := time.Date(start.Year()+n, start.Month(), start.Day(),
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		if t.Month() != start.Month() {
+			// February 29th outside a leap year
+			return nil
+		}
+		return []time.Time{t}
+	}
+	return nil
+}
+
+// fromMonday numbers weekdays from Monday = 0
+func fromMonday(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
+
+// nextOccurrence returns the task that follows t, completed at now, or nil
+// if t does not recur or its rule has ended. The next occurrence is the
+// first one after both the due date and the completion time, so a habit
+// finished late does not leave a backlog of missed days. It stays under
+// the same parent and waits for the same tasks.
+func (t *Task) nextOccurrence(now time.Time) *Task {
+	if t.Recurrence == "" {
+		return nil
+	}
+	r, err := ParseRecurrence(t.Recurrence)
+	if err != nil || r.Count == 1 {
+		return nil
+	}
+	start := now
+	if t.Due != nil {
+		start = *t.Due
+	}
+	after := start
+	if now.After(after) {
+		after = now
+	}
+	due, ok := r.Next(start, after)
+	if !ok {
+		return nil
+	}
+	if r.Count > 0 {
+		r.Count--
+	}
+	return &Task{
+		Title:       t.Title,
+		Description: t.Description,
+		Priority:    t.Priority,
+		Tags:        slices.Clone(t.Tags),
+		Due:         &due,
+		Recurrence:  r.String(),
+		ParentID:    t.ParentID,
+		BlockedBy:   slices.Clone(t.BlockedBy),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
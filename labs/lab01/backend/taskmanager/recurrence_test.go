@@ -0,0 +1,176 @@
+package taskmanager
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule        string
+		expected    string
+		expectError bool
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY", false},
+		{"RRULE:freq=weekly;byday=fr,mo;interval=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1,15", "FREQ=MONTHLY;BYMONTHDAY=-1,15", false},
+		{"FREQ=DAILY;COUNT=3", "FREQ=DAILY;COUNT=3", false},
+		{"FREQ=YEARLY;UNTIL=20300101T000000Z", "FREQ=YEARLY;UNTIL=20300101T000000Z", false},
+		{"FREQ=DAILY;UNTIL=20300101", "FREQ=DAILY;UNTIL=20300101T235959Z", false},
+		{"", "", true},
+		{"FREQ=HOURLY", "", true},
+		{"INTERVAL=2", "", true},
+		{"FREQ=DAILY;INTERVAL=0", "", true},
+		{"FREQ=DAILY;BYDAY=MO", "", true},
+		{"FREQ=WEEKLY;BYDAY=XX", "", true},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", "", true},
+		{"FREQ=DAILY;COUNT=2;UNTIL=20300101", "", true},
+		{"FREQ=DAILY;WKST=SU", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if tt.expectError {
+				if !errors.Is(err, ErrInvalidRecurrence) {
+					t.Errorf("Expected ErrInvalidRecurrence, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := r.String(); got != tt.expected {
+				t.Errorf("String() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	// Wednesday
+	start := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule     string
+		after    time.Time
+		expected time.Time
+	}{
+		{"FREQ=DAILY", start, start.AddDate(0, 0, 1)},
+		{"FREQ=DAILY;INTERVAL=3", start.AddDate(0, 0, 100), start.AddDate(0, 0, 102)},
+		{"FREQ=DAILY", start.Add(-time.Hour), start},
+		{"FREQ=WEEKLY", start, start.AddDate(0, 0, 7)},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", start, time.Date(2025, 1, 17, 9, 0, 0, 0, time.UTC)},
+		{"FREQ=WEEKLY;BYDAY=MO,TU", start, time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", start, time.Date(2025, 1, 27, 9, 0, 0, 0, time.UTC)},
+		{"FREQ=MONTHLY", start, time.Date(2025, 2, 15, 9, 0, 0, 0, time.UTC)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", start, time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC)},
+		{"FREQ=MONTHLY;BYMONTHDAY=30", time.Date(2025, 1, 30, 9, 0, 0, 0, time.UTC), time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC)},
+		{"FREQ=YEARLY", start.AddDate(3, 0, 0), start.AddDate(4, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := r.Next(start, tt.after)
+			if !ok || !got.Equal(tt.expected) {
+				t.Errorf("Next(%v) = %v, %v, want %v", tt.after, got, ok, tt.expected)
+			}
+		})
+	}
+
+	leap := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	r, _ := ParseRecurrence("FREQ=YEARLY")
+	if got, _ := r.Next(leap, leap); !got.Equal(leap.AddDate(4, 0, 0)) {
+		t.Errorf("Expected next February 29th in 2028, got %v", got)
+	}
+
+	r, _ = ParseRecurrence("FREQ=DAILY;UNTIL=20250116")
+	if _, ok := r.Next(start, start.AddDate(0, 0, 1)); ok {
+		t.Error("Expected no occurrence after UNTIL")
+	}
+}
+
+func TestCompleteRecurringTask(t *testing.T) {
+	tm := NewTaskManager()
+	due := time.Now().Add(time.Hour).Truncate(time.Second)
+	task, err := tm.AddTask("Drink water", "", WithDue(due), WithTags("health"), WithRecurrence("freq=daily;count=2"))
+	if err != nil {
+		t.Fatalf("Failed to add: %v", err)
+	}
+	if task.Recurrence != "FREQ=DAILY;COUNT=2" {
+		t.Errorf("Expected normalized rule, got %s", task.Recurrence)
+	}
+
+	done, next, err := tm.CompleteTask(task.ID)
+	if err != nil {
+		t.Fatalf("Failed to complete: %v", err)
+	}
+	if !done.Done || next == nil {
+		t.Fatalf("Expected done task and next occurrence, got %+v, %+v", done, next)
+	}
+	if next.Done || !next.Due.Equal(due.AddDate(0, 0, 1)) || !next.HasTag("health") {
+		t.Errorf("Unexpected next occurrence: %+v", next)
+	}
+	if next.Recurrence != "FREQ=DAILY;COUNT=1" {
+		t.Errorf("Expected COUNT to count down, got %s", next.Recurrence)
+	}
+
+	// Completing again through UpdateTask does not add another occurrence
+	if _, err := tm.UpdateTask(task.ID, TaskUpdate{Done: ptr(true)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, last, _ := tm.CompleteTask(next.ID); last != nil {
+		t.Errorf("Expected the rule to end after COUNT occurrences, got %+v", last)
+	}
+	if n := len(tm.ListTasks(Query{})); n != 2 {
+		t.Errorf("Expected 2 tasks, got %d", n)
+	}
+
+	if _, err := tm.AddTask("Bad", "", WithRecurrence("FREQ=SOMETIMES")); !errors.Is(err, ErrInvalidRecurrence) {
+		t.Errorf("Expected ErrInvalidRecurrence, got %v", err)
+	}
+}
+
+func TestCompleteLateRecurringTask(t *testing.T) {
+	tm := NewTaskManager()
+	due := time.Now().AddDate(0, 0, -3)
+	task, _ := tm.AddTask("Stretch", "", WithDue(due), WithRecurrence("FREQ=DAILY"))
+
+	_, next, _ := tm.CompleteTask(task.ID)
+	if next == nil || !next.Due.After(time.Now()) || next.Due.After(time.Now().AddDate(0, 0, 1)) {
+		t.Errorf("Expected the next occurrence within a day, got %+v", next)
+	}
+	if next.Due.Hour() != due.Hour() || next.Due.Minute() != due.Minute() {
+		t.Errorf("Expected the clock time of the original due date, got %v", next.Due)
+	}
+}
+
+func TestRecurringSubtaskKeepsParentAndBlockers(t *testing.T) {
+	tm := NewTaskManager()
+	parent, _ := tm.AddTask("Marathon training", "")
+	blocker, _ := tm.AddTask("Buy shoes", "")
+	task, _ := tm.AddTask("Run", "", WithParent(parent.ID), WithRecurrence("FREQ=DAILY"))
+	if err := tm.AddDependency(task.ID, blocker.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, next, err := tm.CompleteTask(task.ID)
+	if err != nil {
+		t.Fatalf("Failed to complete: %v", err)
+	}
+	if next.ParentID != parent.ID {
+		t.Errorf("Expected parent %d, got %d", parent.ID, next.ParentID)
+	}
+	if !slices.Equal(next.BlockedBy, []int{blocker.ID}) {
+		t.Errorf("Expected blockers [%d], got %v", blocker.ID, next.BlockedBy)
+	}
+	if subtasks, _ := tm.Subtasks(parent.ID); len(subtasks) != 2 {
+		t.Errorf("Expected both occurrences under the parent, got %d", len(subtasks))
+	}
+}
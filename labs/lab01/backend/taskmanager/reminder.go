@@ -0,0 +1,145 @@
+package taskmanager
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time; tests replace it to control the Scheduler
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Reminder tells that a task is due. At is when the reminder was due, the
+// task's due date minus the lead time.
+type Reminder struct {
+	Task *Task
+	At   time.Time
+}
+
+// Scheduler sends a Reminder for every open task with a due date once the
+// due date minus the lead time has passed. A task is reminded once per due
+// date; changing the due date reminds it again.
+type Scheduler struct {
+	tm       *TaskManager
+	clock    Clock
+	lead     time.Duration
+	poll     time.Duration
+	callback func(Reminder)
+	c        chan Reminder
+	sent     map[int]time.Time
+}
+
+type SchedulerOption func(*Scheduler)
+
+// WithClock replaces the system clock
+func WithClock(c Clock) SchedulerOption {
+	return func(s *Scheduler) { s.clock = c }
+}
+
+// WithLeadTime sends reminders d before tasks are due
+func WithLeadTime(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) { s.lead = d }
+}
+
+// WithPollInterval sets how often the scheduler looks for new or changed
+// tasks; the default is one minute, which also replaces intervals that are
+// not positive
+func WithPollInterval(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) { s.poll = d }
+}
+
+// WithCallback calls f for each reminder instead of sending it on C
+func WithCallback(f func(Reminder)) SchedulerOption {
+	return func(s *Scheduler) { s.callback = f }
+}
+
+func NewScheduler(tm *TaskManager, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		tm:    tm,
+		clock: realClock{},
+		poll:  time.Minute,
+		c:     make(chan Reminder),
+		sent:  make(map[int]time.Time),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.poll <= 0 {
+		s.poll = time.Minute
+	}
+	return s
+}
+
+// C delivers the reminders when no callback is set
+func (s *Scheduler) C() <-chan Reminder {
+	return s.c
+}
+
+// Run sends reminders until ctx is done and returns ctx.Err()
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		now := s.clock.Now()
+		next := now.Add(s.poll)
+		open := false
+		tasks := s.tm.ListTasks(Query{Done: &open, Now: now})
+		s.forgetMissing(tasks)
+		for _, task := range tasks {
+			if task.Due == nil {
+				continue
+			}
+			if sent, ok := s.sent[task.ID]; ok && sent.Equal(*task.Due) {
+				continue
+			}
+			at := task.Due.Add(-s.lead)
+			if at.After(now) {
+				if at.Before(next) {
+					next = at
+				}
+				continue
+			}
+			if err := s.send(ctx, Reminder{Task: task, At: at}); err != nil {
+				return err
+			}
+			s.sent[task.ID] = *task.Due
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(next.Sub(now)):
+		}
+	}
+}
+
+// forgetMissing drops the reminders sent for tasks that are no longer open
+func (s *Scheduler) forgetMissing(open []*Task) {
+	keep := make(map[int]bool, len(open))
+	for _, task := range open {
+		keep[task.ID] = true
+	}
+	for id := range s.sent {
+		if !keep[id] {
+			delete(s.sent, id)
+		}
+	}
+}
+
+func (s *Scheduler) send(ctx context.Context, r Reminder) error {
+	if s.callback != nil {
+		s.callback(r)
+		return ctx.Err()
+	}
+	select {
+	case s.c <- r:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
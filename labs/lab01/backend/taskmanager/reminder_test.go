@@ -0,0 +1,169 @@
+package taskmanager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when Advance is called. Waiting tells when the
+// scheduler has started waiting on it.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []fakeTimer
+	waiting chan struct{}
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiting: make(chan struct{}, 100)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, timer)
+	c.waiting <- struct{}{}
+	return timer.c
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	var pending []fakeTimer
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+		} else {
+			timer.c <- c.now
+		}
+	}
+	c.timers = pending
+}
+
+func (c *fakeClock) waitForScheduler(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.waiting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Scheduler did not wait on the clock")
+	}
+}
+
+func expectNoReminder(t *testing.T, s *Scheduler) {
+	t.Helper()
+	select {
+	case r := <-s.C():
+		t.Fatalf("Unexpected reminder for task %d", r.Task.ID)
+	default:
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	start := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	tm := NewTaskManager()
+	water, _ := tm.AddTask("Drink water", "", WithDue(start.Add(30*time.Minute)))
+	sleep, _ := tm.AddTask("Sleep", "", WithDue(start.Add(14*time.Hour)))
+	tm.AddTask("Read", "")
+
+	s := NewScheduler(tm, WithClock(clock), WithLeadTime(10*time.Minute), WithPollInterval(time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	clock.waitForScheduler(t)
+	expectNoReminder(t, s)
+
+	// The scheduler wakes at the first reminder, not at the poll interval
+	clock.Advance(20 * time.Minute)
+	r := <-s.C()
+	if r.Task.ID != water.ID || !r.At.Equal(start.Add(20*time.Minute)) {
+		t.Errorf("Unexpected reminder: task %d at %v", r.Task.ID, r.At)
+	}
+	clock.waitForScheduler(t)
+	expectNoReminder(t, s)
+
+	// Moving the due date reminds again
+	tm.UpdateTask(water.ID, TaskUpdate{Due: ptr(start.Add(2 * time.Hour))})
+	clock.Advance(time.Hour)
+	clock.waitForScheduler(t)
+	clock.Advance(time.Hour)
+	if r := <-s.C(); r.Task.ID != water.ID {
+		t.Errorf("Expected a second reminder for task %d, got %d", water.ID, r.Task.ID)
+	}
+	clock.waitForScheduler(t)
+
+	// Done tasks are not reminded
+	tm.UpdateTask(sleep.ID, TaskUpdate{Done: ptr(true)})
+	clock.Advance(24 * time.Hour)
+	clock.waitForScheduler(t)
+	expectNoReminder(t, s)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSchedulerCallback(t *testing.T) {
+	start := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	tm := NewTaskManager()
+	tm.AddTask("Overdue", "", WithDue(start.Add(-time.Hour)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var got []Reminder
+	s := NewScheduler(tm, WithClock(clock), WithCallback(func(r Reminder) {
+		got = append(got, r)
+		cancel()
+	}))
+
+	if err := s.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(got) != 1 || got[0].Task.Title != "Overdue" {
+		t.Errorf("Expected one reminder for the overdue task, got %+v", got)
+	}
+}
+
+func TestSchedulerNonPositivePollInterval(t *testing.T) {
+	start := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	tm := NewTaskManager()
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		clock := newFakeClock(start)
+		s := NewScheduler(tm, WithClock(clock), WithPollInterval(d))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- s.Run(ctx) }()
+		clock.waitForScheduler(t)
+
+		clock.mu.Lock()
+		at := clock.timers[0].at
+		clock.mu.Unlock()
+		if want := start.Add(time.Minute); !at.Equal(want) {
+			t.Errorf("WithPollInterval(%v): expected to wait until %v, got %v", d, want, at)
+		}
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package taskmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+var ErrCorruptFile = errors.New("corrupt task file")
+
+// snapshotVersion is the format version written to task files
+const snapshotVersion = 1
+
+// Snapshot is the saved state of a TaskManager
+type Snapshot struct {
+	Version int     `json:"version"`
+	NextID  int     `json:"next_id"`
+	Tasks   []*Task `json:"tasks"`
+	// Seq is the sequence number of the last event included
+	Seq int64 `json:"seq,omitempty"`
+	// Undo and Redo are the events Undo and Redo would revert or repeat,
+	// the next one last
+	Undo []*Event `json:"undo,omitempty"`
+	Redo []*Event `json:"redo,omitempty"`
+}
+
+// Store saves and loads TaskManager state
+type Store interface {
+	// Load returns the saved state, or nil if nothing was saved yet
+	Load() (*Snapshot, error)
+	Save(*Snapshot) error
+}
+
+// FileStore keeps the state in a JSON file. Saves write a temporary file
+// next to it and rename it into place, so a crash leaves either the old or
+// the new file, never a partial one.
+type FileStore struct {
+	Path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+func (s *FileStore) Load() (*Snapshot, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("taskmanager: %w", err)
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("taskmanager: %w %s: %v", ErrCorruptFile, s.Path, err)
+	}
+	if err := snap.validate(); err != nil {
+		return nil, fmt.Errorf("taskmanager: %w %s: %v", ErrCorruptFile, s.Path, err)
+	}
+	return &snap, nil
+}
+
+func (s *FileStore) Save(snap *Snapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+
+	dir := filepath.Dir(s.Path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("taskmanager: %w", err)
+	}
+	// Make the rename itself durable; not every platform can sync a directory
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// validate checks the invariants a TaskManager relies on
+func (snap *Snapshot) validate() error {
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported version %d", snap.Version)
+	}
+	seen := make(map[int]bool, len(snap.Tasks))
+	for i, task := range snap.Tasks {
+		switch {
+		case task == nil:
+			return fmt.Errorf("task %d is null", i)
+		case task.ID <= 0:
+			return fmt.Errorf("task %d has invalid id %d", i, task.ID)
+		case seen[task.ID]:
+			return fmt.Errorf("duplicate task id %d", task.ID)
+		case task.Title == "":
+			return fmt.Errorf("task %d: %v", task.ID, ErrEmptyTitle)
+		case task.ID >= snap.NextID:
+			return fmt.Errorf("task id %d is not below next_id %d", task.ID, snap.NextID)
+		}
+		if _, err := normalizeRecurrence(task.Recurrence); err != nil {
+			return fmt.Errorf("task %d: %v", task.ID, err)
+		}
+		seen[task.ID] = true
+	}
+	if snap.NextID < 1 {
+		return fmt.Errorf("invalid next_id %d", snap.NextID)
+	}
+
+	tasks := make(map[int]*Task, len(snap.Tasks))
+	for _, task := range snap.Tasks {
+		tasks[task.ID] = task
+	}
+	for _, task := range snap.Tasks {
+		if _, ok := tasks[task.ParentID]; task.ParentID != 0 && !ok {
+			return fmt.Errorf("task %d: parent %d does not exist", task.ID, task.ParentID)
+		}
+		for _, id := range task.BlockedBy {
+			if _, ok := tasks[id]; !ok {
+				return fmt.Errorf("task %d: blocker %d does not exist", task.ID, id)
+			}
+		}
+	}
+	if _, err := topologicalOrder(tasks); err != nil {
+		return err
+	}
+	return nil
+}
+
+// snapshot copies the state; the caller holds tm.mu
+func (tm *TaskManager) snapshot() *Snapshot {
+	snap := &Snapshot{
+		Version: snapshotVersion,
+		NextID:  tm.nextID,
+		Tasks:   make([]*Task, 0, len(tm.tasks)),
+		Seq:     tm.seq,
+		Undo:    slices.Clone(tm.undo),
+		Redo:    slices.Clone(tm.redo),
+	}
+	for _, task := range tm.tasks {
+		snap.Tasks = append(snap.Tasks, task.clone())
+	}
+	sort.Slice(snap.Tasks, func(i, j int) bool { return snap.Tasks[i].ID < snap.Tasks[j].ID })
+	return snap
+}
+
+// restore replaces the state with a copy of snap; the caller holds tm.mu
+// or has not shared tm yet
+func (tm *TaskManager) restore(snap *Snapshot) {
+	tm.tasks = make(map[int]*Task, len(snap.Tasks))
+	for _, task := range snap.Tasks {
+		tm.tasks[task.ID] = task.clone()
+	}
+	tm.nextID = snap.NextID
+	tm.seq, tm.snapshotSeq = snap.Seq, snap.Seq
+	tm.undo, tm.redo = snap.Undo, snap.Redo
+}
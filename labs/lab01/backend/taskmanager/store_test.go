@@ -0,0 +1,165 @@
+package taskmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	tm, err := OpenTaskManager(NewFileStore(path))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	if len(tm.ListTasks(Query{})) != 0 {
+		t.Error("Expected no tasks from a missing file")
+	}
+	first, _ := tm.AddTask("Drink water", "8 glasses")
+	second, _ := tm.AddTask("Sleep", "")
+	if _, err := tm.UpdateTask(first.ID, TaskUpdate{Done: ptr(true)}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := tm.DeleteTask(second.ID); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+
+	reopened, err := OpenTaskManager(NewFileStore(path))
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	got, err := reopened.GetTask(first.ID)
+	if err != nil {
+		t.Fatalf("Task lost on reopen: %v", err)
+	}
+	if got.Title != "Drink water" || !got.Done || !got.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("Reloaded task differs: %+v", got)
+	}
+	if _, err := reopened.GetTask(second.ID); err != ErrTaskNotFound {
+		t.Errorf("Deleted task came back: %v", err)
+	}
+
+	// nextID survives, so the deleted task's ID is not reused
+	third, _ := reopened.AddTask("Walk", "")
+	if third.ID != 3 {
+		t.Errorf("Expected ID 3 after reopen, got %d", third.ID)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the task file, found %d entries", len(entries))
+	}
+}
+
+func TestFileStoreCorrupt(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not json", "{tasks: "},
+		{"wrong version", `{"version": 7, "next_id": 1, "tasks": []}`},
+		{"duplicate id", `{"version": 1, "next_id": 3, "tasks": [{"id": 1, "title": "a"}, {"id": 1, "title": "b"}]}`},
+		{"id not below next_id", `{"version": 1, "next_id": 2, "tasks": [{"id": 2, "title": "a"}]}`},
+		{"empty title", `{"version": 1, "next_id": 2, "tasks": [{"id": 1, "title": ""}]}`},
+		{"null task", `{"version": 1, "next_id": 2, "tasks": [null]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := OpenTaskManager(NewFileStore(path)); !errors.Is(err, ErrCorruptFile) {
+				t.Errorf("Expected ErrCorruptFile, got %v", err)
+			}
+		})
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Load() (*Snapshot, error) { return nil, nil }
+func (failingStore) Save(*Snapshot) error     { return errors.New("disk full") }
+
+func TestSaveFailureRollsBack(t *testing.T) {
+	tm := NewTaskManager()
+	task, _ := tm.AddTask("Task", "")
+	tm.store = failingStore{}
+
+	if _, err := tm.AddTask("Another", ""); err == nil {
+		t.Error("Expected AddTask to fail")
+	}
+	if _, err := tm.UpdateTask(task.ID, TaskUpdate{Title: ptr("Renamed"), Done: ptr(true)}); err == nil {
+		t.Error("Expected UpdateTask to fail")
+	}
+	if err := tm.DeleteTask(task.ID); err == nil {
+		t.Error("Expected DeleteTask to fail")
+	}
+
+	tasks := tm.ListTasks(Query{})
+	if len(tasks) != 1 || tasks[0].Title != "Task" || tasks[0].Done {
+		t.Errorf("State changed despite failed saves: %+v", tasks)
+	}
+	if tm.nextID != 2 {
+		t.Errorf("Expected nextID 2, got %d", tm.nextID)
+	}
+}
+
+func TestGetTaskReturnsCopy(t *testing.T) {
+	tm := NewTaskManager()
+	added, _ := tm.AddTask("Task", "")
+	added.Title = "changed"
+
+	got, _ := tm.GetTask(added.ID)
+	got.Done = true
+	for _, task := range tm.ListTasks(Query{}) {
+		task.Description = "changed"
+	}
+
+	stored, _ := tm.GetTask(added.ID)
+	if stored.Title != "Task" || stored.Done || stored.Description != "" {
+		t.Errorf("Internal state was mutated: %+v", stored)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	tm, err := OpenTaskManager(NewFileStore(filepath.Join(t.TempDir(), "tasks.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				task, err := tm.AddTask("Task", "")
+				if err != nil {
+					t.Errorf("AddTask failed: %v", err)
+					return
+				}
+				tm.UpdateTask(task.ID, TaskUpdate{Done: ptr(true)})
+				tm.GetTask(task.ID)
+				tm.ListTasks(Query{})
+			}
+		}()
+	}
+	wg.Wait()
+
+	tasks := tm.ListTasks(Query{})
+	if len(tasks) != 80 {
+		t.Errorf("Expected 80 tasks, got %d", len(tasks))
+	}
+	seen := make(map[int]bool)
+	for _, task := range tasks {
+		if seen[task.ID] {
+			t.Errorf("Duplicate ID %d", task.ID)
+		}
+		seen[task.ID] = true
+	}
+}
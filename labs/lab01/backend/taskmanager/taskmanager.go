@@ -2,86 +2,380 @@ package taskmanager
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
-	ErrEmptyTitle   = errors.New("title cannot be empty")
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrEmptyTitle      = errors.New("title cannot be empty")
+	ErrInvalidPriority = errors.New("invalid priority")
 )
 
 type Task struct {
-	ID          int
-	Title       string
-	Description string
-	Done        bool
-	CreatedAt   time.Time
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Done        bool       `json:"done"`
+	Priority    Priority   `json:"priority,omitempty"`
+	Due         *time.Time `json:"due,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	// Recurrence is an RRULE, see ParseRecurrence. Completing a recurring
+	// task adds its next occurrence.
+	Recurrence string `json:"recurrence,omitempty"`
+	// ParentID is the task this is a subtask of, 0 for none
+	ParentID int `json:"parent_id,omitempty"`
+	// BlockedBy lists the tasks that must be done before this one
+	BlockedBy   []int      `json:"blocked_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
 
+// TaskUpdate lists the fields UpdateTask changes; nil fields are kept
+type TaskUpdate struct {
+	Title       *string
+	Description *string
+	Done        *bool
+	Priority    *Priority
+	Due         *time.Time
+	// ClearDue removes the due date
+	ClearDue bool
+	// Tags replaces all tags
+	Tags *[]string
+	// Recurrence replaces the recurrence rule; "" stops the recurrence
+	Recurrence *string
+	// ParentID moves the task under another one; 0 makes it top-level
+	ParentID *int
+}
+
+// TaskOption sets an optional field of a new task
+type TaskOption func(*Task)
+
+func WithPriority(p Priority) TaskOption {
+	return func(t *Task) { t.Priority = p }
+}
+
+func WithDue(due time.Time) TaskOption {
+	return func(t *Task) { t.Due = &due }
+}
+
+func WithTags(tags ...string) TaskOption {
+	return func(t *Task) { t.Tags = tags }
+}
+
+// WithParent makes the task a subtask of the task with ID parentID
+func WithParent(parentID int) TaskOption {
+	return func(t *Task) { t.ParentID = parentID }
+}
+
+// WithRecurrence makes the task repeat by an RRULE such as "FREQ=DAILY"
+func WithRecurrence(rule string) TaskOption {
+	return func(t *Task) { t.Recurrence = rule }
+}
+
+// TaskManager is safe for concurrent use. Tasks it returns are copies;
+// changes to them take effect only through UpdateTask.
 type TaskManager struct {
+	mu     sync.RWMutex
 	tasks  map[int]*Task
 	nextID int
+	store  Store
+
+	// log records every change; durable is set when it is the source of
+	// truth, with the store only holding snapshots
+	log           EventLog
+	durable       bool
+	seq           int64
+	snapshotSeq   int64
+	snapshotEvery int64
+	undo, redo    []*Event
+}
+
+// Option configures OpenTaskManager
+type Option func(*TaskManager)
+
+// WithEventLog appends every change to log and replays the changes made
+// since the last snapshot when opening. The store then only saves a
+// snapshot every few events, see WithSnapshotInterval.
+func WithEventLog(log EventLog) Option {
+	return func(tm *TaskManager) {
+		tm.log = log
+		tm.durable = true
+	}
 }
 
+// WithSnapshotInterval saves a snapshot every n events when an event log
+// is used; the default is 100
+func WithSnapshotInterval(n int) Option {
+	return func(tm *TaskManager) { tm.snapshotEvery = int64(max(n, 1)) }
+}
+
+// NewTaskManager returns an empty TaskManager that keeps its last 100
+// events in memory for Undo, Redo and History
 func NewTaskManager() *TaskManager {
 	return &TaskManager{
-		tasks:  make(map[int]*Task),
-		nextID: 1,
+		tasks:         make(map[int]*Task),
+		nextID:        1,
+		log:           &MemoryEventLog{Limit: maxUndo},
+		snapshotEvery: 100,
+	}
+}
+
+// OpenTaskManager returns a TaskManager holding the tasks saved in store,
+// which then saves every change. A store with nothing saved yet gives an
+// empty TaskManager. store may be nil with WithEventLog, to rebuild the
+// tasks from the log alone.
+func OpenTaskManager(store Store, opts ...Option) (*TaskManager, error) {
+	tm := NewTaskManager()
+	tm.store = store
+	for _, opt := range opts {
+		opt(tm)
+	}
+
+	if store != nil {
+		snap, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		if snap != nil {
+			tm.restore(snap)
+		}
 	}
+	if tm.durable {
+		events, err := tm.log.Events(tm.seq)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if err := tm.replay(e); err != nil {
+				return nil, fmt.Errorf("taskmanager: %w: event %d: %v", ErrCorruptFile, e.Seq, err)
+			}
+		}
+	}
+	return tm, nil
 }
 
-func (tm *TaskManager) AddTask(title, description string) (*Task, error) {
+func (tm *TaskManager) AddTask(title, description string, opts ...TaskOption) (*Task, error) {
 	if title == "" {
 		return nil, ErrEmptyTitle
 	}
+	now := time.Now()
 	task := &Task{
-		ID:          tm.nextID,
 		Title:       title,
 		Description: description,
 		Done:        false,
-		CreatedAt:   time.Now(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(task)
+	}
+	if !task.Priority.valid() {
+		return nil, ErrInvalidPriority
 	}
-	tm.tasks[tm.nextID] = task
-	tm.nextID++
-	return task, nil
+	task.Tags = normalizeTags(task.Tags)
+	rule, err := normalizeRecurrence(task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	task.Recurrence = rule
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if task.ParentID != 0 {
+		parent, ok := tm.tasks[task.ParentID]
+		if !ok {
+			return nil, fmt.Errorf("parent %d: %w", task.ParentID, ErrTaskNotFound)
+		}
+		// A done parent has no open subtasks
+		if parent.Done {
+			return nil, fmt.Errorf("parent %d: %w", task.ParentID, ErrParentDone)
+		}
+	}
+	task.ID = tm.nextID
+	if err := tm.commit(EventAdd, tm.nextID+1, []*Task{task}, nil); err != nil {
+		return nil, err
+	}
+	return task.clone(), nil
 }
 
-func (tm *TaskManager) UpdateTask(id int, title, description string, done bool) error {
+// UpdateTask changes the fields set in u and returns the updated task.
+// Marking a task done sets CompletedAt; marking it not done clears it.
+// Marking a recurring task done also adds its next occurrence.
+func (tm *TaskManager) UpdateTask(id int, u TaskUpdate) (*Task, error) {
+	updated, _, err := tm.update(id, u)
+	return updated, err
+}
+
+// CompleteTask marks a task done and returns it along with the next
+// occurrence of a recurring task, or nil
+func (tm *TaskManager) CompleteTask(id int) (completed, next *Task, err error) {
+	done := true
+	return tm.update(id, TaskUpdate{Done: &done})
+}
+
+func (tm *TaskManager) update(id int, u TaskUpdate) (*Task, *Task, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
 	task, ok := tm.tasks[id]
 	if !ok {
-		return ErrTaskNotFound
+		return nil, nil, ErrTaskNotFound
 	}
-	if title == "" {
-		return ErrEmptyTitle
+	now := time.Now()
+	updated, err := task.apply(u, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.ParentID != nil && *u.ParentID != task.ParentID {
+		if err := tm.checkParent(id, *u.ParentID); err != nil {
+			return nil, nil, err
+		}
+		updated.ParentID = *u.ParentID
+	}
+	// Moving an open task under a done parent, or reopening a subtask of
+	// one, would leave the parent done with open subtasks
+	if !updated.Done && updated.ParentID != 0 && (updated.ParentID != task.ParentID || task.Done) && tm.tasks[updated.ParentID].Done {
+		return nil, nil, fmt.Errorf("parent %d: %w", updated.ParentID, ErrParentDone)
 	}
-	task.Title = title
-	task.Description = description
-	task.Done = done
-	return nil
+
+	put, nextID := []*Task{updated}, tm.nextID
+	var next *Task
+	if updated.Done && !task.Done {
+		if open := tm.openSubtasks(id); len(open) > 0 {
+			return nil, nil, fmt.Errorf("%w: %v", ErrOpenSubtasks, open)
+		}
+		if next = updated.nextOccurrence(now); next != nil {
+			next.ID = nextID
+			next.BlockedBy = slices.DeleteFunc(next.BlockedBy, func(b int) bool { _, ok := tm.tasks[b]; return !ok })
+			put, nextID = append(put, next), nextID+1
+		}
+	}
+
+	if err := tm.commit(EventUpdate, nextID, put, nil); err != nil {
+		return nil, nil, err
+	}
+	if next != nil {
+		next = next.clone()
+	}
+	return updated.clone(), next, nil
 }
 
-func (tm *TaskManager) DeleteTask(id int) error {
-	if _, ok := tm.tasks[id]; !ok {
-		return ErrTaskNotFound
+// apply returns a copy of t with u applied at time now
+func (t *Task) apply(u TaskUpdate, now time.Time) (*Task, error) {
+	updated := t.clone()
+	if u.Title != nil {
+		if *u.Title == "" {
+			return nil, ErrEmptyTitle
+		}
+		updated.Title = *u.Title
+	}
+	if u.Description != nil {
+		updated.Description = *u.Description
+	}
+	if u.Priority != nil {
+		if !u.Priority.valid() {
+			return nil, ErrInvalidPriority
+		}
+		updated.Priority = *u.Priority
+	}
+	if u.ClearDue {
+		updated.Due = nil
+	}
+	if u.Due != nil {
+		due := *u.Due
+		updated.Due = &due
+	}
+	if u.Tags != nil {
+		updated.Tags = normalizeTags(*u.Tags)
 	}
-	delete(tm.tasks, id)
-	return nil
+	if u.Recurrence != nil {
+		rule, err := normalizeRecurrence(*u.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+		updated.Recurrence = rule
+	}
+	if u.Done != nil && *u.Done != updated.Done {
+		updated.Done = *u.Done
+		updated.CompletedAt = nil
+		if updated.Done {
+			updated.CompletedAt = &now
+		}
+	}
+	updated.UpdatedAt = now
+	return updated, nil
+}
+
+// DeleteTask deletes a task that has no subtasks and blocks no other task;
+// see DeleteTaskWithPolicy
+func (tm *TaskManager) DeleteTask(id int) error {
+	return tm.DeleteTaskWithPolicy(id, DeleteRefuse)
 }
 
 func (tm *TaskManager) GetTask(id int) (*Task, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
 	task, ok := tm.tasks[id]
 	if !ok {
 		return nil, ErrTaskNotFound
 	}
-	return task, nil
+	return task.clone(), nil
+}
+
+func (t *Task) clone() *Task {
+	c := *t
+	c.Tags = slices.Clone(t.Tags)
+	c.BlockedBy = slices.Clone(t.BlockedBy)
+	if t.Due != nil {
+		due := *t.Due
+		c.Due = &due
+	}
+	if t.CompletedAt != nil {
+		completed := *t.CompletedAt
+		c.CompletedAt = &completed
+	}
+	return &c
+}
+
+// HasTag reports whether the task has tag, ignoring case
+func (t *Task) HasTag(tag string) bool {
+	return slices.Contains(t.Tags, strings.ToLower(strings.TrimSpace(tag)))
+}
+
+// Overdue reports whether the task is open and was due before now
+func (t *Task) Overdue(now time.Time) bool {
+	return !t.Done && t.Due != nil && t.Due.Before(now)
 }
 
-func (tm *TaskManager) ListTasks(filterDone *bool) []*Task {
-	var result []*Task
-	for _, task := range tm.tasks {
-		if filterDone == nil || task.Done == *filterDone {
-			result = append(result, task)
+// normalizeTags lowercases and trims tags, drops empty ones and duplicates
+// and sorts them
+func normalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
 		}
 	}
+	slices.Sort(result)
 	return result
 }
+
+// normalizeRecurrence checks an RRULE and returns it in canonical form
+func normalizeRecurrence(rule string) (string, error) {
+	if rule == "" {
+		return "", nil
+	}
+	r, err := ParseRecurrence(rule)
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
@@ -4,6 +4,10 @@ import (
 	"testing"
 )
 
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func TestNewTaskManager(t *testing.T) {
 	tm := NewTaskManager()
 	if tm == nil {
@@ -167,7 +171,7 @@ func TestUpdateTask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tm.UpdateTask(tt.id, tt.title, tt.description, tt.done)
+			_, err := tm.UpdateTask(tt.id, TaskUpdate{Title: &tt.title, Description: &tt.description, Done: &tt.done})
 
 			if tt.expectError {
 				if err == nil {
@@ -262,7 +266,7 @@ func TestListTasks(t *testing.T) {
 	_, _ = tm.AddTask("Task 3", "Description 3")
 
 	// Mark one task as done
-	tm.UpdateTask(task2.ID, task2.Title, task2.Description, true)
+	tm.UpdateTask(task2.ID, TaskUpdate{Done: ptr(true)})
 
 	tests := []struct {
 		name     string
@@ -288,7 +292,7 @@ func TestListTasks(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tasks := tm.ListTasks(tt.filter)
+			tasks := tm.ListTasks(Query{Done: tt.filter})
 			if len(tasks) != tt.expected {
 				t.Errorf("ListTasks() returned %d tasks, want %d", len(tasks), tt.expected)
 			}
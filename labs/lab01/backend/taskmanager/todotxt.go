@@ -0,0 +1,173 @@
+package taskmanager
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// todo.txt has priorities A to Z; the three levels map to the first three
+var todoTxtPriorities = map[Priority]string{PriorityHigh: "A", PriorityMedium: "B", PriorityLow: "C"}
+
+// writeTodoTxt writes one line per task:
+//
+//	x 2025-07-02 2025-07-01 Title +tag @context due:2025-07-05 id:3 parent:1 dep:2 rrule:FREQ=DAILY
+//
+// Completed tasks keep their priority as pri:A, as the format suggests
+func writeTodoTxt(w io.Writer, tasks []*Task) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range tasks {
+		var parts []string
+		if t.Done {
+			parts = append(parts, "x")
+			if t.CompletedAt != nil {
+				parts = append(parts, formatDate(t.CompletedAt), formatDate(&t.CreatedAt))
+			}
+		} else {
+			if letter, ok := todoTxtPriorities[t.Priority]; ok {
+				parts = append(parts, "("+letter+")")
+			}
+			parts = append(parts, formatDate(&t.CreatedAt))
+		}
+		parts = append(parts, t.Title)
+		for _, tag := range t.Tags {
+			tag = strings.ReplaceAll(tag, " ", "_")
+			if strings.HasPrefix(tag, "@") {
+				parts = append(parts, tag)
+			} else {
+				parts = append(parts, "+"+tag)
+			}
+		}
+		if t.Due != nil {
+			parts = append(parts, "due:"+formatDate(t.Due))
+		}
+		parts = append(parts, fmt.Sprintf("id:%d", t.ID))
+		if t.ParentID != 0 {
+			parts = append(parts, fmt.Sprintf("parent:%d", t.ParentID))
+		}
+		if len(t.BlockedBy) > 0 {
+			parts = append(parts, "dep:"+formatIDs(t.BlockedBy))
+		}
+		if t.Recurrence != "" {
+			parts = append(parts, "rrule:"+t.Recurrence)
+		}
+		if letter, ok := todoTxtPriorities[t.Priority]; ok && t.Done {
+			parts = append(parts, "pri:"+letter)
+		}
+		bw.WriteString(strings.Join(parts, " ") + "\n")
+	}
+	return bw.Flush()
+}
+
+func readTodoTxt(r io.Reader) ([]*record, []*LineError, error) {
+	var records []*record
+	var errs []*LineError
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		rec, err := todoTxtRecord(text)
+		if err != nil {
+			errs = append(errs, &LineError{Line: line, Err: err})
+			continue
+		}
+		rec.line = line
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("taskmanager: %w", err)
+	}
+	return records, errs, nil
+}
+
+func todoTxtRecord(line string) (*record, error) {
+	t := &Task{}
+	rec := &record{task: t}
+	words := strings.Fields(line)
+
+	date := func() *time.Time {
+		if len(words) == 0 {
+			return nil
+		}
+		d, err := time.ParseInLocation(time.DateOnly, words[0], time.Local)
+		if err != nil {
+			return nil
+		}
+		words = words[1:]
+		return &d
+	}
+	if words[0] == "x" {
+		t.Done = true
+		words = words[1:]
+		t.CompletedAt = date()
+	} else if p, ok := todoTxtPriority(words[0]); ok {
+		t.Priority = p
+		words = words[1:]
+	}
+	if created := date(); created != nil {
+		t.CreatedAt = *created
+	}
+
+	var title []string
+	for _, word := range words {
+		if strings.HasPrefix(word, "+") && len(word) > 1 {
+			t.Tags = append(t.Tags, word[1:])
+			continue
+		}
+		if strings.HasPrefix(word, "@") && len(word) > 1 {
+			t.Tags = append(t.Tags, word)
+			continue
+		}
+		key, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			title = append(title, word)
+			continue
+		}
+		switch key {
+		case "due":
+			due, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("invalid due date %q", value)
+			}
+			t.Due = &due
+		case "id":
+			rec.ref = value
+		case "parent":
+			rec.parent = value
+		case "dep":
+			rec.blockedBy = splitList(value)
+		case "rrule":
+			t.Recurrence = value
+		case "pri":
+			p, ok := todoTxtPriority("(" + value + ")")
+			if !ok {
+				return nil, fmt.Errorf("%w %q", ErrInvalidPriority, value)
+			}
+			t.Priority = p
+		default:
+			// Other key:value pairs, like a time of day, are part of the text
+			title = append(title, word)
+		}
+	}
+	t.Title = strings.Join(title, " ")
+	return rec, nil
+}
+
+// todoTxtPriority reads a priority such as "(B)"; letters past C are low
+func todoTxtPriority(s string) (Priority, bool) {
+	if len(s) != 3 || s[0] != '(' || s[2] != ')' || s[1] < 'A' || s[1] > 'Z' {
+		return PriorityNone, false
+	}
+	switch s[1] {
+	case 'A':
+		return PriorityHigh, true
+	case 'B':
+		return PriorityMedium, true
+	}
+	return PriorityLow, true
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"lab03-backend/models"
 	"lab03-backend/storage"
+	"lab03-backend/tracing"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,14 +16,16 @@ import (
 
 type Handler struct {
 	storage *storage.MemoryStorage
+	client  *http.Client
 }
 
 func NewHandler(s *storage.MemoryStorage) *Handler {
-	return &Handler{storage: s}
+	return &Handler{storage: s, client: tracing.NewClient()}
 }
 
 func (h *Handler) SetupRoutes() *mux.Router {
 	router := mux.NewRouter()
+	router.Use(tracing.Middleware())
 	// router.Use(corsMiddleware)
 
 	api := router.PathPrefix("/api").Subrouter()
@@ -197,12 +200,21 @@ func (h *Handler) ServeCatImage(w http.ResponseWriter, r *http.Request) {
 	code := vars["code"]
 	url := fmt.Sprintf("https://http.cat/%s", code)
 
-	resp, err := http.Get(url)
-	if err != nil || resp.StatusCode != 200 {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
 		http.Error(w, "Image not found", http.StatusNotFound)
 		return
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
 
 	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
 	w.WriteHeader(http.StatusOK)
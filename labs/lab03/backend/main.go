@@ -1,30 +1,35 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"lab03-backend/api"
 	"lab03-backend/storage"
+	"lab03-backend/tracing"
 
-	"github.com/rs/cors"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/pkg/cors"
 )
 
 func main() {
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+
 	storage := storage.NewMemoryStorage()
 	handler := api.NewHandler(storage)
 	router := handler.SetupRoutes()
 
-	c := cors.New(cors.Options{
-		AllowedOrigins:       []string{"http://localhost:3000"}, // 👈 именно это значение ждёт тест
-		AllowedMethods:       []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:       []string{"Content-Type"},
-		ExposedHeaders:       []string{"Content-Type", "Access-Control-Allow-Origin", "Access-Control-Allow-Methods"},
-		AllowCredentials:     true,
-		OptionsPassthrough:   false,
-		OptionsSuccessStatus: 200,
-	})
+	c, err := newCORS()
+	if err != nil {
+		log.Fatal("Failed to configure CORS:", err)
+	}
 
 	srv := &http.Server{
 		Addr:         ":8080",
@@ -34,6 +39,56 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	log.Println("Server started on :8080")
-	log.Fatal(srv.ListenAndServe())
+	go func() {
+		log.Println("Server started on :8080")
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// Wait for an interrupt, then drain requests and flush traces
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	forced := false
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+		forced = true
+	}
+
+	// The drain may have used up ctx, and the spans of stuck requests are
+	// the ones worth keeping, so the flush gets its own deadline
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer flushCancel()
+
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+
+	if forced {
+		cancel()
+		flushCancel()
+		log.Fatal("Server exited before all requests completed")
+	}
+	log.Println("Server exited")
+}
+
+// newCORS returns the CORS policy engine of the API, the same one the main
+// backend uses
+func newCORS() (*cors.Engine, error) {
+	return cors.New(cors.Config{
+		Default: cors.Policy{
+			AllowedOrigins:   []string{"http://localhost:3000"}, // 👈 именно это значение ждёт тест
+			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Content-Type"},
+			ExposedHeaders:   []string{"Content-Type"},
+			AllowCredentials: true,
+			PreflightStatus:  http.StatusOK,
+		},
+	}, nil)
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"lab03-backend/api"
+	"lab03-backend/storage"
+)
+
+func newTestServer(t *testing.T) http.Handler {
+	t.Helper()
+	c, err := newCORS()
+	if err != nil {
+		t.Fatalf("newCORS() failed: %v", err)
+	}
+	return c.Handler(api.NewHandler(storage.NewMemoryStorage()).SetupRoutes())
+}
+
+func TestCORS(t *testing.T) {
+	srv := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/messages", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+		t.Errorf("Expected the origin echoed, got %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Expected credentials allowed, got %q", got)
+	}
+	if !slices.Contains(rr.Header().Values("Vary"), "Origin") {
+		t.Errorf("Expected Vary: Origin, got %v", rr.Header().Values("Vary"))
+	}
+
+	req.Header.Set("Origin", "https://attacker.test")
+	rr = httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no CORS headers for another origin, got %q", got)
+	}
+	if !slices.Contains(rr.Header().Values("Vary"), "Origin") {
+		t.Errorf("Expected Vary: Origin for another origin, got %v", rr.Header().Values("Vary"))
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	srv := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/messages", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+		t.Errorf("Expected the origin echoed, got %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type" {
+		t.Errorf("Expected Content-Type allowed, got %q", got)
+	}
+}
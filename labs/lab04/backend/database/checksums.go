@@ -0,0 +1,239 @@
+package database
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+// ChecksumTable records the SHA-256 checksum of every applied migration file
+const ChecksumTable = "goose_migration_checksums"
+
+// ErrMigrationChanged is returned in strict mode when an applied migration
+// file has been edited since it was applied
+var ErrMigrationChanged = errors.New("applied migration has changed")
+
+// ChangedMigration is an applied migration whose file no longer matches the
+// checksum recorded when it was applied
+type ChangedMigration struct {
+	Version  int64
+	Source   string
+	Recorded string
+	Current  string
+}
+
+// ChangedMigrations compares the migration files in dir with the checksums
+// recorded for them. It only reads from the database.
+func ChangedMigrations(db *sql.DB, dir string) ([]ChangedMigration, error) {
+	migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect migrations: %v", err)
+	}
+	recorded, err := recordedChecksums(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []ChangedMigration
+	for _, m := range migrations {
+		sum, ok := recorded[m.Version]
+		if !ok {
+			continue
+		}
+		current, err := fileChecksum(m.Source)
+		if err != nil {
+			return nil, err
+		}
+		if current != sum {
+			changed = append(changed, ChangedMigration{Version: m.Version, Source: m.Source, Recorded: sum, Current: current})
+		}
+	}
+	return changed, nil
+}
+
+// recordChecksums stores the checksum of every applied migration that has
+// none yet, and replaces it for migrations newly applied in this run
+func recordChecksums(db *sql.DB, dir string, before map[int64]bool) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + ChecksumTable + ` (
+		version_id INTEGER PRIMARY KEY,
+		checksum TEXT NOT NULL,
+		recorded_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("failed to create checksum table: %v", err)
+	}
+
+	migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("failed to collect migrations: %v", err)
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			continue
+		}
+		sum, err := fileChecksum(m.Source)
+		if err != nil {
+			return err
+		}
+		query := `INSERT INTO ` + ChecksumTable + ` (version_id, checksum) VALUES (?, ?)
+			ON CONFLICT (version_id) DO NOTHING`
+		if !before[m.Version] {
+			// Rolled back and reapplied: the file may legitimately differ now
+			query = `INSERT INTO ` + ChecksumTable + ` (version_id, checksum) VALUES (?, ?)
+				ON CONFLICT (version_id) DO UPDATE SET checksum = excluded.checksum, recorded_at = CURRENT_TIMESTAMP`
+		}
+		if _, err := db.Exec(query, m.Version, sum); err != nil {
+			return fmt.Errorf("failed to record checksum of %s: %v", filepath.Base(m.Source), err)
+		}
+	}
+	return nil
+}
+
+// recordedChecksums reads the checksum table; a missing table means none were recorded
+func recordedChecksums(db *sql.DB) (map[int64]string, error) {
+	sums := make(map[int64]string)
+	exists, err := tableExists(db, ChecksumTable)
+	if err != nil || !exists {
+		return sums, err
+	}
+
+	rows, err := db.Query(`SELECT version_id, checksum FROM ` + ChecksumTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksums: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int64
+		var sum string
+		if err := rows.Scan(&version, &sum); err != nil {
+			return nil, fmt.Errorf("failed to read checksums: %v", err)
+		}
+		sums[version] = sum
+	}
+	return sums, rows.Err()
+}
+
+// appliedVersions reads the goose version table without creating it.
+// The latest row of each version decides whether it is applied.
+func appliedVersions(db *sql.DB) (map[int64]bool, error) {
+	applied := make(map[int64]bool)
+	exists, err := tableExists(db, goose.TableName())
+	if err != nil || !exists {
+		return applied, err
+	}
+
+	rows, err := db.Query(`SELECT version_id, is_applied FROM ` + goose.TableName() + ` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration versions: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return nil, fmt.Errorf("failed to read migration versions: %v", err)
+		}
+		if version > 0 {
+			applied[version] = isApplied
+		}
+	}
+	return applied, rows.Err()
+}
+
+func tableExists(db *sql.DB, name string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for table %s: %v", name, err)
+	}
+	return n > 0, nil
+}
+
+// fileChecksum returns the hex-encoded SHA-256 of a migration file
+func fileChecksum(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read migration: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeDryRun prints the SQL goose.Up would execute for the pending
+// migrations in dir, in order, each in the transaction goose runs it in
+func writeDryRun(db *sql.DB, dir string, w io.Writer) error {
+	migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("failed to collect migrations: %v", err)
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+	var current int64
+	for version, ok := range applied {
+		if ok && version > current {
+			current = version
+		}
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		up, err := upSection(m.Source)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "-- up %s\nBEGIN;\n%s\nINSERT INTO %s (version_id, is_applied) VALUES (%d, true);\nCOMMIT;\n\n",
+			filepath.Base(m.Source), up, goose.TableName(), m.Version)
+		pending++
+	}
+	_, err = fmt.Fprintf(w, "-- %d migration(s) would be applied\n", pending)
+	return err
+}
+
+// upSection returns the SQL of a migration file's Up section without the
+// goose annotations
+func upSection(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read migration: %v", err)
+	}
+	defer f.Close()
+
+	var lines []string
+	inUp := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "-- +goose Up"):
+			inUp = true
+		case strings.HasPrefix(trimmed, "-- +goose Down"):
+			inUp = false
+		case strings.HasPrefix(trimmed, "-- +goose"):
+		case inUp:
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read migration: %v", err)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
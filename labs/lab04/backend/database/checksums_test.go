@@ -0,0 +1,99 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openMigrationTestDB opens a fresh SQLite database and copies the
+// migrations into a temporary directory that the test may edit
+func openMigrationTestDB(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	if err := os.Mkdir(migrationsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(MigrationsDir, "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No migrations found in %s: %v", MigrationsDir, err)
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(migrationsDir, filepath.Base(f)), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, migrationsDir
+}
+
+func TestRunMigrationsDryRun(t *testing.T) {
+	db, dir := openMigrationTestDB(t)
+
+	var out bytes.Buffer
+	if err := RunMigrationsWithOptions(db, MigrationOptions{Dir: dir, DryRun: true, Out: &out}); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	script := out.String()
+	users := strings.Index(script, "CREATE TABLE users")
+	posts := strings.Index(script, "CREATE TABLE posts")
+	if users < 0 || posts < users {
+		t.Errorf("Expected users before posts in the dry run, got:\n%s", script)
+	}
+	if strings.Contains(script, "DROP TABLE") || strings.Contains(script, "+goose") {
+		t.Errorf("Expected only the up SQL without annotations, got:\n%s", script)
+	}
+	if exists, _ := tableExists(db, "users"); exists {
+		t.Error("Dry run must not change the database")
+	}
+}
+
+func TestRunMigrationsDetectsChangedFiles(t *testing.T) {
+	db, dir := openMigrationTestDB(t)
+
+	if err := RunMigrationsWithOptions(db, MigrationOptions{Dir: dir, Strict: true}); err != nil {
+		t.Fatalf("RunMigrations failed: %v", err)
+	}
+	changed, err := ChangedMigrations(db, dir)
+	if err != nil || len(changed) != 0 {
+		t.Fatalf("Expected no changes after migrating, got %v, %v", changed, err)
+	}
+
+	// Edit an applied migration
+	files, _ := filepath.Glob(filepath.Join(dir, "*.sql"))
+	f, err := os.OpenFile(files[0], os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("\n-- edited\n")
+	f.Close()
+
+	changed, err = ChangedMigrations(db, dir)
+	if err != nil {
+		t.Fatalf("ChangedMigrations failed: %v", err)
+	}
+	if len(changed) != 1 || changed[0].Source != files[0] || changed[0].Recorded == changed[0].Current {
+		t.Fatalf("Expected %s to be reported as changed, got %+v", files[0], changed)
+	}
+
+	if err := RunMigrationsWithOptions(db, MigrationOptions{Dir: dir, Strict: true}); !errors.Is(err, ErrMigrationChanged) {
+		t.Errorf("Expected strict mode to fail with ErrMigrationChanged, got %v", err)
+	}
+	if err := RunMigrationsWithOptions(db, MigrationOptions{Dir: dir}); err != nil {
+		t.Errorf("Expected a warning only outside strict mode, got %v", err)
+	}
+}
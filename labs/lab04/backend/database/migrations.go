@@ -3,30 +3,85 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/pressly/goose/v3"
 )
 
+// MigrationsDir is the migrations directory, relative to the backend directory
+const MigrationsDir = "../migrations"
+
+// MigrationOptions configures RunMigrationsWithOptions
+type MigrationOptions struct {
+	// Dir holds the migration files; defaults to MigrationsDir
+	Dir string
+	// Strict refuses to migrate when an applied migration file has changed
+	// since it was applied; otherwise a warning is logged
+	Strict bool
+	// DryRun prints the SQL of the pending migrations instead of running it
+	DryRun bool
+	// Out receives the dry-run SQL; defaults to os.Stdout
+	Out io.Writer
+}
+
 // RunMigrations runs database migrations using goose
 func RunMigrations(db *sql.DB) error {
+	return RunMigrationsWithOptions(db, MigrationOptions{})
+}
+
+// RunMigrationsWithOptions runs database migrations using goose and records
+// the checksum of every applied migration file, see ChangedMigrations
+func RunMigrationsWithOptions(db *sql.DB, opts MigrationOptions) error {
 	if db == nil {
 		return fmt.Errorf("database connection cannot be nil")
 	}
+	if opts.Dir == "" {
+		opts.Dir = MigrationsDir
+	}
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
 
 	// Set goose dialect for SQLite
 	if err := goose.SetDialect("sqlite3"); err != nil {
 		return fmt.Errorf("failed to set goose dialect: %v", err)
 	}
 
-	// Get path to migrations directory (relative to backend directory)
-	migrationsDir := "../migrations"
+	// Detect edits to migrations that were already applied
+	changed, err := ChangedMigrations(db, opts.Dir)
+	if err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		names := make([]string, len(changed))
+		for i, c := range changed {
+			names[i] = filepath.Base(c.Source)
+		}
+		if opts.Strict {
+			return fmt.Errorf("%w: %s", ErrMigrationChanged, strings.Join(names, ", "))
+		}
+		log.Printf("⚠️ Applied migrations have changed since they were applied: %s", strings.Join(names, ", "))
+	}
+
+	if opts.DryRun {
+		return writeDryRun(db, opts.Dir, opts.Out)
+	}
+
+	before, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
 
 	// Run migrations from the migrations directory
-	if err := goose.Up(db, migrationsDir); err != nil {
+	if err := goose.Up(db, opts.Dir); err != nil {
 		return fmt.Errorf("failed to run migrations: %v", err)
 	}
 
-	return nil
+	return recordChecksums(db, opts.Dir, before)
 }
 
 // TODO: Implement this function
@@ -0,0 +1,78 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+var (
+	firstNames = []string{
+		"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace", "Heidi",
+		"Ivan", "Judy", "Mallory", "Niaj", "Olivia", "Peggy", "Rupert", "Sybil",
+		"Trent", "Uma", "Victor", "Walter",
+	}
+	lastNames = []string{
+		"Anderson", "Brown", "Chen", "Davis", "Evans", "Fischer", "Garcia", "Hughes",
+		"Ivanova", "Jones", "Kim", "Lopez", "Miller", "Novak", "Petrov", "Smith",
+	}
+	words = []string{
+		"database", "query", "index", "schema", "migration", "transaction", "table",
+		"row", "column", "join", "cache", "backup", "replica", "cursor", "driver",
+		"connection", "pool", "lock", "commit", "rollback", "constraint", "trigger",
+	}
+	fakeCategories = []Category{
+		{Ref: "fake-tech", Name: "Fake Technology", Description: "Generated posts about technology", Color: "#007bff"},
+		{Ref: "fake-databases", Name: "Fake Databases", Description: "Generated posts about storage", Color: "#28a745"},
+		{Ref: "fake-go", Name: "Fake Go", Description: "Generated posts about Go", Color: "#00add8"},
+		{Ref: "fake-news", Name: "Fake News", Description: "Generated announcements", Color: "#dc3545"},
+	}
+)
+
+// Generate returns a fixture of fake users and posts, plus a fixed set of
+// "Fake ..." categories that do not clash with hand-written fixtures. The
+// same seed always produces the same fixture, so it can be applied
+// repeatedly for load testing.
+func Generate(seed int64, users, posts int) *Fixture {
+	rng := rand.New(rand.NewPCG(uint64(seed), 0x5eed))
+	f := &Fixture{Categories: append([]Category(nil), fakeCategories...)}
+
+	for i := range users {
+		first := firstNames[rng.IntN(len(firstNames))]
+		last := lastNames[rng.IntN(len(lastNames))]
+		f.Users = append(f.Users, User{
+			Ref:   fmt.Sprintf("fake-user-%d", i+1),
+			Name:  first + " " + last,
+			Email: fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(first), strings.ToLower(last), i+1),
+		})
+	}
+	if users == 0 {
+		return f
+	}
+
+	for i := range posts {
+		var categories []string
+		for _, c := range rng.Perm(len(fakeCategories))[:rng.IntN(3)] {
+			categories = append(categories, fakeCategories[c].Ref)
+		}
+		f.Posts = append(f.Posts, Post{
+			Ref:        fmt.Sprintf("fake-post-%d", i+1),
+			User:       f.Users[rng.IntN(users)].Ref,
+			Title:      fmt.Sprintf("%s #%d", sentence(rng, 3+rng.IntN(4)), i+1),
+			Content:    sentence(rng, 20+rng.IntN(40)) + ".",
+			Published:  rng.IntN(4) != 0,
+			Categories: categories,
+		})
+	}
+	return f
+}
+
+// sentence joins n random words and capitalizes the first one
+func sentence(rng *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = words[rng.IntN(len(words))]
+	}
+	s := strings.Join(parts, " ")
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,176 @@
+// Package seed loads declarative fixtures into the users, categories, posts
+// and post_categories tables.
+//
+// A fixture is a YAML or JSON document listing rows per table. Rows may be
+// given a symbolic ref, which other rows use to point at them:
+//
+//	users:
+//	  - ref: alice
+//	    name: Alice
+//	    email: alice@example.com
+//	categories:
+//	  - ref: go
+//	    name: Go
+//	posts:
+//	  - user: alice
+//	    title: Hello, World
+//	    categories: [go]
+//
+// Rows are upserted by their natural key (users by email, categories by
+// name, posts by author and title), so applying a fixture twice leaves the
+// database unchanged.
+package seed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is a set of rows to load
+type Fixture struct {
+	Users      []User     `json:"users" yaml:"users"`
+	Categories []Category `json:"categories" yaml:"categories"`
+	Posts      []Post     `json:"posts" yaml:"posts"`
+}
+
+// User is a row of the users table, keyed by email
+type User struct {
+	Ref   string `json:"ref,omitempty" yaml:"ref,omitempty"`
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+}
+
+// Category is a row of the categories table, keyed by name
+type Category struct {
+	Ref         string `json:"ref,omitempty" yaml:"ref,omitempty"`
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Color       string `json:"color,omitempty" yaml:"color,omitempty"`
+	// Active defaults to true
+	Active *bool `json:"active,omitempty" yaml:"active,omitempty"`
+}
+
+// Post is a row of the posts table, keyed by author and title. User and
+// Categories hold refs of users and categories.
+type Post struct {
+	Ref        string   `json:"ref,omitempty" yaml:"ref,omitempty"`
+	User       string   `json:"user" yaml:"user"`
+	Title      string   `json:"title" yaml:"title"`
+	Content    string   `json:"content,omitempty" yaml:"content,omitempty"`
+	Published  bool     `json:"published,omitempty" yaml:"published,omitempty"`
+	Categories []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+}
+
+// LoadFile reads a fixture from a .yaml, .yml or .json file
+func LoadFile(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %v", err)
+	}
+
+	f := &Fixture{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(f)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(f)
+	default:
+		return nil, fmt.Errorf("unsupported fixture format %q", ext)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse fixture %s: %v", path, err)
+	}
+	return f, nil
+}
+
+// Merge combines fixtures into one, in order
+func Merge(fixtures ...*Fixture) *Fixture {
+	merged := &Fixture{}
+	for _, f := range fixtures {
+		merged.Users = append(merged.Users, f.Users...)
+		merged.Categories = append(merged.Categories, f.Categories...)
+		merged.Posts = append(merged.Posts, f.Posts...)
+	}
+	return merged
+}
+
+// Validate checks required fields, duplicate refs and natural keys, and
+// that every reference points at a row of the fixture
+func (f *Fixture) Validate() error {
+	userRefs := make(map[string]bool)
+	emails := make(map[string]bool)
+	for i, u := range f.Users {
+		if u.Name == "" || u.Email == "" {
+			return fmt.Errorf("users[%d]: name and email are required", i)
+		}
+		if emails[strings.ToLower(u.Email)] {
+			return fmt.Errorf("users[%d]: duplicate email %q", i, u.Email)
+		}
+		emails[strings.ToLower(u.Email)] = true
+		if err := addRef(userRefs, "users", i, u.Ref); err != nil {
+			return err
+		}
+	}
+
+	categoryRefs := make(map[string]bool)
+	names := make(map[string]bool)
+	for i, c := range f.Categories {
+		if c.Name == "" {
+			return fmt.Errorf("categories[%d]: name is required", i)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("categories[%d]: duplicate name %q", i, c.Name)
+		}
+		names[c.Name] = true
+		if err := addRef(categoryRefs, "categories", i, c.Ref); err != nil {
+			return err
+		}
+	}
+
+	postRefs := make(map[string]bool)
+	titles := make(map[[2]string]bool)
+	for i, p := range f.Posts {
+		if p.User == "" || p.Title == "" {
+			return fmt.Errorf("posts[%d]: user and title are required", i)
+		}
+		if titles[[2]string{p.User, p.Title}] {
+			return fmt.Errorf("posts[%d]: duplicate title %q for user %q", i, p.Title, p.User)
+		}
+		titles[[2]string{p.User, p.Title}] = true
+		if !userRefs[p.User] {
+			return fmt.Errorf("posts[%d]: unknown user ref %q", i, p.User)
+		}
+		for _, c := range p.Categories {
+			if !categoryRefs[c] {
+				return fmt.Errorf("posts[%d]: unknown category ref %q", i, c)
+			}
+		}
+		if err := addRef(postRefs, "posts", i, p.Ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addRef(refs map[string]bool, table string, i int, ref string) error {
+	if ref == "" {
+		return nil
+	}
+	if refs[ref] {
+		return fmt.Errorf("%s[%d]: duplicate ref %q", table, i, ref)
+	}
+	refs[ref] = true
+	return nil
+}
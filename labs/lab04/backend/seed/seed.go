@@ -0,0 +1,209 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Result reports what Apply did and the IDs of the rows with refs
+type Result struct {
+	Users      map[string]int64 // user ref -> users.id
+	Categories map[string]int64 // category ref -> categories.id
+	Posts      map[string]int64 // post ref -> posts.id
+
+	Inserted  int
+	Updated   int
+	Unchanged int
+}
+
+func (r *Result) count(inserted, updated bool) {
+	switch {
+	case inserted:
+		r.Inserted++
+	case updated:
+		r.Updated++
+	default:
+		r.Unchanged++
+	}
+}
+
+// Apply upserts the rows of the fixtures in a single transaction. Refs are
+// shared between the fixtures, so a later fixture may point at rows of an
+// earlier one. Soft-deleted rows matching a fixture row are restored.
+func Apply(ctx context.Context, db *sql.DB, fixtures ...*Fixture) (*Result, error) {
+	f := Merge(fixtures...)
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fixture: %v", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	res := &Result{
+		Users:      make(map[string]int64),
+		Categories: make(map[string]int64),
+		Posts:      make(map[string]int64),
+	}
+	for _, u := range f.Users {
+		id, err := upsertUser(ctx, tx, u, res)
+		if err != nil {
+			return nil, err
+		}
+		if u.Ref != "" {
+			res.Users[u.Ref] = id
+		}
+	}
+	for _, c := range f.Categories {
+		id, err := upsertCategory(ctx, tx, c, res)
+		if err != nil {
+			return nil, err
+		}
+		if c.Ref != "" {
+			res.Categories[c.Ref] = id
+		}
+	}
+	for _, p := range f.Posts {
+		id, err := upsertPost(ctx, tx, p, res.Users[p.User], res)
+		if err != nil {
+			return nil, err
+		}
+		if p.Ref != "" {
+			res.Posts[p.Ref] = id
+		}
+		for _, ref := range p.Categories {
+			if err := linkCategory(ctx, tx, id, res.Categories[ref], res); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit fixtures: %v", err)
+	}
+	return res, nil
+}
+
+func upsertUser(ctx context.Context, tx *sql.Tx, u User, res *Result) (int64, error) {
+	email := strings.ToLower(strings.TrimSpace(u.Email))
+	var id int64
+	var name string
+	var deleted bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT id, name, deleted_at IS NOT NULL FROM users WHERE email = ?`, email,
+	).Scan(&id, &name, &deleted)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result, err := tx.ExecContext(ctx, `INSERT INTO users (name, email) VALUES (?, ?)`, u.Name, email)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert user %s: %v", email, err)
+		}
+		res.count(true, false)
+		return result.LastInsertId()
+	case err != nil:
+		return 0, fmt.Errorf("failed to look up user %s: %v", email, err)
+	}
+
+	changed := name != u.Name || deleted
+	if changed {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE users SET name = ?, deleted_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, u.Name, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update user %s: %v", email, err)
+		}
+	}
+	res.count(false, changed)
+	return id, nil
+}
+
+func upsertCategory(ctx context.Context, tx *sql.Tx, c Category, res *Result) (int64, error) {
+	active := c.Active == nil || *c.Active
+	var id int64
+	var description, color sql.NullString
+	var isActive, deleted bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT id, description, color, active, deleted_at IS NOT NULL FROM categories WHERE name = ?`, c.Name,
+	).Scan(&id, &description, &color, &isActive, &deleted)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO categories (name, description, color, active) VALUES (?, ?, ?, ?)`,
+			c.Name, c.Description, c.Color, active)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert category %s: %v", c.Name, err)
+		}
+		res.count(true, false)
+		return result.LastInsertId()
+	case err != nil:
+		return 0, fmt.Errorf("failed to look up category %s: %v", c.Name, err)
+	}
+
+	changed := description.String != c.Description || color.String != c.Color || isActive != active || deleted
+	if changed {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE categories SET description = ?, color = ?, active = ?, deleted_at = NULL,
+			 updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			c.Description, c.Color, active, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update category %s: %v", c.Name, err)
+		}
+	}
+	res.count(false, changed)
+	return id, nil
+}
+
+func upsertPost(ctx context.Context, tx *sql.Tx, p Post, userID int64, res *Result) (int64, error) {
+	var id int64
+	var content sql.NullString
+	var published, deleted bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT id, content, published, deleted_at IS NOT NULL FROM posts WHERE user_id = ? AND title = ?
+		 ORDER BY id LIMIT 1`, userID, p.Title,
+	).Scan(&id, &content, &published, &deleted)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO posts (user_id, title, content, published) VALUES (?, ?, ?, ?)`,
+			userID, p.Title, p.Content, p.Published)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert post %q: %v", p.Title, err)
+		}
+		res.count(true, false)
+		return result.LastInsertId()
+	case err != nil:
+		return 0, fmt.Errorf("failed to look up post %q: %v", p.Title, err)
+	}
+
+	changed := content.String != p.Content || published != p.Published || deleted
+	if changed {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE posts SET content = ?, published = ?, deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+			 WHERE id = ?`,
+			p.Content, p.Published, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update post %q: %v", p.Title, err)
+		}
+	}
+	res.count(false, changed)
+	return id, nil
+}
+
+func linkCategory(ctx context.Context, tx *sql.Tx, postID, categoryID int64, res *Result) error {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO post_categories (post_id, category_id) VALUES (?, ?) ON CONFLICT DO NOTHING`,
+		postID, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to link post %d to category %d: %v", postID, categoryID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to link post %d to category %d: %v", postID, categoryID, err)
+	}
+	res.count(n > 0, false)
+	return nil
+}
@@ -0,0 +1,184 @@
+package seed_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lab04-backend/database"
+	"lab04-backend/seed"
+	"lab04-backend/seed/seedtest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+func count(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestLoadResolvesRefs(t *testing.T) {
+	db := openTestDB(t)
+	res := seedtest.Load(t, db, "testdata/blog.yaml", "testdata/extra.json")
+
+	for table, want := range map[string]int{"users": 3, "categories": 2, "posts": 3, "post_categories": 3} {
+		if got := count(t, db, table); got != want {
+			t.Errorf("Expected %d rows in %s, got %d", want, table, got)
+		}
+	}
+
+	var author int64
+	if err := db.QueryRow(`SELECT user_id FROM posts WHERE id = ?`, res.Posts["hello"]).Scan(&author); err != nil {
+		t.Fatal(err)
+	}
+	if author != res.Users["alice"] {
+		t.Errorf("Expected hello to belong to alice (%d), got %d", res.Users["alice"], author)
+	}
+
+	var linked int
+	db.QueryRow(`SELECT COUNT(*) FROM post_categories pc JOIN posts p ON p.id = pc.post_id
+		WHERE p.title = 'Reply' AND pc.category_id = ?`, res.Categories["go"]).Scan(&linked)
+	if linked != 1 {
+		t.Errorf("Expected the JSON fixture's post to be linked to the YAML fixture's category")
+	}
+}
+
+func TestApplyIsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+	first := seedtest.Load(t, db, "testdata/blog.yaml")
+	if first.Inserted != 8 {
+		t.Errorf("Expected 8 inserted rows, got %d", first.Inserted)
+	}
+
+	second := seedtest.Load(t, db, "testdata/blog.yaml")
+	if second.Inserted != 0 || second.Updated != 0 || second.Unchanged != 8 {
+		t.Errorf("Expected 8 unchanged rows, got %+v", second)
+	}
+	if !reflect.DeepEqual(first.Users, second.Users) || !reflect.DeepEqual(first.Posts, second.Posts) {
+		t.Errorf("Expected the same IDs on the second run")
+	}
+}
+
+func TestApplyUpdatesAndRestores(t *testing.T) {
+	db := openTestDB(t)
+	res := seedtest.Load(t, db, "testdata/blog.yaml")
+
+	db.Exec(`UPDATE users SET name = 'Renamed' WHERE id = ?`, res.Users["alice"])
+	db.Exec(`UPDATE posts SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?`, res.Posts["draft"])
+
+	again := seedtest.Load(t, db, "testdata/blog.yaml")
+	if again.Updated != 2 {
+		t.Errorf("Expected 2 updated rows, got %+v", again)
+	}
+	var name string
+	db.QueryRow(`SELECT name FROM users WHERE id = ?`, res.Users["alice"]).Scan(&name)
+	if name != "Alice" {
+		t.Errorf("Expected name Alice, got %s", name)
+	}
+	var deleted sql.NullString
+	db.QueryRow(`SELECT deleted_at FROM posts WHERE id = ?`, res.Posts["draft"]).Scan(&deleted)
+	if deleted.Valid {
+		t.Errorf("Expected the soft-deleted post to be restored")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture seed.Fixture
+		wantErr string
+	}{
+		{
+			name:    "missing email",
+			fixture: seed.Fixture{Users: []seed.User{{Name: "A"}}},
+			wantErr: "name and email are required",
+		},
+		{
+			name: "duplicate email",
+			fixture: seed.Fixture{Users: []seed.User{
+				{Name: "A", Email: "a@example.com"},
+				{Name: "B", Email: "A@example.com"},
+			}},
+			wantErr: "duplicate email",
+		},
+		{
+			name:    "unknown user",
+			fixture: seed.Fixture{Posts: []seed.Post{{User: "nobody", Title: "T"}}},
+			wantErr: "unknown user ref",
+		},
+		{
+			name: "unknown category",
+			fixture: seed.Fixture{
+				Users: []seed.User{{Ref: "a", Name: "A", Email: "a@example.com"}},
+				Posts: []seed.Post{{User: "a", Title: "T", Categories: []string{"missing"}}},
+			},
+			wantErr: "unknown category ref",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fixture.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestApplyRejectsInvalidFixture(t *testing.T) {
+	db := openTestDB(t)
+	f := &seed.Fixture{Posts: []seed.Post{{User: "nobody", Title: "T"}}}
+	if _, err := seed.Apply(context.Background(), db, f); err == nil {
+		t.Fatal("Expected an error for an unknown user ref")
+	}
+	if got := count(t, db, "posts"); got != 0 {
+		t.Errorf("Expected no posts, got %d", got)
+	}
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	a := seed.Generate(42, 10, 50)
+	b := seed.Generate(42, 10, 50)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("Expected the same fixture for the same seed")
+	}
+	if c := seed.Generate(43, 10, 50); reflect.DeepEqual(a, c) {
+		t.Error("Expected a different fixture for a different seed")
+	}
+	if len(a.Users) != 10 || len(a.Posts) != 50 {
+		t.Errorf("Expected 10 users and 50 posts, got %d and %d", len(a.Users), len(a.Posts))
+	}
+
+	db := openTestDB(t)
+	if _, err := seed.Apply(context.Background(), db, a); err != nil {
+		t.Fatalf("Failed to apply generated fixture: %v", err)
+	}
+	res, err := seed.Apply(context.Background(), db, b)
+	if err != nil {
+		t.Fatalf("Failed to reapply generated fixture: %v", err)
+	}
+	if res.Inserted != 0 || res.Updated != 0 {
+		t.Errorf("Expected reapplying the same seed to change nothing, got %+v", res)
+	}
+}
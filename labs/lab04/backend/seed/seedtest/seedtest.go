@@ -0,0 +1,32 @@
+// Package seedtest loads fixtures from Go tests
+package seedtest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"lab04-backend/seed"
+)
+
+// Load applies the fixture files to db and fails the test on any error.
+// The database must already be migrated.
+//
+//	res := seedtest.Load(t, db, "testdata/blog.yaml")
+//	aliceID := res.Users["alice"]
+func Load(t testing.TB, db *sql.DB, paths ...string) *seed.Result {
+	t.Helper()
+	fixtures := make([]*seed.Fixture, 0, len(paths))
+	for _, path := range paths {
+		f, err := seed.LoadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to load fixture: %v", err)
+		}
+		fixtures = append(fixtures, f)
+	}
+	res, err := seed.Apply(context.Background(), db, fixtures...)
+	if err != nil {
+		t.Fatalf("Failed to apply fixtures: %v", err)
+	}
+	return res
+}